@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 
@@ -51,12 +53,71 @@ func main() {
 	
 	// Create data directory for watchlists
 	dataDir := filepath.Join(currentDir, "..", "data")
-	watchlistService := services.NewWatchlistService(dataDir, appLogger)
+	watchlistService := services.NewWatchlistService(dataDir, movieService, appLogger)
+	if err := watchlistService.MigrateDataDir(); err != nil {
+		appLogger.Error("Error migrating watchlist data: %v", err)
+		return
+	}
+	if scale := os.Getenv("RATING_SCALE"); scale != "" {
+		value, err := strconv.Atoi(scale)
+		if err == nil {
+			err = watchlistService.SetRatingScale(value)
+		}
+		if err != nil {
+			appLogger.Warning("Invalid RATING_SCALE %q, using half stars out of 5", scale)
+		}
+	}
+	trashRetention := durationFromEnv("TRASH_RETENTION", 30*24*time.Hour, appLogger)
+	watchlistService.SetTrashRetention(trashRetention)
+	if count := os.Getenv("VERSION_RETENTION_COUNT"); count != "" || os.Getenv("VERSION_RETENTION_AGE") != "" {
+		maxVersions := 50
+		if count != "" {
+			if n, err := strconv.Atoi(count); err == nil {
+				maxVersions = n
+			} else {
+				appLogger.Warning("Invalid VERSION_RETENTION_COUNT %q, using default %d", count, maxVersions)
+			}
+		}
+		maxAge := durationFromEnv("VERSION_RETENTION_AGE", 90*24*time.Hour, appLogger)
+		if err := watchlistService.SetVersionRetention(maxVersions, maxAge); err != nil {
+			appLogger.Warning("Invalid version retention: %v, using defaults", err)
+		}
+	}
 	exportService := services.NewExportService(appLogger)
+	posterCacheDir := os.Getenv("POSTER_CACHE_DIR")
+	if posterCacheDir == "" {
+		posterCacheDir = filepath.Join(dataDir, "posters")
+	}
+	exportService.SetPosterCacheDir(posterCacheDir)
+
+	// Periodically refresh stale ratings and posters
+	metadataMaxAge := durationFromEnv("METADATA_MAX_AGE", 7*24*time.Hour, appLogger)
+	refreshInterval := durationFromEnv("METADATA_REFRESH_INTERVAL", time.Hour, appLogger)
+	stopRefresher := watchlistService.StartMetadataRefresher(refreshInterval, metadataMaxAge)
+	defer stopRefresher()
+
+	// Expired trash entries are purged in the background
+	sweepInterval := durationFromEnv("TRASH_SWEEP_INTERVAL", time.Hour, appLogger)
+	stopSweeper := watchlistService.StartTrashSweeper(sweepInterval)
+	defer stopSweeper()
+
+	// Large exports run in the background; finished files expire after EXPORT_TTL
+	exportJobs := services.NewExportJobService(dataDir, watchlistService, exportService, appLogger)
+	exportJobs.SetArtifactTTL(durationFromEnv("EXPORT_TTL", 24*time.Hour, appLogger))
+	exportWorkers := 2
+	if n, err := strconv.Atoi(os.Getenv("EXPORT_WORKERS")); err == nil && n > 0 {
+		exportWorkers = n
+	}
+	stopExportWorkers := exportJobs.StartWorkers(exportWorkers)
+	defer stopExportWorkers()
+	cleanupInterval := durationFromEnv("EXPORT_CLEANUP_INTERVAL", time.Hour, appLogger)
+	stopExportCleanup := exportJobs.StartCleanup(cleanupInterval)
+	defer stopExportCleanup()
 
 	// Initialize handlers
 	movieHandler := handlers.NewMovieHandler(movieService, appLogger)
-	watchlistHandler := handlers.NewWatchlistHandler(watchlistService, exportService, appLogger)
+	watchlistHandler := handlers.NewWatchlistHandler(watchlistService, exportService, exportJobs, appLogger)
+	watchlistHandler.ModerationToken = os.Getenv("MODERATION_TOKEN")
 
 	// Setup routes
 	r := router.SetupRoutes(movieHandler, watchlistHandler)
@@ -65,3 +126,18 @@ func main() {
 	fmt.Println("Server starting on localhost:8080")
 	log.Fatal(http.ListenAndServe(":8080", r))
 }
+
+// durationFromEnv reads a duration such as "24h" from the environment,
+// falling back to def when it is unset or invalid
+func durationFromEnv(key string, def time.Duration, appLogger *logger.Logger) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		appLogger.Warning("Invalid %s %q, using default %s", key, value, def)
+		return def
+	}
+	return d
+}
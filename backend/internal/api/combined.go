@@ -70,7 +70,7 @@ func (s *MovieService) GetMovieDetails(tmdbMovieID int, movieTitle string) (*Com
 
 	// 3. Data Validation (basic example)
 	if combinedData.TMDBData == nil && combinedData.OMDBData == nil {
-		return nil, fmt.Errorf("could not retrieve movie details from either TMDB or OMDB")
+		return nil, fmt.Errorf("could not retrieve movie details from either TMDB or OMDB: %w", err)
 	}
 
 	if err := s.validateMovieData(combinedData); err != nil {
@@ -122,17 +122,39 @@ func (s *MovieService) GetTVDetails(tmdbTVID int, tvTitle string) (*CombinedMovi
 
 	// 3. Data Validation
 	if combinedData.TMDBData == nil && combinedData.OMDBData == nil {
-		return nil, fmt.Errorf("could not retrieve TV show details from either TMDB or OMDB")
+		return nil, fmt.Errorf("could not retrieve TV show details from either TMDB or OMDB: %w", err)
 	}
 
 	return combinedData, nil
 }
 
+// GetMovieReleaseDates fetches a movie's release dates. The result has a
+// results array with one entry per country.
+func (s *MovieService) GetMovieReleaseDates(movieID int) (map[string]interface{}, error) {
+	return s.TMDBClient.GetMovieReleaseDates(movieID)
+}
+
+// GetTVSeasonDetails fetches a TV season with its episode list from TMDB.
+func (s *MovieService) GetTVSeasonDetails(tmdbTVID, seasonNumber int) (map[string]interface{}, error) {
+	return s.TMDBClient.GetTVSeasonDetails(tmdbTVID, seasonNumber)
+}
+
+// FindByIMDbID looks up movies and TV shows by IMDb id. The result has
+// movie_results and tv_results arrays.
+func (s *MovieService) FindByIMDbID(imdbID string) (map[string]interface{}, error) {
+	return s.TMDBClient.FindByIMDbID(imdbID)
+}
+
 // GetMovieCredits fetches cast and crew information for a movie.
 func (s *MovieService) GetMovieCredits(movieID int) (map[string]interface{}, error) {
 	return s.TMDBClient.GetMovieCredits(movieID)
 }
 
+// GetTVCredits fetches cast and crew information for a TV show.
+func (s *MovieService) GetTVCredits(tvID int) (map[string]interface{}, error) {
+	return s.TMDBClient.GetTVCredits(tvID)
+}
+
 // GetGenres fetches the list of movie genres.
 func (s *MovieService) GetGenres() ([]interface{}, error) {
 	return s.TMDBClient.GetGenres()
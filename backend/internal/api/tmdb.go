@@ -2,9 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"time"
 
 	"r.a.w/backend/pkg/logger"
@@ -14,6 +16,9 @@ const (
 	TMDB_BASE_URL = "https://api.themoviedb.org/3"
 )
 
+// ErrNotFound is returned when TMDB has no resource with the requested id.
+var ErrNotFound = errors.New("not found")
+
 // TMDBClient represents a client for the TMDB API.
 type TMDBClient struct {
 	APIKey     string
@@ -38,6 +43,12 @@ func (c *TMDBClient) GetMovieDetails(movieID int) (map[string]interface{}, error
 	return c.fetchData(url)
 }
 
+// GetMovieReleaseDates fetches a movie's release dates per country and release type from TMDB.
+func (c *TMDBClient) GetMovieReleaseDates(movieID int) (map[string]interface{}, error) {
+	url := fmt.Sprintf("%s/movie/%d/release_dates?api_key=%s", TMDB_BASE_URL, movieID, c.APIKey)
+	return c.fetchData(url)
+}
+
 // GetTrendingMovies fetches trending movies from TMDB.
 func (c *TMDBClient) GetTrendingMovies() ([]interface{}, error) {
 	url := fmt.Sprintf("%s/trending/movie/week?api_key=%s", TMDB_BASE_URL, c.APIKey)
@@ -68,7 +79,19 @@ func (c *TMDBClient) GetTrendingContent(contentType string, page int) (map[strin
 
 // GetTVDetails fetches TV show details from TMDB.
 func (c *TMDBClient) GetTVDetails(tvID int) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/tv/%d?api_key=%s", TMDB_BASE_URL, tvID, c.APIKey)
+	url := fmt.Sprintf("%s/tv/%d?api_key=%s&append_to_response=external_ids", TMDB_BASE_URL, tvID, c.APIKey)
+	return c.fetchData(url)
+}
+
+// GetTVSeasonDetails fetches a single season of a TV show, including its episodes, from TMDB.
+func (c *TMDBClient) GetTVSeasonDetails(tvID, seasonNumber int) (map[string]interface{}, error) {
+	url := fmt.Sprintf("%s/tv/%d/season/%d?api_key=%s", TMDB_BASE_URL, tvID, seasonNumber, c.APIKey)
+	return c.fetchData(url)
+}
+
+// FindByIMDbID looks up movies and TV shows by their IMDb id on TMDB.
+func (c *TMDBClient) FindByIMDbID(imdbID string) (map[string]interface{}, error) {
+	url := fmt.Sprintf("%s/find/%s?api_key=%s&external_source=imdb_id", TMDB_BASE_URL, url.PathEscape(imdbID), c.APIKey)
 	return c.fetchData(url)
 }
 
@@ -92,6 +115,12 @@ func (c *TMDBClient) GetMovieCredits(movieID int) (map[string]interface{}, error
 	return c.fetchData(url)
 }
 
+// GetTVCredits fetches the cast and crew of a TV show's latest season from TMDB.
+func (c *TMDBClient) GetTVCredits(tvID int) (map[string]interface{}, error) {
+	url := fmt.Sprintf("%s/tv/%d/credits?api_key=%s", TMDB_BASE_URL, tvID, c.APIKey)
+	return c.fetchData(url)
+}
+
 // GetGenres fetches the list of movie genres from TMDB.
 func (c *TMDBClient) GetGenres() ([]interface{}, error) {
 	url := fmt.Sprintf("%s/genre/movie/list?api_key=%s", TMDB_BASE_URL, c.APIKey)
@@ -108,7 +137,8 @@ func (c *TMDBClient) GetGenres() ([]interface{}, error) {
 
 // SearchMovies searches for movies by title from TMDB.
 func (c *TMDBClient) SearchMovies(query string) ([]interface{}, error) {
-	url := fmt.Sprintf("%s/search/movie?api_key=%s&query=%s", TMDB_BASE_URL, c.APIKey, query)
+	escaped := url.QueryEscape(query)
+	url := fmt.Sprintf("%s/search/movie?api_key=%s&query=%s", TMDB_BASE_URL, c.APIKey, escaped)
 	data, err := c.fetchData(url)
 	if err != nil {
 		return nil, err
@@ -129,7 +159,8 @@ func (c *TMDBClient) SearchContent(query, contentType string, page int) (map[str
 		page = 1
 	}
 	
-	url := fmt.Sprintf("%s/search/%s?api_key=%s&query=%s&page=%d", TMDB_BASE_URL, contentType, c.APIKey, query, page)
+	escaped := url.QueryEscape(query)
+	url := fmt.Sprintf("%s/search/%s?api_key=%s&query=%s&page=%d", TMDB_BASE_URL, contentType, c.APIKey, escaped, page)
 	return c.fetchData(url)
 }
 
@@ -220,6 +251,9 @@ func (c *TMDBClient) fetchData(url string) (map[string]interface{}, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("API request failed with status code: %d: %w", resp.StatusCode, ErrNotFound)
+	}
 	if resp.StatusCode != http.StatusOK {
 		c.Logger.Error("TMDB API request failed with status code: %d for URL: %s", resp.StatusCode, url)
 		return nil, fmt.Errorf("API request failed with status code: %d", resp.StatusCode)
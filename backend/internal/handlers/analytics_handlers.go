@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+)
+
+// GetAnalytics handles GET /api/watchlist/{userID}/analytics. It takes from,
+// to, media_type and interval (month or week) query parameters. Dates are
+// YYYY-MM-DD, taken as UTC days with to inclusive, or RFC 3339 times.
+func (h *WatchlistHandler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	query := models.AnalyticsQuery{
+		MediaType: params.Get("media_type"),
+		Interval:  params.Get("interval"),
+	}
+	if v := params.Get("from"); v != "" {
+		from, err := parseAnalyticsDate(v, false)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid from %q, use YYYY-MM-DD or RFC 3339", v), http.StatusBadRequest)
+			return
+		}
+		query.From = &from
+	}
+	if v := params.Get("to"); v != "" {
+		to, err := parseAnalyticsDate(v, true)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid to %q, use YYYY-MM-DD or RFC 3339", v), http.StatusBadRequest)
+			return
+		}
+		query.To = &to
+	}
+
+	analytics, err := h.WatchlistService.GetAnalytics(userID, query)
+	if err != nil {
+		h.Logger.Error("Error computing analytics for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error computing analytics: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analytics)
+	h.Logger.Success("Successfully computed analytics for user %s", userID)
+}
+
+// parseAnalyticsDate parses a range bound. A plain date as the end of a
+// range includes that whole day.
+func parseAnalyticsDate(value string, isEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
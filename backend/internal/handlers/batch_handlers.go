@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+)
+
+// ApplyBatch handles POST /api/watchlist/{userID}/batch
+// and POST /api/users/{userID}/lists/{listID}/batch
+func (h *WatchlistHandler) ApplyBatch(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var req models.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.WatchlistService.ApplyBatch(userID, listID, req)
+	if err != nil {
+		h.Logger.Error("Error applying batch for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error applying batch: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	// A batch with failed operations is rejected as a whole; the results say which failed
+	status := http.StatusOK
+	for _, result := range resp.Results {
+		if !result.OK {
+			status = http.StatusUnprocessableEntity
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+	if resp.Applied {
+		h.Logger.Success("Successfully applied batch of %d operation(s) for user %s", len(resp.Results), userID)
+	}
+}
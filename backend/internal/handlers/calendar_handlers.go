@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+	"r.a.w/backend/internal/services"
+)
+
+// GetCalendarFeed handles GET /api/watchlist/{userID}/calendar
+func (h *WatchlistHandler) GetCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	feed, err := h.WatchlistService.GetCalendarFeed(userID)
+	if err != nil {
+		h.Logger.Error("Error fetching calendar feed for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching calendar feed: %v", err), calendarErrorStatus(err))
+		return
+	}
+	feed.URL = calendarURL(r, feed)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feed)
+	h.Logger.Success("Successfully fetched calendar feed for user %s", userID)
+}
+
+// CreateCalendarFeed handles POST /api/watchlist/{userID}/calendar. Calling
+// it again issues a new token and invalidates the previous URL.
+func (h *WatchlistHandler) CreateCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	feed, err := h.WatchlistService.CreateCalendarFeed(userID)
+	if err != nil {
+		h.Logger.Error("Error creating calendar feed for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error creating calendar feed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	feed.URL = calendarURL(r, feed)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(feed)
+	h.Logger.Success("Successfully created calendar feed for user %s", userID)
+}
+
+// DeleteCalendarFeed handles DELETE /api/watchlist/{userID}/calendar
+func (h *WatchlistHandler) DeleteCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.DeleteCalendarFeed(userID); err != nil {
+		h.Logger.Error("Error deleting calendar feed for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error deleting calendar feed: %v", err), calendarErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "success",
+		"message": "Calendar feed deleted",
+	})
+	h.Logger.Success("Successfully deleted calendar feed for user %s", userID)
+}
+
+// GetCalendar handles GET /api/watchlist/{userID}/calendar.ics?token=...
+func (h *WatchlistHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	watchlist, err := h.WatchlistService.GetCalendarWatchlist(userID, r.URL.Query().Get("token"))
+	if errors.Is(err, services.ErrInvalidCalendarToken) {
+		h.Logger.Warning("Rejected calendar request for user %s: %v", userID, err)
+		http.Error(w, "Invalid calendar token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error loading calendar for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error loading calendar: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	exporter, err := h.ExportService.Exporter("ics")
+	if err != nil {
+		h.Logger.Error("Error rendering calendar for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error rendering calendar: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	export := &models.WatchlistExport{Watchlist: watchlist}
+	if err := streamExport(w, r, exporter, export, services.ExportOptions{}, "calendar.ics"); err != nil {
+		h.Logger.Error("Error rendering calendar for user %s: %v", userID, err)
+		return
+	}
+	h.Logger.Success("Successfully served calendar for user %s", userID)
+}
+
+// calendarErrorStatus maps a calendar subscription error to an HTTP status code
+func calendarErrorStatus(err error) int {
+	if errors.Is(err, services.ErrCalendarNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// calendarURL returns the absolute subscription URL of a calendar feed
+func calendarURL(r *http.Request, feed *models.CalendarFeed) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/api/watchlist/%s/calendar.ics?token=%s",
+		scheme, r.Host, url.PathEscape(feed.UserID), url.QueryEscape(feed.Token))
+}
@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+	"r.a.w/backend/internal/services"
+)
+
+// CreateInvite handles POST /api/users/{userID}/lists/{listID}/invites
+func (h *WatchlistHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	if userID == "" || listID == "" {
+		http.Error(w, "User ID and List ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var req models.InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	invite, err := h.WatchlistService.CreateInvite(userID, listID, req)
+	if err != nil {
+		h.Logger.Error("Error inviting to list %s for user %s: %v", listID, userID, err)
+		http.Error(w, fmt.Sprintf("Error creating invite: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invite)
+	h.Logger.Success("Successfully created %s invite to list %s for user %s", invite.Role, listID, userID)
+}
+
+// RevokeInvite handles DELETE /api/users/{userID}/lists/{listID}/invites/{inviteID}
+func (h *WatchlistHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+	inviteID := vars["inviteID"]
+
+	if userID == "" || listID == "" || inviteID == "" {
+		http.Error(w, "User ID, List ID and Invite ID are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.RevokeInvite(userID, listID, inviteID); err != nil {
+		h.Logger.Error("Error revoking invite %s to list %s for user %s: %v", inviteID, listID, userID, err)
+		http.Error(w, fmt.Sprintf("Error revoking invite: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Invite revoked"})
+	h.Logger.Success("Successfully revoked invite %s to list %s for user %s", inviteID, listID, userID)
+}
+
+// GetMembers handles GET /api/users/{userID}/lists/{listID}/members
+func (h *WatchlistHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	if userID == "" || listID == "" {
+		http.Error(w, "User ID and List ID are required", http.StatusBadRequest)
+		return
+	}
+
+	collab, err := h.WatchlistService.GetCollaboration(userID, listID)
+	if err != nil {
+		h.Logger.Error("Error fetching members of list %s for user %s: %v", listID, userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching members: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collab)
+	h.Logger.Success("Successfully fetched members of list %s for user %s", listID, userID)
+}
+
+// UpdateMember handles PUT /api/users/{userID}/lists/{listID}/members/{memberID}
+func (h *WatchlistHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+	memberID := vars["memberID"]
+
+	if userID == "" || listID == "" || memberID == "" {
+		http.Error(w, "User ID, List ID and Member ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var requestBody struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.WatchlistService.UpdateMemberRole(userID, listID, memberID, requestBody.Role)
+	if err != nil {
+		h.Logger.Error("Error updating member %s of list %s for user %s: %v", memberID, listID, userID, err)
+		http.Error(w, fmt.Sprintf("Error updating member: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(member)
+	h.Logger.Success("Successfully made %s a %s of list %s for user %s", memberID, member.Role, listID, userID)
+}
+
+// RemoveMember handles DELETE /api/users/{userID}/lists/{listID}/members/{memberID}
+func (h *WatchlistHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+	memberID := vars["memberID"]
+
+	if userID == "" || listID == "" || memberID == "" {
+		http.Error(w, "User ID, List ID and Member ID are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.RemoveMember(userID, listID, memberID); err != nil {
+		h.Logger.Error("Error removing member %s of list %s for user %s: %v", memberID, listID, userID, err)
+		http.Error(w, fmt.Sprintf("Error removing member: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Member removed"})
+	h.Logger.Success("Successfully removed member %s of list %s for user %s", memberID, listID, userID)
+}
+
+// AcceptInvite handles POST /api/users/{userID}/invites/{token}
+func (h *WatchlistHandler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	token := vars["token"]
+
+	if userID == "" || token == "" {
+		http.Error(w, "User ID and invite token are required", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.WatchlistService.AcceptInvite(userID, token)
+	if err != nil {
+		h.Logger.Error("Error accepting invite for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error accepting invite: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+	h.Logger.Success("User %s joined collaborative list %s as %s", userID, summary.ID, summary.Role)
+}
+
+// GetCollaborations handles GET /api/users/{userID}/collabs
+func (h *WatchlistHandler) GetCollaborations(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	summaries, err := h.WatchlistService.GetCollaborations(userID)
+	if err != nil {
+		h.Logger.Error("Error fetching collaborative lists for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching collaborative lists: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summaries)
+	h.Logger.Success("Successfully fetched collaborative lists for user %s", userID)
+}
+
+// GetCollaborativeList handles GET /api/users/{userID}/collabs/{collabID}
+func (h *WatchlistHandler) GetCollaborativeList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	collabID := vars["collabID"]
+
+	if userID == "" || collabID == "" {
+		http.Error(w, "User ID and Collaboration ID are required", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.WatchlistService.GetCollaborativeList(userID, collabID)
+	if err != nil {
+		h.Logger.Error("Error fetching collaborative list %s for user %s: %v", collabID, userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching collaborative list: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+	h.Logger.Success("Successfully fetched collaborative list %s for user %s", collabID, userID)
+}
+
+// LeaveCollaboration handles DELETE /api/users/{userID}/collabs/{collabID}
+func (h *WatchlistHandler) LeaveCollaboration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	collabID := vars["collabID"]
+
+	if userID == "" || collabID == "" {
+		http.Error(w, "User ID and Collaboration ID are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.LeaveCollaboration(userID, collabID); err != nil {
+		h.Logger.Error("Error leaving collaborative list %s for user %s: %v", collabID, userID, err)
+		http.Error(w, fmt.Sprintf("Error leaving collaborative list: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Left collaborative list"})
+	h.Logger.Success("User %s left collaborative list %s", userID, collabID)
+}
+
+// GetCollaborationActivity handles GET /api/users/{userID}/collabs/{collabID}/activity
+func (h *WatchlistHandler) GetCollaborationActivity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	collabID := vars["collabID"]
+
+	if userID == "" || collabID == "" {
+		http.Error(w, "User ID and Collaboration ID are required", http.StatusBadRequest)
+		return
+	}
+
+	activity, err := h.WatchlistService.GetCollaborationActivity(userID, collabID)
+	if err != nil {
+		h.Logger.Error("Error fetching activity of collaborative list %s for user %s: %v", collabID, userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching activity: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(activity)
+	h.Logger.Success("Successfully fetched activity of collaborative list %s for user %s", collabID, userID)
+}
+
+// AddCollaborativeItem handles POST /api/users/{userID}/collabs/{collabID}/items
+func (h *WatchlistHandler) AddCollaborativeItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	collabID := vars["collabID"]
+
+	if userID == "" || collabID == "" {
+		http.Error(w, "User ID and Collaboration ID are required", http.StatusBadRequest)
+		return
+	}
+
+	// Only the media type and TMDB id are accepted; metadata is fetched server-side
+	var requestBody struct {
+		MediaType string `json:"media_type"`
+		ID        int    `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.WatchlistService.AddCollaborativeItem(userID, collabID, requestBody.MediaType, requestBody.ID)
+	if err != nil {
+		h.Logger.Error("Error adding to collaborative list %s for user %s: %v", collabID, userID, err)
+		http.Error(w, fmt.Sprintf("Error adding to collaborative list: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+	h.Logger.Success("Successfully added %s %d to collaborative list %s for user %s", item.MediaType, item.MovieID, collabID, userID)
+}
+
+// RemoveCollaborativeItem handles DELETE /api/users/{userID}/collabs/{collabID}/items/{itemID}
+func (h *WatchlistHandler) RemoveCollaborativeItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	collabID := vars["collabID"]
+	itemID := vars["itemID"]
+
+	if userID == "" || collabID == "" || itemID == "" {
+		http.Error(w, "User ID, Collaboration ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.RemoveCollaborativeItem(userID, collabID, itemID); err != nil {
+		h.Logger.Error("Error removing from collaborative list %s for user %s: %v", collabID, userID, err)
+		http.Error(w, fmt.Sprintf("Error removing from collaborative list: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Item removed from collaborative list"})
+	h.Logger.Success("Successfully removed item %s from collaborative list %s for user %s", itemID, collabID, userID)
+}
+
+// MarkCollaborativeWatched handles PUT /api/users/{userID}/collabs/{collabID}/items/{itemID}/watched
+func (h *WatchlistHandler) MarkCollaborativeWatched(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	collabID := vars["collabID"]
+	itemID := vars["itemID"]
+
+	if userID == "" || collabID == "" || itemID == "" {
+		http.Error(w, "User ID, Collaboration ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional; an empty body records a watch right now
+	var input models.WatchEventInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.WatchlistService.MarkCollaborativeWatched(userID, collabID, itemID, input)
+	if err != nil {
+		h.Logger.Error("Error marking item %s of collaborative list %s as watched for user %s: %v", itemID, collabID, userID, err)
+		http.Error(w, fmt.Sprintf("Error marking as watched: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(event)
+	h.Logger.Success("Successfully marked item %s of collaborative list %s as watched for user %s", itemID, collabID, userID)
+}
+
+// MarkCollaborativeUnwatched handles PUT /api/users/{userID}/collabs/{collabID}/items/{itemID}/unwatched
+func (h *WatchlistHandler) MarkCollaborativeUnwatched(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	collabID := vars["collabID"]
+	itemID := vars["itemID"]
+
+	if userID == "" || collabID == "" || itemID == "" {
+		http.Error(w, "User ID, Collaboration ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.MarkCollaborativeUnwatched(userID, collabID, itemID); err != nil {
+		h.Logger.Error("Error marking item %s of collaborative list %s as unwatched for user %s: %v", itemID, collabID, userID, err)
+		http.Error(w, fmt.Sprintf("Error marking as unwatched: %v", err), collabErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Item marked as unwatched"})
+	h.Logger.Success("Successfully marked item %s of collaborative list %s as unwatched for user %s", itemID, collabID, userID)
+}
+
+// collabErrorStatus maps a collaborative list error to an HTTP status code,
+// falling back to the list errors for everything else
+func collabErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCollabNotFound), errors.Is(err, services.ErrInviteNotFound), errors.Is(err, services.ErrMemberNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCollabForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInviteExpired):
+		return http.StatusGone
+	default:
+		return watchlistErrorStatus(err)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+)
+
+// DiscoverShares handles GET /api/shared. It takes q, sort (recent or
+// popular), featured, limit and cursor query parameters.
+func (h *WatchlistHandler) DiscoverShares(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := models.DiscoveryQuery{
+		Text:   params.Get("q"),
+		Sort:   params.Get("sort"),
+		Cursor: params.Get("cursor"),
+	}
+	if v := params.Get("featured"); v != "" {
+		featured, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid featured %q", v), http.StatusBadRequest)
+			return
+		}
+		query.Featured = featured
+	}
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+
+	page, err := h.WatchlistService.DiscoverShares(query)
+	if err != nil {
+		h.Logger.Error("Error listing shared watchlists: %v", err)
+		http.Error(w, fmt.Sprintf("Error listing shared watchlists: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+	h.Logger.Success("Successfully listed %d of %d shared watchlists", len(page.Listings), page.Total)
+}
+
+// ModerateShare handles PUT /api/moderation/shared/{shareToken}
+func (h *WatchlistHandler) ModerateShare(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	shareToken := vars["shareToken"]
+
+	if h.ModerationToken == "" {
+		http.Error(w, "Moderation is disabled", http.StatusForbidden)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Moderation-Token")), []byte(h.ModerationToken)) != 1 {
+		http.Error(w, "Invalid moderation token", http.StatusUnauthorized)
+		return
+	}
+	if shareToken == "" {
+		http.Error(w, "Share token is required", http.StatusBadRequest)
+		return
+	}
+
+	var update models.ModerationUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	share, err := h.WatchlistService.ModerateShare(shareToken, update)
+	if err != nil {
+		h.Logger.Error("Error moderating shared watchlist with token %s: %v", shareToken, err)
+		http.Error(w, fmt.Sprintf("Error moderating shared watchlist: %v", err), shareErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(share)
+	h.Logger.Success("Moderated shared watchlist %s: featured=%t unlisted=%t", share.ID, share.Featured, share.UnlistedAt != nil)
+}
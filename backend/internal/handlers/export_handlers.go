@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"r.a.w/backend/internal/models"
+	"r.a.w/backend/internal/services"
+)
+
+// parseExportOptions reads the format specific export settings:
+// posters for PDF, theme and lang for HTML
+func parseExportOptions(r *http.Request) (services.ExportOptions, error) {
+	query := r.URL.Query()
+	opts := services.ExportOptions{
+		PDF: services.PDFOptions{Posters: query.Get("posters") == "true"},
+		HTML: services.HTMLOptions{
+			Theme:  query.Get("theme"),
+			Locale: query.Get("lang"),
+		},
+	}
+	
+	if opts.HTML.Locale == "" {
+		// First language of the Accept-Language header, e.g. "fr-CA,fr;q=0.9"
+		opts.HTML.Locale = strings.SplitN(strings.SplitN(r.Header.Get("Accept-Language"), ",", 2)[0], ";", 2)[0]
+	}
+	if opts.HTML.Theme != "" && !services.IsReportTheme(opts.HTML.Theme) {
+		return opts, fmt.Errorf("invalid theme %q, use light, dark or print", opts.HTML.Theme)
+	}
+	
+	return opts, nil
+}
+
+// streamExport writes an export straight to the response, gzip-compressed
+// when the client accepts it. Errors are reported to the client while nothing
+// has been written yet; after that they can only be returned for logging.
+func streamExport(w http.ResponseWriter, r *http.Request, exporter services.Exporter, export *models.WatchlistExport, opts services.ExportOptions, filename string) error {
+	w.Header().Set("Content-Type", exporter.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Header().Add("Vary", "Accept-Encoding")
+	
+	out := &responseWriter{w: w}
+	var body io.Writer = out
+	var gz *gzip.Writer
+	if acceptsGzip(r) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz = gzip.NewWriter(out)
+		body = gz
+	}
+	
+	err := exporter.Export(body, export, opts)
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if err != nil && !out.written {
+		w.Header().Del("Content-Encoding")
+		w.Header().Del("Content-Disposition")
+		http.Error(w, fmt.Sprintf("Error exporting watchlist: %v", err), http.StatusInternalServerError)
+	}
+	return err
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// responseWriter records whether anything reached the response body
+type responseWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		rw.written = true
+	}
+	return rw.w.Write(p)
+}
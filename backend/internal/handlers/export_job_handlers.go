@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+	"r.a.w/backend/internal/services"
+)
+
+// CreateExportJob handles POST /api/watchlist/{userID}/exports
+func (h *WatchlistHandler) CreateExportJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ExportJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.ExportJobs.CreateJob(userID, req)
+	if err != nil {
+		h.Logger.Error("Error creating export job for user %s: %v", userID, err)
+		status := watchlistErrorStatus(err)
+		if errors.Is(err, services.ErrExportQueueFull) {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, fmt.Sprintf("Error creating export job: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/api/watchlist/%s/exports/%s", url.PathEscape(userID), job.ID))
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(presentExportJob(r, job))
+	h.Logger.Success("Successfully queued %s export job %s for user %s", job.Format, job.ID, userID)
+}
+
+// GetExportJobs handles GET /api/watchlist/{userID}/exports
+func (h *WatchlistHandler) GetExportJobs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	jobs, err := h.ExportJobs.GetJobs(userID)
+	if err != nil {
+		h.Logger.Error("Error fetching export jobs for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching export jobs: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for i := range jobs {
+		jobs[i] = *presentExportJob(r, &jobs[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+	h.Logger.Success("Successfully fetched export jobs for user %s", userID)
+}
+
+// GetExportJob handles GET /api/watchlist/{userID}/exports/{jobID}
+func (h *WatchlistHandler) GetExportJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	jobID := vars["jobID"]
+
+	if userID == "" || jobID == "" {
+		http.Error(w, "User ID and Job ID are required", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.ExportJobs.GetJob(userID, jobID)
+	if err != nil {
+		h.Logger.Error("Error fetching export job %s for user %s: %v", jobID, userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching export job: %v", err), exportJobStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(presentExportJob(r, job))
+	h.Logger.Success("Successfully fetched export job %s for user %s", jobID, userID)
+}
+
+// DownloadExport handles GET /api/watchlist/{userID}/exports/{jobID}/download?token=...
+func (h *WatchlistHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	jobID := vars["jobID"]
+
+	if userID == "" || jobID == "" {
+		http.Error(w, "User ID and Job ID are required", http.StatusBadRequest)
+		return
+	}
+
+	file, job, err := h.ExportJobs.OpenArtifact(userID, jobID, r.URL.Query().Get("token"))
+	if err != nil {
+		h.Logger.Warning("Rejected download of export %s for user %s: %v", jobID, userID, err)
+		http.Error(w, fmt.Sprintf("Error downloading export: %v", err), exportJobStatus(err))
+		return
+	}
+	defer file.Close()
+
+	modTime := job.CreatedAt
+	if job.FinishedAt != nil {
+		modTime = *job.FinishedAt
+	}
+	w.Header().Set("Content-Type", job.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", job.Filename))
+	// ServeContent sets Content-Length and handles range requests
+	http.ServeContent(w, r, job.Filename, modTime, file)
+	h.Logger.Success("Successfully served export %s for user %s", jobID, userID)
+}
+
+// presentExportJob prepares a job for a response: the download token is
+// only handed out as part of the download URL, while the export is available
+func presentExportJob(r *http.Request, job *models.ExportJob) *models.ExportJob {
+	presented := *job
+	presented.DownloadToken = ""
+	if job.Status == models.ExportJobDone && (job.ExpiresAt == nil || time.Now().Before(*job.ExpiresAt)) {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		presented.DownloadURL = fmt.Sprintf("%s://%s/api/watchlist/%s/exports/%s/download?token=%s",
+			scheme, r.Host, url.PathEscape(job.UserID), job.ID, url.QueryEscape(job.DownloadToken))
+	}
+	return &presented
+}
+
+// exportJobStatus maps an export job error to an HTTP status code
+func exportJobStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrExportJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrExportNotReady):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrExportExpired):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+)
+
+// maxImportSize bounds the size of an uploaded import file
+const maxImportSize = 10 << 20
+
+// ImportWatchlist handles POST /api/watchlist/{userID}/import?format=&list_id=&dry_run=.
+// The file is sent as the "file" field of a multipart form or as the raw body.
+// Our own JSON and NDJSON exports replace the list, moving the items they
+// leave out to the trash; other formats are merged into it.
+func (h *WatchlistHandler) ImportWatchlist(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Missing import file", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		http.Error(w, "Could not read import file", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	dryRun := query.Get("dry_run") == "true"
+	report, err := h.WatchlistService.ImportWatchlist(userID, query.Get("list_id"), query.Get("format"), data, dryRun)
+	if err != nil {
+		h.Logger.Error("Error importing watchlist for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error importing watchlist: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+	h.Logger.Success("Successfully imported %s file for user %s", report.Format, userID)
+}
+
+// GetImport handles GET /api/watchlist/{userID}/import/{importID}
+func (h *WatchlistHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	importID := vars["importID"]
+
+	if userID == "" || importID == "" {
+		http.Error(w, "User ID and Import ID are required", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.WatchlistService.GetImport(userID, importID)
+	if err != nil {
+		h.Logger.Error("Error fetching import %s for user %s: %v", importID, userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching import: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+	h.Logger.Success("Successfully fetched import %s for user %s", importID, userID)
+}
+
+// ConfirmImport handles POST /api/watchlist/{userID}/import/{importID}/confirm
+func (h *WatchlistHandler) ConfirmImport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	importID := vars["importID"]
+
+	if userID == "" || importID == "" {
+		http.Error(w, "User ID and Import ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var confirmation models.ImportConfirmation
+	if err := json.NewDecoder(r.Body).Decode(&confirmation); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.WatchlistService.ConfirmImport(userID, importID, confirmation)
+	if err != nil {
+		h.Logger.Error("Error confirming import %s for user %s: %v", importID, userID, err)
+		http.Error(w, fmt.Sprintf("Error confirming import: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+	h.Logger.Success("Successfully confirmed import %s for user %s", importID, userID)
+}
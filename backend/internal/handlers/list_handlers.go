@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+)
+
+// GetLists handles GET /api/users/{userID}/lists
+func (h *WatchlistHandler) GetLists(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	lists, err := h.WatchlistService.GetLists(userID)
+	if err != nil {
+		h.Logger.Error("Error fetching lists for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching lists: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lists)
+	h.Logger.Success("Successfully fetched lists for user %s", userID)
+}
+
+// CreateList handles POST /api/users/{userID}/lists
+func (h *WatchlistHandler) CreateList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var input models.ListInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.WatchlistService.CreateList(userID, input)
+	if err != nil {
+		h.Logger.Error("Error creating list for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error creating list: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(list)
+	h.Logger.Success("Successfully created list %s for user %s", list.ID, userID)
+}
+
+// GetList handles GET /api/users/{userID}/lists/{listID}
+func (h *WatchlistHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	if userID == "" || listID == "" {
+		http.Error(w, "User ID and List ID are required", http.StatusBadRequest)
+		return
+	}
+
+	query, err := parseWatchlistQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.WatchlistService.QueryList(userID, listID, query)
+	if err != nil {
+		h.Logger.Error("Error fetching list %s for user %s: %v", listID, userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching list: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+	h.Logger.Success("Successfully fetched list %s for user %s", listID, userID)
+}
+
+// UpdateList handles PUT /api/users/{userID}/lists/{listID}
+func (h *WatchlistHandler) UpdateList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	if userID == "" || listID == "" {
+		http.Error(w, "User ID and List ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var input models.ListInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.WatchlistService.UpdateList(userID, listID, input)
+	if err != nil {
+		h.Logger.Error("Error updating list %s for user %s: %v", listID, userID, err)
+		http.Error(w, fmt.Sprintf("Error updating list: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+	h.Logger.Success("Successfully updated list %s for user %s", listID, userID)
+}
+
+// DeleteList handles DELETE /api/users/{userID}/lists/{listID}
+func (h *WatchlistHandler) DeleteList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	if userID == "" || listID == "" {
+		http.Error(w, "User ID and List ID are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.DeleteList(userID, listID); err != nil {
+		h.Logger.Error("Error deleting list %s for user %s: %v", listID, userID, err)
+		http.Error(w, fmt.Sprintf("Error deleting list: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "List deleted"})
+	h.Logger.Success("Successfully deleted list %s for user %s", listID, userID)
+}
+
+// AddToList handles POST /api/users/{userID}/lists/{listID}/items
+func (h *WatchlistHandler) AddToList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	if userID == "" || listID == "" {
+		http.Error(w, "User ID and List ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var requestBody struct {
+		MediaType string `json:"media_type"`
+		ID        int    `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.WatchlistService.AddToList(userID, listID, requestBody.MediaType, requestBody.ID)
+	if err != nil {
+		h.Logger.Error("Error adding to list %s for user %s: %v", listID, userID, err)
+		http.Error(w, fmt.Sprintf("Error adding to list: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+	h.Logger.Success("Successfully added %s %d to list %s for user %s", item.MediaType, item.MovieID, listID, userID)
+}
+
+// RemoveFromList handles DELETE /api/users/{userID}/lists/{listID}/items/{itemID}
+func (h *WatchlistHandler) RemoveFromList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+	itemID := vars["itemID"]
+
+	if userID == "" || listID == "" || itemID == "" {
+		http.Error(w, "User ID, List ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.RemoveFromList(userID, listID, itemID); err != nil {
+		h.Logger.Error("Error removing from list %s for user %s: %v", listID, userID, err)
+		http.Error(w, fmt.Sprintf("Error removing from list: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Item removed from list"})
+	h.Logger.Success("Successfully removed item from list %s for user %s", listID, userID)
+}
+
+// TransferItem handles POST /api/users/{userID}/lists/{listID}/items/{itemID}/move
+// and POST .../copy, with a body like {"target_list_id": "..."}
+func (h *WatchlistHandler) TransferItem(move bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		userID := vars["userID"]
+		listID := vars["listID"]
+		itemID := vars["itemID"]
+
+		var requestBody struct {
+			TargetListID string `json:"target_list_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.TargetListID == "" {
+			http.Error(w, "target_list_id is required", http.StatusBadRequest)
+			return
+		}
+
+		action, done := "copy", "copied"
+		transfer := h.WatchlistService.CopyItem
+		if move {
+			action, done = "move", "moved"
+			transfer = h.WatchlistService.MoveItem
+		}
+
+		item, err := transfer(userID, listID, itemID, requestBody.TargetListID)
+		if err != nil {
+			h.Logger.Error("Error trying to %s item %s for user %s: %v", action, itemID, userID, err)
+			http.Error(w, fmt.Sprintf("Error trying to %s item: %v", action, err), watchlistErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(item)
+		h.Logger.Success("Successfully %s item %s to list %s for user %s", done, itemID, requestBody.TargetListID, userID)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+)
+
+// ReorderItem handles PUT /api/watchlist/{userID}/{itemID}/position
+// and PUT /api/users/{userID}/lists/{listID}/items/{itemID}/position
+func (h *WatchlistHandler) ReorderItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+	itemID := vars["itemID"]
+
+	if userID == "" || itemID == "" {
+		http.Error(w, "User ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.WatchlistService.ReorderItem(userID, listID, itemID, req)
+	if err != nil {
+		h.Logger.Error("Error reordering item %s for user %s: %v", itemID, userID, err)
+		http.Error(w, fmt.Sprintf("Error reordering item: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+	h.Logger.Success("Successfully reordered item %s for user %s", itemID, userID)
+}
+
+// SetPriority handles PUT /api/watchlist/{userID}/{itemID}/priority
+// and PUT /api/users/{userID}/lists/{listID}/items/{itemID}/priority
+func (h *WatchlistHandler) SetPriority(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+	itemID := vars["itemID"]
+
+	if userID == "" || itemID == "" {
+		http.Error(w, "User ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var requestBody struct {
+		Priority string `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.WatchlistService.SetPriority(userID, listID, itemID, requestBody.Priority)
+	if err != nil {
+		h.Logger.Error("Error setting priority of item %s for user %s: %v", itemID, userID, err)
+		http.Error(w, fmt.Sprintf("Error setting priority: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+	h.Logger.Success("Successfully set priority of item %s for user %s", itemID, userID)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// parseWatchlistQuery reads the filter, sort and pagination parameters of a
+// list request. added_after accepts RFC 3339 timestamps or plain dates, and
+// tag may be repeated to require several tags.
+func parseWatchlistQuery(r *http.Request) (models.WatchlistQuery, error) {
+	params := r.URL.Query()
+	query := models.WatchlistQuery{
+		Status:    params.Get("status"),
+		Genre:     params.Get("genre"),
+		MediaType: params.Get("media_type"),
+		Text:      params.Get("q"),
+		Sort:      params.Get("sort"),
+		Order:     params.Get("order"),
+		Cursor:    params.Get("cursor"),
+		Tags:      params["tag"],
+	}
+
+	if v := params.Get("min_rating"); v != "" {
+		rating, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return query, fmt.Errorf("invalid min_rating %q", v)
+		}
+		query.MinRating = rating
+	}
+
+	if v := params.Get("added_after"); v != "" {
+		addedAfter, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			addedAfter, err = time.Parse("2006-01-02", v)
+		}
+		if err != nil {
+			return query, fmt.Errorf("invalid added_after %q, use YYYY-MM-DD or RFC 3339", v)
+		}
+		query.AddedAfter = &addedAfter
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return query, fmt.Errorf("invalid limit %q", v)
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+	"r.a.w/backend/internal/services"
+)
+
+// GetShares handles GET /api/watchlist/{userID}/shares
+func (h *WatchlistHandler) GetShares(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	shares, err := h.WatchlistService.GetShares(userID)
+	if err != nil {
+		h.Logger.Error("Error fetching shares for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching shares: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shares)
+	h.Logger.Success("Successfully fetched shares for user %s", userID)
+}
+
+// UpdateShare handles PUT /api/watchlist/{userID}/shares/{shareID}
+func (h *WatchlistHandler) UpdateShare(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	shareID := vars["shareID"]
+
+	if userID == "" || shareID == "" {
+		http.Error(w, "User ID and Share ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var update models.ShareUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	share, err := h.WatchlistService.UpdateShare(userID, shareID, update)
+	if err != nil {
+		h.Logger.Error("Error updating share %s for user %s: %v", shareID, userID, err)
+		http.Error(w, fmt.Sprintf("Error updating share: %v", err), shareErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(share)
+	h.Logger.Success("Successfully updated share %s for user %s", shareID, userID)
+}
+
+// RevokeShare handles POST /api/watchlist/{userID}/shares/{shareID}/revoke
+func (h *WatchlistHandler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	shareID := vars["shareID"]
+
+	if userID == "" || shareID == "" {
+		http.Error(w, "User ID and Share ID are required", http.StatusBadRequest)
+		return
+	}
+
+	share, err := h.WatchlistService.RevokeShare(userID, shareID)
+	if err != nil {
+		h.Logger.Error("Error revoking share %s for user %s: %v", shareID, userID, err)
+		http.Error(w, fmt.Sprintf("Error revoking share: %v", err), shareErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(share)
+	h.Logger.Success("Successfully revoked share %s for user %s", shareID, userID)
+}
+
+// RegenerateShareToken handles POST /api/watchlist/{userID}/shares/{shareID}/token
+func (h *WatchlistHandler) RegenerateShareToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	shareID := vars["shareID"]
+
+	if userID == "" || shareID == "" {
+		http.Error(w, "User ID and Share ID are required", http.StatusBadRequest)
+		return
+	}
+
+	share, err := h.WatchlistService.RegenerateShareToken(userID, shareID)
+	if err != nil {
+		h.Logger.Error("Error regenerating token of share %s for user %s: %v", shareID, userID, err)
+		http.Error(w, fmt.Sprintf("Error regenerating share token: %v", err), shareErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(share)
+	h.Logger.Success("Successfully regenerated token of share %s for user %s", shareID, userID)
+}
+
+// DeleteShare handles DELETE /api/watchlist/{userID}/shares/{shareID}
+func (h *WatchlistHandler) DeleteShare(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	shareID := vars["shareID"]
+
+	if userID == "" || shareID == "" {
+		http.Error(w, "User ID and Share ID are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.DeleteShare(userID, shareID); err != nil {
+		h.Logger.Error("Error deleting share %s for user %s: %v", shareID, userID, err)
+		http.Error(w, fmt.Sprintf("Error deleting share: %v", err), shareErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "success",
+		"message": "Share deleted",
+	})
+	h.Logger.Success("Successfully deleted share %s for user %s", shareID, userID)
+}
+
+// GetShareAccessLog handles GET /api/watchlist/{userID}/shares/{shareID}/access
+func (h *WatchlistHandler) GetShareAccessLog(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	shareID := vars["shareID"]
+
+	if userID == "" || shareID == "" {
+		http.Error(w, "User ID and Share ID are required", http.StatusBadRequest)
+		return
+	}
+
+	log, err := h.WatchlistService.GetShareAccessLog(userID, shareID)
+	if err != nil {
+		h.Logger.Error("Error fetching access log of share %s for user %s: %v", shareID, userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching share access log: %v", err), shareErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(log)
+	h.Logger.Success("Successfully fetched access log of share %s for user %s", shareID, userID)
+}
+
+// CloneSharedWatchlist handles POST /api/shared/{shareToken}/clone. The
+// caller is identified by the user_id query parameter, like viewers of the share.
+func (h *WatchlistHandler) CloneSharedWatchlist(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	shareToken := vars["shareToken"]
+	viewer := models.ShareViewer{
+		UserID:    r.URL.Query().Get("user_id"),
+		Password:  r.Header.Get("X-Share-Password"),
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+
+	if shareToken == "" || viewer.UserID == "" {
+		http.Error(w, "Share token and User ID are required", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional; an empty body merges into the default list
+	var req models.CloneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.WatchlistService.CloneSharedWatchlist(shareToken, viewer, req)
+	if err != nil {
+		h.Logger.Error("Error cloning shared watchlist with token %s for user %s: %v", shareToken, viewer.UserID, err)
+		http.Error(w, fmt.Sprintf("Error cloning shared watchlist: %v", err), shareErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+	h.Logger.Success("Successfully cloned shared watchlist with token %s for user %s", shareToken, viewer.UserID)
+}
+
+// clientIP returns the address a request came from, without its port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// shareErrorStatus maps a share error to an HTTP status code, falling back to
+// the list errors for everything else
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrSharePasswordRequired), errors.Is(err, services.ErrShareWrongPassword):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrShareNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrShareGone):
+		return http.StatusGone
+	default:
+		return watchlistErrorStatus(err)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+)
+
+// GetTags handles GET /api/watchlist/{userID}/tags
+func (h *WatchlistHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.WatchlistService.GetTags(userID)
+	if err != nil {
+		h.Logger.Error("Error fetching tags for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching tags: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+	h.Logger.Success("Successfully fetched tags for user %s", userID)
+}
+
+// UpdateTags handles POST /api/watchlist/{userID}/tags and .../tags/remove,
+// and the same routes under /api/users/{userID}/lists/{listID}
+func (h *WatchlistHandler) UpdateTags(add bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		userID := vars["userID"]
+		listID := vars["listID"]
+
+		if userID == "" {
+			http.Error(w, "User ID is required", http.StatusBadRequest)
+			return
+		}
+
+		var update models.TagUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var items []models.WatchlistItem
+		var err error
+		if add {
+			items, err = h.WatchlistService.AddTags(userID, listID, update)
+		} else {
+			items, err = h.WatchlistService.RemoveTags(userID, listID, update)
+		}
+		if err != nil {
+			h.Logger.Error("Error updating tags for user %s: %v", userID, err)
+			http.Error(w, fmt.Sprintf("Error updating tags: %v", err), watchlistErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(items)
+		h.Logger.Success("Successfully updated tags on %d item(s) for user %s", len(items), userID)
+	}
+}
+
+// RenameTag handles POST /api/watchlist/{userID}/tags/rename
+func (h *WatchlistHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var rename models.TagRename
+	if err := json.NewDecoder(r.Body).Decode(&rename); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	changed, err := h.WatchlistService.RenameTag(userID, rename)
+	if err != nil {
+		h.Logger.Error("Error renaming tag for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error renaming tag: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":          rename.From,
+		"to":            rename.To,
+		"items_changed": changed,
+	})
+	h.Logger.Success("Successfully renamed tag %q to %q for user %s", rename.From, rename.To, userID)
+}
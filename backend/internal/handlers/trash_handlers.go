@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// GetTrash handles GET /api/watchlist/{userID}/trash
+func (h *WatchlistHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.WatchlistService.GetTrash(userID)
+	if err != nil {
+		h.Logger.Error("Error fetching trash for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching trash: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+	h.Logger.Success("Successfully fetched trash for user %s", userID)
+}
+
+// RestoreItem handles POST /api/watchlist/{userID}/trash/{itemID}/restore
+func (h *WatchlistHandler) RestoreItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	itemID := vars["itemID"]
+
+	if userID == "" || itemID == "" {
+		http.Error(w, "User ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.WatchlistService.RestoreItem(userID, itemID)
+	if err != nil {
+		h.Logger.Error("Error restoring item %s for user %s: %v", itemID, userID, err)
+		http.Error(w, fmt.Sprintf("Error restoring item: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+	h.Logger.Success("Successfully restored item %s for user %s", itemID, userID)
+}
+
+// PurgeItem handles DELETE /api/watchlist/{userID}/trash/{itemID}
+func (h *WatchlistHandler) PurgeItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	itemID := vars["itemID"]
+
+	if userID == "" || itemID == "" {
+		http.Error(w, "User ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.PurgeItem(userID, itemID); err != nil {
+		h.Logger.Error("Error purging item %s for user %s: %v", itemID, userID, err)
+		http.Error(w, fmt.Sprintf("Error purging item: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Item permanently deleted"})
+	h.Logger.Success("Successfully purged item %s for user %s", itemID, userID)
+}
+
+// EmptyTrash handles DELETE /api/watchlist/{userID}/trash
+func (h *WatchlistHandler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.EmptyTrash(userID); err != nil {
+		h.Logger.Error("Error emptying trash for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error emptying trash: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Trash emptied"})
+	h.Logger.Success("Successfully emptied trash for user %s", userID)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// GetTVProgress handles GET /api/watchlist/{userID}/{itemID}/progress
+func (h *WatchlistHandler) GetTVProgress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	itemID := vars["itemID"]
+
+	if userID == "" || itemID == "" {
+		http.Error(w, "User ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.WatchlistService.GetTVProgress(userID, itemID)
+	if err != nil {
+		h.Logger.Error("Error fetching TV progress for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching TV progress: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+	h.Logger.Success("Successfully fetched TV progress for user %s", userID)
+}
+
+// MarkEpisodeWatched handles PUT /api/watchlist/{userID}/{itemID}/seasons/{season}/episodes/{episode}/watched
+// and PUT .../unwatched
+func (h *WatchlistHandler) MarkEpisodeWatched(watched bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		userID := vars["userID"]
+		itemID := vars["itemID"]
+
+		season, err := strconv.Atoi(vars["season"])
+		if err != nil {
+			http.Error(w, "Invalid season number", http.StatusBadRequest)
+			return
+		}
+		episode, err := strconv.Atoi(vars["episode"])
+		if err != nil {
+			http.Error(w, "Invalid episode number", http.StatusBadRequest)
+			return
+		}
+
+		summary, err := h.WatchlistService.MarkEpisodeWatched(userID, itemID, season, episode, watched)
+		if err != nil {
+			h.Logger.Error("Error updating episode S%02dE%02d for user %s: %v", season, episode, userID, err)
+			http.Error(w, fmt.Sprintf("Error updating episode: %v", err), watchlistErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+		h.Logger.Success("Successfully updated episode S%02dE%02d for user %s", season, episode, userID)
+	}
+}
+
+// MarkSeasonWatched handles PUT /api/watchlist/{userID}/{itemID}/seasons/{season}/watched
+// and PUT .../unwatched
+func (h *WatchlistHandler) MarkSeasonWatched(watched bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		userID := vars["userID"]
+		itemID := vars["itemID"]
+
+		season, err := strconv.Atoi(vars["season"])
+		if err != nil {
+			http.Error(w, "Invalid season number", http.StatusBadRequest)
+			return
+		}
+
+		summary, err := h.WatchlistService.MarkSeasonWatched(userID, itemID, season, watched)
+		if err != nil {
+			h.Logger.Error("Error updating season %d for user %s: %v", season, userID, err)
+			http.Error(w, fmt.Sprintf("Error updating season: %v", err), watchlistErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+		h.Logger.Success("Successfully updated season %d for user %s", season, userID)
+	}
+}
+
+// MarkWatchedUpTo handles PUT /api/watchlist/{userID}/{itemID}/progress
+// with a body like {"up_to": "S02E05"}
+func (h *WatchlistHandler) MarkWatchedUpTo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	itemID := vars["itemID"]
+
+	var requestBody struct {
+		UpTo string `json:"up_to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	season, episode, err := parseEpisodeCode(requestBody.UpTo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.WatchlistService.MarkWatchedUpTo(userID, itemID, season, episode)
+	if err != nil {
+		h.Logger.Error("Error marking up to %s for user %s: %v", requestBody.UpTo, userID, err)
+		http.Error(w, fmt.Sprintf("Error updating progress: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+	h.Logger.Success("Successfully marked up to %s as watched for user %s", requestBody.UpTo, userID)
+}
+
+// parseEpisodeCode parses codes like "S02E05" (case-insensitive)
+func parseEpisodeCode(code string) (int, int, error) {
+	var season, episode int
+	_, err := fmt.Sscanf(strings.ToUpper(code), "S%dE%d", &season, &episode)
+	if err != nil || season < 1 || episode < 1 {
+		return 0, 0, fmt.Errorf("invalid episode code %q, expected format S02E05", code)
+	}
+	return season, episode, nil
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetVersions handles GET /api/watchlist/{userID}/versions
+// and GET /api/users/{userID}/lists/{listID}/versions
+func (h *WatchlistHandler) GetVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := h.WatchlistService.GetVersions(userID, listID)
+	if err != nil {
+		h.Logger.Error("Error fetching versions for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching versions: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+	h.Logger.Success("Successfully fetched versions for user %s", userID)
+}
+
+// GetVersion handles GET /api/watchlist/{userID}/versions/{version}
+func (h *WatchlistHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	number, err := strconv.Atoi(vars["version"])
+	if userID == "" || err != nil {
+		http.Error(w, "User ID and a numeric version are required", http.StatusBadRequest)
+		return
+	}
+
+	version, err := h.WatchlistService.GetVersion(userID, listID, number)
+	if err != nil {
+		h.Logger.Error("Error fetching version %d for user %s: %v", number, userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching version: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(version)
+	h.Logger.Success("Successfully fetched version %d for user %s", number, userID)
+}
+
+// DiffVersions handles GET /api/watchlist/{userID}/versions/diff?from=&to=.
+// Without to, the version is compared with the current list.
+func (h *WatchlistHandler) DiffVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "A numeric from version is required", http.StatusBadRequest)
+		return
+	}
+	to := 0
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid to version", http.StatusBadRequest)
+			return
+		}
+	}
+
+	diff, err := h.WatchlistService.DiffVersions(userID, listID, from, to)
+	if err != nil {
+		h.Logger.Error("Error diffing versions for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error diffing versions: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+	h.Logger.Success("Successfully diffed versions %d and %d for user %s", from, to, userID)
+}
+
+// RestoreVersion handles POST /api/watchlist/{userID}/versions/{version}/restore
+func (h *WatchlistHandler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	listID := vars["listID"]
+
+	number, err := strconv.Atoi(vars["version"])
+	if userID == "" || err != nil {
+		http.Error(w, "User ID and a numeric version are required", http.StatusBadRequest)
+		return
+	}
+
+	watchlist, err := h.WatchlistService.RestoreVersion(userID, listID, number)
+	if err != nil {
+		h.Logger.Error("Error restoring version %d for user %s: %v", number, userID, err)
+		http.Error(w, fmt.Sprintf("Error restoring version: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(watchlist)
+	h.Logger.Success("Successfully restored version %d for user %s", number, userID)
+}
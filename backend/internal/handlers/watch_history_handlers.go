@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"r.a.w/backend/internal/models"
+)
+
+// GetWatchHistory handles GET /api/watchlist/{userID}/history?item_id=
+func (h *WatchlistHandler) GetWatchHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	itemID := r.URL.Query().Get("item_id")
+
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.WatchlistService.GetWatchHistory(userID, itemID)
+	if err != nil {
+		h.Logger.Error("Error fetching watch history for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error fetching watch history: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+	h.Logger.Success("Successfully fetched watch history for user %s", userID)
+}
+
+// UpdateWatchEvent handles PUT /api/watchlist/{userID}/history/{eventID}
+func (h *WatchlistHandler) UpdateWatchEvent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	eventID := vars["eventID"]
+
+	if userID == "" || eventID == "" {
+		http.Error(w, "User ID and Event ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var input models.WatchEventInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.WatchlistService.UpdateWatchEvent(userID, eventID, input)
+	if err != nil {
+		h.Logger.Error("Error updating watch event %s for user %s: %v", eventID, userID, err)
+		http.Error(w, fmt.Sprintf("Error updating watch event: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(event)
+	h.Logger.Success("Successfully updated watch event %s for user %s", eventID, userID)
+}
+
+// DeleteWatchEvent handles DELETE /api/watchlist/{userID}/history/{eventID}
+func (h *WatchlistHandler) DeleteWatchEvent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	eventID := vars["eventID"]
+
+	if userID == "" || eventID == "" {
+		http.Error(w, "User ID and Event ID are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WatchlistService.DeleteWatchEvent(userID, eventID); err != nil {
+		h.Logger.Error("Error deleting watch event %s for user %s: %v", eventID, userID, err)
+		http.Error(w, fmt.Sprintf("Error deleting watch event: %v", err), watchlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Watch event deleted"})
+	h.Logger.Success("Successfully deleted watch event %s for user %s", eventID, userID)
+}
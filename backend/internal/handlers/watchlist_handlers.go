@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"r.a.w/backend/internal/models"
@@ -15,14 +18,20 @@ import (
 type WatchlistHandler struct {
 	WatchlistService *services.WatchlistService
 	ExportService    *services.ExportService
+	ExportJobs       *services.ExportJobService
 	Logger           *logger.Logger
+
+	// ModerationToken must be sent in the X-Moderation-Token header of
+	// moderation requests; moderation is disabled while it is empty
+	ModerationToken string
 }
 
 // NewWatchlistHandler creates a new WatchlistHandler
-func NewWatchlistHandler(watchlistService *services.WatchlistService, exportService *services.ExportService, logger *logger.Logger) *WatchlistHandler {
+func NewWatchlistHandler(watchlistService *services.WatchlistService, exportService *services.ExportService, exportJobs *services.ExportJobService, logger *logger.Logger) *WatchlistHandler {
 	return &WatchlistHandler{
 		WatchlistService: watchlistService,
 		ExportService:    exportService,
+		ExportJobs:       exportJobs,
 		Logger:           logger,
 	}
 }
@@ -37,10 +46,16 @@ func (h *WatchlistHandler) GetWatchlist(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	
-	watchlist, err := h.WatchlistService.GetWatchlist(userID)
+	query, err := parseWatchlistQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	watchlist, err := h.WatchlistService.QueryWatchlist(userID, query)
 	if err != nil {
 		h.Logger.Error("Error fetching watchlist for user %s: %v", userID, err)
-		http.Error(w, fmt.Sprintf("Error fetching watchlist: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error fetching watchlist: %v", err), watchlistErrorStatus(err))
 		return
 	}
 	
@@ -59,21 +74,26 @@ func (h *WatchlistHandler) AddToWatchlist(w http.ResponseWriter, r *http.Request
 		return
 	}
 	
-	var item models.WatchlistItem
-	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+	// Only the media type and TMDB id are accepted; metadata is fetched server-side
+	var requestBody struct {
+		MediaType string `json:"media_type"`
+		ID        int    `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 	
-	if err := h.WatchlistService.AddToWatchlist(userID, item); err != nil {
+	item, err := h.WatchlistService.AddToWatchlist(userID, requestBody.MediaType, requestBody.ID)
+	if err != nil {
 		h.Logger.Error("Error adding to watchlist for user %s: %v", userID, err)
-		http.Error(w, fmt.Sprintf("Error adding to watchlist: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error adding to watchlist: %v", err), watchlistErrorStatus(err))
 		return
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Movie added to watchlist"})
-	h.Logger.Success("Successfully added movie to watchlist for user %s", userID)
+	json.NewEncoder(w).Encode(item)
+	h.Logger.Success("Successfully added %s %d to watchlist for user %s", item.MediaType, item.MovieID, userID)
 }
 
 // RemoveFromWatchlist handles DELETE /api/watchlist/{userID}/{itemID}
@@ -89,7 +109,7 @@ func (h *WatchlistHandler) RemoveFromWatchlist(w http.ResponseWriter, r *http.Re
 	
 	if err := h.WatchlistService.RemoveFromWatchlist(userID, itemID); err != nil {
 		h.Logger.Error("Error removing from watchlist for user %s: %v", userID, err)
-		http.Error(w, fmt.Sprintf("Error removing from watchlist: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error removing from watchlist: %v", err), watchlistErrorStatus(err))
 		return
 	}
 	
@@ -109,19 +129,22 @@ func (h *WatchlistHandler) MarkAsWatched(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	
-	var requestBody struct {
-		Notes string `json:"notes"`
+	// The body is optional; an empty body records a watch right now
+	var input models.WatchEventInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
 	}
-	json.NewDecoder(r.Body).Decode(&requestBody)
 	
-	if err := h.WatchlistService.MarkAsWatched(userID, itemID, requestBody.Notes); err != nil {
+	event, err := h.WatchlistService.MarkAsWatched(userID, itemID, input)
+	if err != nil {
 		h.Logger.Error("Error marking as watched for user %s: %v", userID, err)
-		http.Error(w, fmt.Sprintf("Error marking as watched: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error marking as watched: %v", err), watchlistErrorStatus(err))
 		return
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Movie marked as watched"})
+	json.NewEncoder(w).Encode(event)
 	h.Logger.Success("Successfully marked movie as watched for user %s", userID)
 }
 
@@ -138,7 +161,7 @@ func (h *WatchlistHandler) MarkAsUnwatched(w http.ResponseWriter, r *http.Reques
 	
 	if err := h.WatchlistService.MarkAsUnwatched(userID, itemID); err != nil {
 		h.Logger.Error("Error marking as unwatched for user %s: %v", userID, err)
-		http.Error(w, fmt.Sprintf("Error marking as unwatched: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error marking as unwatched: %v", err), watchlistErrorStatus(err))
 		return
 	}
 	
@@ -147,6 +170,35 @@ func (h *WatchlistHandler) MarkAsUnwatched(w http.ResponseWriter, r *http.Reques
 	h.Logger.Success("Successfully marked movie as unwatched for user %s", userID)
 }
 
+// UpdatePersonalData handles PUT /api/watchlist/{userID}/{itemID}/review
+func (h *WatchlistHandler) UpdatePersonalData(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	itemID := vars["itemID"]
+	
+	if userID == "" || itemID == "" {
+		http.Error(w, "User ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+	
+	var update models.PersonalDataUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	item, err := h.WatchlistService.UpdatePersonalData(userID, itemID, update)
+	if err != nil {
+		h.Logger.Error("Error updating personal data for user %s: %v", userID, err)
+		http.Error(w, fmt.Sprintf("Error updating rating and review: %v", err), watchlistErrorStatus(err))
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+	h.Logger.Success("Successfully updated rating and review for user %s", userID)
+}
+
 // GetWatchlistStats handles GET /api/watchlist/{userID}/stats
 func (h *WatchlistHandler) GetWatchlistStats(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -169,11 +221,12 @@ func (h *WatchlistHandler) GetWatchlistStats(w http.ResponseWriter, r *http.Requ
 	h.Logger.Success("Successfully fetched watchlist stats for user %s", userID)
 }
 
-// ExportWatchlist handles GET /api/watchlist/{userID}/export
+// ExportWatchlist handles GET /api/watchlist/{userID}/export. The export is
+// streamed, gzip-compressed when the client accepts it.
 func (h *WatchlistHandler) ExportWatchlist(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID := vars["userID"]
-	format := r.URL.Query().Get("format") // csv or pdf
+	format := r.URL.Query().Get("format") // see ExportService.Formats
 	
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
@@ -184,47 +237,28 @@ func (h *WatchlistHandler) ExportWatchlist(w http.ResponseWriter, r *http.Reques
 		format = "csv"
 	}
 	
-	watchlist, err := h.WatchlistService.GetWatchlist(userID)
+	exporter, err := h.ExportService.Exporter(format)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format. Use one of: %s", strings.Join(h.ExportService.Formats(), ", ")), http.StatusBadRequest)
+		return
+	}
+	
+	opts, err := parseExportOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	export, err := h.WatchlistService.ExportData(userID, models.DefaultListID)
 	if err != nil {
 		h.Logger.Error("Error fetching watchlist for export for user %s: %v", userID, err)
 		http.Error(w, fmt.Sprintf("Error fetching watchlist: %v", err), http.StatusInternalServerError)
 		return
 	}
 	
-	switch format {
-	case "csv":
-		data, err := h.ExportService.ExportToCSV(watchlist)
-		if err != nil {
-			h.Logger.Error("Error exporting to CSV for user %s: %v", userID, err)
-			http.Error(w, fmt.Sprintf("Error exporting to CSV: %v", err), http.StatusInternalServerError)
-			return
-		}
-		
-		w.Header().Set("Content-Type", "text/csv")
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=watchlist_%s.csv", userID))
-		w.Write(data)
-		
-	case "pdf":
-		stats, err := h.WatchlistService.GetWatchlistStats(userID)
-		if err != nil {
-			h.Logger.Error("Error fetching stats for PDF export for user %s: %v", userID, err)
-			http.Error(w, fmt.Sprintf("Error fetching stats: %v", err), http.StatusInternalServerError)
-			return
-		}
-		
-		data, err := h.ExportService.ExportToPDF(watchlist, stats)
-		if err != nil {
-			h.Logger.Error("Error exporting to PDF for user %s: %v", userID, err)
-			http.Error(w, fmt.Sprintf("Error exporting to PDF: %v", err), http.StatusInternalServerError)
-			return
-		}
-		
-		w.Header().Set("Content-Type", "text/html")
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=watchlist_%s.html", userID))
-		w.Write(data)
-		
-	default:
-		http.Error(w, "Invalid format. Use 'csv' or 'pdf'", http.StatusBadRequest)
+	filename := fmt.Sprintf("watchlist_%s.%s", userID, exporter.Extension())
+	if err := streamExport(w, r, exporter, export, opts, filename); err != nil {
+		h.Logger.Error("Error exporting to %s for user %s: %v", format, userID, err)
 		return
 	}
 	
@@ -241,21 +275,16 @@ func (h *WatchlistHandler) CreateShareableWatchlist(w http.ResponseWriter, r *ht
 		return
 	}
 	
-	var requestBody struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		IsPublic    bool   `json:"is_public"`
-	}
-	
+	var requestBody models.ShareRequest
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 	
-	shareableWatchlist, err := h.WatchlistService.CreateShareableWatchlist(userID, requestBody.Title, requestBody.Description, requestBody.IsPublic)
+	shareableWatchlist, err := h.WatchlistService.CreateShareableWatchlist(userID, requestBody)
 	if err != nil {
 		h.Logger.Error("Error creating shareable watchlist for user %s: %v", userID, err)
-		http.Error(w, fmt.Sprintf("Error creating shareable watchlist: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error creating shareable watchlist: %v", err), shareErrorStatus(err))
 		return
 	}
 	
@@ -264,24 +293,48 @@ func (h *WatchlistHandler) CreateShareableWatchlist(w http.ResponseWriter, r *ht
 	h.Logger.Success("Successfully created shareable watchlist for user %s", userID)
 }
 
-// GetSharedWatchlist handles GET /api/shared/{shareToken}
+// GetSharedWatchlist handles GET /api/shared/{shareToken}. Private shares are
+// only shown to their creator, identified by the user_id query parameter.
+// Password protected shares take the password in the X-Share-Password header.
 func (h *WatchlistHandler) GetSharedWatchlist(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	shareToken := vars["shareToken"]
+	viewer := models.ShareViewer{
+		UserID:    r.URL.Query().Get("user_id"),
+		Password:  r.Header.Get("X-Share-Password"),
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
 	
 	if shareToken == "" {
 		http.Error(w, "Share token is required", http.StatusBadRequest)
 		return
 	}
 	
-	sharedWatchlist, err := h.WatchlistService.GetSharedWatchlist(shareToken)
+	sharedWatchlist, err := h.WatchlistService.GetSharedWatchlist(shareToken, viewer)
 	if err != nil {
 		h.Logger.Error("Error fetching shared watchlist with token %s: %v", shareToken, err)
-		http.Error(w, fmt.Sprintf("Error fetching shared watchlist: %v", err), http.StatusNotFound)
+		http.Error(w, fmt.Sprintf("Error fetching shared watchlist: %v", err), shareErrorStatus(err))
 		return
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(sharedWatchlist)
 	h.Logger.Success("Successfully fetched shared watchlist with token %s", shareToken)
-}
\ No newline at end of file
+}
+
+// watchlistErrorStatus maps list and item errors to HTTP status codes
+func watchlistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrItemExists):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrTitleNotFound), errors.Is(err, services.ErrItemNotFound), errors.Is(err, services.ErrListNotFound),
+		errors.Is(err, services.ErrEpisodeNotFound), errors.Is(err, services.ErrEventNotFound), errors.Is(err, services.ErrTrashNotFound),
+		errors.Is(err, services.ErrVersionNotFound), errors.Is(err, services.ErrImportNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// Bucket sizes of the analytics time series
+const (
+	IntervalMonth = "month"
+	IntervalWeek  = "week"
+)
+
+// AnalyticsQuery limits analytics to watches in [From, To) of one media
+// type. Nil bounds and an empty media type are not filtered on; an empty
+// interval means monthly buckets.
+type AnalyticsQuery struct {
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+	MediaType string     `json:"media_type,omitempty"`
+	Interval  string     `json:"interval,omitempty"`
+}
+
+// WatchlistAnalytics describes a user's viewing habits from their watch
+// history. Watches count every watch event, rewatches included; titles
+// count each title once. Top directors come from the credits, so the
+// creators of a show are not among them; titles stored before credits were
+// fetched get them at their next metadata refresh. Days and buckets are in UTC.
+type WatchlistAnalytics struct {
+	Query         AnalyticsQuery `json:"query"`
+	TotalWatches  int            `json:"total_watches"`
+	TitlesWatched int            `json:"titles_watched"`
+
+	WatchesOverTime []TimeBucket   `json:"watches_over_time"`
+	Runtime         RuntimeSummary `json:"runtime"`
+	Decades         []DecadeCount  `json:"decades"`
+	TopDirectors    []PersonCount  `json:"top_directors"`
+	TopActors       []PersonCount  `json:"top_actors"`
+	AddToWatch      AddToWatchTime `json:"add_to_watch"`
+	Streaks         WatchStreaks   `json:"streaks"`
+	YearsInReview   []YearInReview `json:"years_in_review"`
+}
+
+// TimeBucket counts the watches and time spent watching of one month or week
+type TimeBucket struct {
+	Start          time.Time `json:"start"`
+	Label          string    `json:"label"` // 2024-03 or 2024-W10
+	Watches        int       `json:"watches"`
+	RuntimeMinutes int       `json:"runtime_minutes"`
+}
+
+// RuntimeSummary is the time spent watching: every watch of a movie, and
+// every episode of a show when it was watched. A show marked watched without
+// any of its episodes counts them all, once. AverageMinutes is per movie or
+// episode; watches and episodes without a known runtime are left out.
+type RuntimeSummary struct {
+	TotalMinutes   int     `json:"total_minutes"`
+	AverageMinutes float64 `json:"average_minutes"`
+	UnknownWatches int     `json:"unknown_watches"`
+}
+
+// DecadeCount counts watched titles released in a decade, such as 1990
+type DecadeCount struct {
+	Decade int `json:"decade"`
+	Titles int `json:"titles"`
+}
+
+// PersonCount counts watched titles a director or actor worked on
+type PersonCount struct {
+	Name   string `json:"name"`
+	Titles int    `json:"titles"`
+}
+
+// AddToWatchTime is how long titles stayed on a list before their first
+// watch. Titles watched before they were added are left out.
+type AddToWatchTime struct {
+	Titles      int     `json:"titles"`
+	AverageDays float64 `json:"average_days"`
+	MedianDays  float64 `json:"median_days"`
+}
+
+// WatchStreaks are runs of consecutive days or weeks with at least one
+// watch. A current streak is still going if it reaches the end of the range
+// or the day (or week) before it.
+type WatchStreaks struct {
+	CurrentDays   int        `json:"current_days"`
+	LongestDays   int        `json:"longest_days"`
+	CurrentWeeks  int        `json:"current_weeks"`
+	LongestWeeks  int        `json:"longest_weeks"`
+	LastWatchedAt *time.Time `json:"last_watched_at,omitempty"`
+}
+
+// YearInReview summarizes the watches of one calendar year
+type YearInReview struct {
+	Year           int      `json:"year"`
+	Watches        int      `json:"watches"`
+	Titles         int      `json:"titles"`
+	RuntimeMinutes int      `json:"runtime_minutes"`
+	TopGenres      []string `json:"top_genres,omitempty"`
+	TopDirector    string   `json:"top_director,omitempty"`
+	TopActor       string   `json:"top_actor,omitempty"`
+	BusiestMonth   string   `json:"busiest_month,omitempty"`
+	HighestRated   string   `json:"highest_rated,omitempty"` // by personal rating
+}
@@ -0,0 +1,51 @@
+package models
+
+// Batch operation names
+const (
+	BatchOpAdd       = "add"
+	BatchOpRemove    = "remove"
+	BatchOpWatched   = "watched"
+	BatchOpUnwatched = "unwatched"
+	BatchOpTag       = "tag"
+	BatchOpUntag     = "untag"
+	BatchOpMove      = "move"
+)
+
+// BatchOperation is one step of a batch request. Which fields are used
+// depends on Op: add takes MediaType and ID, move takes TargetListID,
+// tag and untag take Tags, watched takes an optional Watch, and every
+// operation except add takes ItemID.
+type BatchOperation struct {
+	Op           string           `json:"op"`
+	ItemID       string           `json:"item_id,omitempty"`
+	MediaType    string           `json:"media_type,omitempty"`
+	ID           int              `json:"id,omitempty"`
+	Tags         []string         `json:"tags,omitempty"`
+	TargetListID string           `json:"target_list_id,omitempty"`
+	Watch        *WatchEventInput `json:"watch,omitempty"`
+}
+
+// BatchRequest is a list of operations applied to one list as a unit.
+// With DryRun set, the operations are checked but nothing is saved.
+type BatchRequest struct {
+	DryRun     bool             `json:"dry_run"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchResult reports the outcome of one operation
+type BatchResult struct {
+	Index int            `json:"index"`
+	Op    string         `json:"op"`
+	OK    bool           `json:"ok"`
+	Error string         `json:"error,omitempty"`
+	Item  *WatchlistItem `json:"item,omitempty"`
+	Event *WatchEvent    `json:"event,omitempty"`
+}
+
+// BatchResponse reports a batch. Applied is false for dry runs and when any
+// operation failed, in which case nothing was saved.
+type BatchResponse struct {
+	DryRun  bool          `json:"dry_run"`
+	Applied bool          `json:"applied"`
+	Results []BatchResult `json:"results"`
+}
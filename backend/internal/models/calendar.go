@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Release types
+const (
+	ReleaseTheatrical = "theatrical"
+	ReleaseDigital    = "digital"
+	ReleaseEpisode    = "episode"
+)
+
+// ReleaseDate is one dated release of a watchlist item
+type ReleaseDate struct {
+	Type string `json:"type"`
+	// Date is the release day, YYYY-MM-DD
+	Date    string `json:"date"`
+	Country string `json:"country,omitempty"`
+
+	// Episode releases only
+	SeasonNumber  int    `json:"season_number,omitempty"`
+	EpisodeNumber int    `json:"episode_number,omitempty"`
+	EpisodeName   string `json:"episode_name,omitempty"`
+}
+
+// CalendarFeed holds the secret token of a user's calendar subscription
+type CalendarFeed struct {
+	UserID    string    `json:"user_id"`
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// URL is the subscription address; it is filled in by the API, not stored
+	URL string `json:"url,omitempty"`
+}
@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+// Roles of a collaborative list's members. Viewers can only look, editors
+// can add, remove and watch items, and the owner also manages membership.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Actions recorded in a collaborative list's activity
+const (
+	ActivityAdded     = "added"
+	ActivityRemoved   = "removed"
+	ActivityWatched   = "watched"
+	ActivityUnwatched = "unwatched"
+	ActivityJoined    = "joined"
+	ActivityLeft      = "left"
+)
+
+// Collaboration makes one of a user's lists shared with invited members.
+// The list itself stays stored with its owner.
+type Collaboration struct {
+	ID        string         `json:"id"`
+	OwnerID   string         `json:"owner_id"`
+	ListID    string         `json:"list_id"`
+	Members   []ListMember   `json:"members"`
+	Invites   []ListInvite   `json:"invites,omitempty"`
+	Activity  []ListActivity `json:"activity,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// ListMember is a member of a collaborative list. The owner is always the
+// first member.
+type ListMember struct {
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	InvitedBy string    `json:"invited_by,omitempty"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+// ListInvite is a single-use invitation to join a collaborative list
+type ListInvite struct {
+	ID        string    `json:"id"`
+	Token     string    `json:"token"`
+	Role      string    `json:"role"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// InviteRequest is the body of a request to invite someone to a list
+type InviteRequest struct {
+	Role      string     `json:"role"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ListActivity records a change made by a member of a collaborative list
+type ListActivity struct {
+	At     time.Time `json:"at"`
+	UserID string    `json:"user_id"`
+	Action string    `json:"action"`
+	ItemID string    `json:"item_id,omitempty"`
+	Title  string    `json:"title,omitempty"`
+}
+
+// CollaborationSummary describes a collaborative list a user belongs to
+type CollaborationSummary struct {
+	ID        string    `json:"id"`
+	OwnerID   string    `json:"owner_id"`
+	ListID    string    `json:"list_id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	ItemCount int       `json:"item_count"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CollaborativeList is a collaborative list as seen by one of its members.
+// IsWatched and WatchedAt of each item are the viewing member's own;
+// WatchedBy shows every member who has seen it. The owner's episode progress,
+// ratings and reviews are only included for the owner.
+type CollaborativeList struct {
+	ID          string              `json:"id"`
+	OwnerID     string              `json:"owner_id"`
+	ListID      string              `json:"list_id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Role        string              `json:"role"`
+	Members     []ListMember        `json:"members"`
+	Items       []CollaborativeItem `json:"items"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// CollaborativeItem is an item of a collaborative list with the watched
+// state of every member
+type CollaborativeItem struct {
+	WatchlistItem
+	WatchedBy []MemberWatch `json:"watched_by"`
+}
+
+// MemberWatch is one member's watched state of an item
+type MemberWatch struct {
+	UserID     string    `json:"user_id"`
+	WatchCount int       `json:"watch_count"`
+	WatchedAt  time.Time `json:"watched_at"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Sort orders of the discovery feed
+const (
+	DiscoverRecent  = "recent"
+	DiscoverPopular = "popular"
+)
+
+// DiscoveryQuery searches, sorts and paginates the feed of public shares.
+// A zero Limit returns the default page size.
+type DiscoveryQuery struct {
+	Text     string `json:"q,omitempty"` // matched against title and description
+	Sort     string `json:"sort,omitempty"`
+	Featured bool   `json:"featured,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Cursor   string `json:"cursor,omitempty"`
+}
+
+// SharedListing is a public share as listed in the discovery feed
+type SharedListing struct {
+	ShareToken  string    `json:"share_token"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Mode        string    `json:"mode"`
+	ItemCount   int       `json:"item_count"`
+	ViewCount   int       `json:"view_count"`
+	CloneCount  int       `json:"clone_count"`
+	Featured    bool      `json:"featured"`
+	PosterPaths []string  `json:"poster_paths,omitempty"` // of the first few items
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DiscoveryPage is one page of the discovery feed
+type DiscoveryPage struct {
+	Listings []SharedListing `json:"listings"`
+	Total    int             `json:"total"`
+	Next     string          `json:"next,omitempty"`
+}
+
+// ModerationUpdate features a share or unlists it, which takes it out of the
+// discovery feed and closes its link; nil fields are left as they are
+type ModerationUpdate struct {
+	Featured *bool  `json:"featured,omitempty"`
+	Unlisted *bool  `json:"unlisted,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// ExportSchemaVersion is the version of the JSON and NDJSON export formats.
+// It changes whenever a field is renamed or removed.
+const ExportSchemaVersion = 1
+
+// ExportGenerator identifies exports written by this application
+const ExportGenerator = "relax-and-watch"
+
+// WatchlistExport is a full-fidelity export of one list together with the
+// watch events of its titles, from which watched state is derived
+type WatchlistExport struct {
+	ExportSchemaVersion int          `json:"export_schema_version"`
+	Generator           string       `json:"generator"`
+	ExportedAt          time.Time    `json:"exported_at"`
+	Watchlist           *Watchlist   `json:"watchlist"`
+	WatchEvents         []WatchEvent `json:"watch_events"`
+}
+
+// NDJSON record types. The first record is the header, carrying the export
+// metadata and the list without its items; items and watch events follow.
+const (
+	NDJSONRecordHeader     = "header"
+	NDJSONRecordItem       = "item"
+	NDJSONRecordWatchEvent = "watch_event"
+)
+
+// NDJSONRecord is one line of an NDJSON export
+type NDJSONRecord struct {
+	Type string `json:"type"`
+
+	ExportSchemaVersion int        `json:"export_schema_version,omitempty"`
+	Generator           string     `json:"generator,omitempty"`
+	ExportedAt          *time.Time `json:"exported_at,omitempty"`
+	Watchlist           *Watchlist `json:"watchlist,omitempty"`
+
+	Item       *WatchlistItem `json:"item,omitempty"`
+	WatchEvent *WatchEvent    `json:"watch_event,omitempty"`
+}
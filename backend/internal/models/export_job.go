@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Export job statuses
+const (
+	ExportJobQueued  = "queued"
+	ExportJobRunning = "running"
+	ExportJobDone    = "done"
+	ExportJobFailed  = "failed"
+)
+
+// ExportJobRequest is the body of a request to start an export job
+type ExportJobRequest struct {
+	Format  string           `json:"format"`
+	ListID  string           `json:"list_id,omitempty"`
+	Options ExportJobOptions `json:"options"`
+}
+
+// ExportJobOptions are the format specific settings of an export job
+type ExportJobOptions struct {
+	Posters bool   `json:"posters,omitempty"`
+	Theme   string `json:"theme,omitempty"`
+	Locale  string `json:"lang,omitempty"`
+}
+
+// ExportJob is an export running in the background. Once done, its artifact
+// can be downloaded until ExpiresAt.
+type ExportJob struct {
+	ID      string           `json:"id"`
+	UserID  string           `json:"user_id"`
+	ListID  string           `json:"list_id"`
+	Format  string           `json:"format"`
+	Options ExportJobOptions `json:"options"`
+	Status  string           `json:"status"`
+	Error   string           `json:"error,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+
+	// Artifact details, set once the job is done
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+
+	// DownloadToken authorizes downloads of the artifact; it only appears in DownloadURL
+	DownloadToken string `json:"download_token,omitempty"`
+	// DownloadURL is filled in by the API, not stored
+	DownloadURL string `json:"download_url,omitempty"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// WatchEvent records a single viewing of a title. Events are appended on every
+// watch, so rewatches are kept instead of overwriting a single WatchedAt.
+type WatchEvent struct {
+	ID        string          `json:"id"`
+	ItemID    string          `json:"item_id"`
+	MediaType string          `json:"media_type"`
+	MovieID   int             `json:"movie_id"`
+	Title     string          `json:"title"`
+	WatchedAt time.Time       `json:"watched_at"`
+	Rating    *PersonalRating `json:"rating,omitempty"`
+	Notes     string          `json:"notes,omitempty"`
+	Platform  string          `json:"platform,omitempty"`
+	// Completion is set on events recorded because every episode of a show
+	// was watched; they are removed again if an episode is unwatched
+	Completion bool      `json:"completion,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WatchHistory is a user's log of watch events
+type WatchHistory struct {
+	UserID    string       `json:"user_id"`
+	Events    []WatchEvent `json:"events"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// WatchEventInput describes a new watch event or an edit to an existing one.
+// Nil fields are left untouched on edit; a rating of 0 clears the rating.
+type WatchEventInput struct {
+	WatchedAt *time.Time `json:"watched_at,omitempty"`
+	Rating    *float64   `json:"rating,omitempty"`
+	Scale     int        `json:"scale,omitempty"`
+	Notes     *string    `json:"notes,omitempty"`
+	Platform  *string    `json:"platform,omitempty"`
+}
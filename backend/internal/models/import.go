@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+)
+
+// Import formats
+const (
+	ImportFormatCSV        = "csv" // our own CSV export
+	ImportFormatIMDb       = "imdb"
+	ImportFormatLetterboxd = "letterboxd"
+	ImportFormatTrakt      = "trakt"
+	ImportFormatJSON       = "json"   // our own full-fidelity export
+	ImportFormatNDJSON     = "ndjson" // our own streaming export
+)
+
+// Import row statuses
+const (
+	ImportStatusMatched   = "matched"
+	ImportStatusAmbiguous = "ambiguous"
+	ImportStatusUnmatched = "unmatched"
+	ImportStatusDuplicate = "duplicate"
+	ImportStatusSkipped   = "skipped"
+)
+
+// Import modes. CSV and other provider files are merged into the list; our
+// own JSON and NDJSON exports replace it, and the items they leave out are
+// moved to the trash.
+const (
+	ImportModeMerge   = "merge"
+	ImportModeReplace = "replace"
+)
+
+// ImportRow is one title read from an import file, before it is matched to TMDB
+type ImportRow struct {
+	Line        int        `json:"line"`
+	Title       string     `json:"title"`
+	Year        int        `json:"year,omitempty"`
+	MediaType   string     `json:"media_type,omitempty"`
+	TMDBID      int        `json:"tmdb_id,omitempty"`
+	IMDbID      string     `json:"imdb_id,omitempty"`
+	Watched     bool       `json:"watched"`
+	WatchedAt   *time.Time `json:"watched_at,omitempty"`
+	Rating      *float64   `json:"rating,omitempty"`
+	RatingScale int        `json:"rating_scale,omitempty"`
+	Notes       string     `json:"notes,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+}
+
+// ImportCandidate is a TMDB title an import row may refer to
+type ImportCandidate struct {
+	MediaType  string `json:"media_type"`
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	Year       int    `json:"year,omitempty"`
+	PosterPath string `json:"poster_path,omitempty"`
+}
+
+// ImportRowResult is the outcome of matching and importing one row
+type ImportRowResult struct {
+	Row        ImportRow         `json:"row"`
+	Status     string            `json:"status"`
+	Match      *ImportCandidate  `json:"match,omitempty"`
+	Candidates []ImportCandidate `json:"candidates,omitempty"`
+	Imported   bool              `json:"imported"`
+	ItemID     string            `json:"item_id,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	// Warning says what of the row could not be kept on an imported item
+	Warning string `json:"warning,omitempty"`
+}
+
+// ImportReport summarizes an import. It is kept until its ambiguous rows are
+// confirmed or it expires. Trashed counts the items a replacing import left
+// out and moved to the trash, or would move on a dry run.
+type ImportReport struct {
+	ID         string            `json:"id"`
+	UserID     string            `json:"user_id"`
+	ListID     string            `json:"list_id"`
+	Format     string            `json:"format"`
+	Mode       string            `json:"mode"`
+	DryRun     bool              `json:"dry_run"`
+	Matched    int               `json:"matched"`
+	Ambiguous  int               `json:"ambiguous"`
+	Unmatched  int               `json:"unmatched"`
+	Duplicates int               `json:"duplicates"`
+	Imported   int               `json:"imported"`
+	Trashed    int               `json:"trashed"`
+	Rows       []ImportRowResult `json:"rows"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+}
+
+// ImportChoice resolves an ambiguous row to a TMDB title. An ID of 0 skips the row.
+type ImportChoice struct {
+	Line      int    `json:"line"`
+	MediaType string `json:"media_type"`
+	ID        int    `json:"id"`
+}
+
+// ImportConfirmation is the second step of an import
+type ImportConfirmation struct {
+	Choices []ImportChoice `json:"choices"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// ListInput describes a new list or changes to an existing one.
+// Nil fields are left untouched on update.
+type ListInput struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Icon        *string `json:"icon,omitempty"`
+	Position    *int    `json:"position,omitempty"`
+}
+
+// ListSummary describes a list without its items
+type ListSummary struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Icon        string    `json:"icon,omitempty"`
+	Position    int       `json:"position"`
+	IsDefault   bool      `json:"is_default"`
+	ItemCount   int       `json:"item_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ReorderRequest moves an item to an index, or directly before or after another item.
+// Exactly one of the fields must be set.
+type ReorderRequest struct {
+	Index  *int   `json:"index,omitempty"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// WatchlistQuery filters, sorts and paginates the items of a list.
+// Zero values mean "no filter"; a zero Limit returns every matching item.
+type WatchlistQuery struct {
+	Status     string     `json:"status,omitempty"` // watched or unwatched
+	Genre      string     `json:"genre,omitempty"`
+	Tags       []string   `json:"tags,omitempty"` // items must carry every tag
+	MediaType  string     `json:"media_type,omitempty"`
+	MinRating  float64    `json:"min_rating,omitempty"`
+	AddedAfter *time.Time `json:"added_after,omitempty"`
+	Text       string     `json:"q,omitempty"` // matched against title, overview and notes
+	Sort       string     `json:"sort,omitempty"`
+	Order      string     `json:"order,omitempty"` // asc or desc
+	Limit      int        `json:"limit,omitempty"`
+	Cursor     string     `json:"cursor,omitempty"`
+}
+
+// WatchlistPage is one page of a queried list. It embeds the list so clients
+// that read the whole watchlist keep working.
+type WatchlistPage struct {
+	*Watchlist
+	Total int    `json:"total"`
+	Next  string `json:"next,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// Supported personal rating scales
+const (
+	RatingScaleFive = 5  // half stars from 0.5 to 5
+	RatingScaleTen  = 10 // whole points from 1 to 10
+)
+
+// PersonalRating is the user's own rating, independent of the provider rating
+type PersonalRating struct {
+	Value   float64   `json:"value"`
+	Scale   int       `json:"scale"`
+	RatedAt time.Time `json:"rated_at"`
+}
+
+// OutOfTen returns the rating normalized to a 10 point scale, matching TMDB ratings
+func (r PersonalRating) OutOfTen() float64 {
+	if r.Scale == 0 {
+		return r.Value
+	}
+	return r.Value / float64(r.Scale) * 10
+}
+
+// Review is a free-text review with its edit history
+type Review struct {
+	Text      string           `json:"text"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	History   []ReviewRevision `json:"history,omitempty"`
+}
+
+// ReviewRevision is a previous version of a review
+type ReviewRevision struct {
+	Text     string    `json:"text"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// PersonalDataUpdate describes a change to an item's personal data.
+// Nil fields are left untouched; a rating of 0 clears the rating.
+type PersonalDataUpdate struct {
+	Rating *float64 `json:"rating,omitempty"`
+	Scale  int      `json:"scale,omitempty"`
+	Review *string  `json:"review,omitempty"`
+	Notes  *string  `json:"notes,omitempty"`
+}
+
+// RatingDisagreement compares a personal rating with the provider rating
+type RatingDisagreement struct {
+	ItemID         string  `json:"item_id"`
+	Title          string  `json:"title"`
+	PersonalRating float64 `json:"personal_rating"`
+	ProviderRating float64 `json:"provider_rating"`
+	Difference     float64 `json:"difference"`
+}
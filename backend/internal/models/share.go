@@ -0,0 +1,182 @@
+package models
+
+import "time"
+
+// Share modes
+const (
+	ShareModeSnapshot = "snapshot"
+	ShareModeLive     = "live"
+)
+
+// Share statuses, as seen by the share's creator
+const (
+	ShareActive  = "active"
+	ShareExpired = "expired"
+	ShareRevoked = "revoked"
+)
+
+// ShareRequest is the body of a request to share a list
+type ShareRequest struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	IsPublic    bool       `json:"is_public"`
+	Mode        string     `json:"mode,omitempty"`
+	ListID      string     `json:"list_id,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+
+	// DisplayName is shown to viewers in place of the user id
+	DisplayName string          `json:"display_name,omitempty"`
+	Visibility  ShareVisibility `json:"visibility"`
+	// Password, when set, must be given to view the share
+	Password string `json:"password,omitempty"`
+}
+
+// ShareUpdate changes a share; nil fields are left as they are. Switching a
+// share to snapshot mode copies the list's current items.
+type ShareUpdate struct {
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	IsPublic    *bool      `json:"is_public,omitempty"`
+	Mode        *string    `json:"mode,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	// ClearExpiry removes the expiry, so the share lasts until revoked
+	ClearExpiry bool `json:"clear_expiry,omitempty"`
+
+	DisplayName *string          `json:"display_name,omitempty"`
+	Visibility  *ShareVisibility `json:"visibility,omitempty"`
+	// Password sets a new password; an empty password removes protection
+	Password *string `json:"password,omitempty"`
+}
+
+// ShareVisibility chooses which personal fields viewers of a share can see
+type ShareVisibility struct {
+	// HideNotes hides notes and reviews
+	HideNotes bool `json:"hide_notes"`
+	// HideWatched hides watched state, watch counts and personal ratings
+	HideWatched bool `json:"hide_watched"`
+	// HideDates hides when items were added, watched and rated
+	HideDates bool `json:"hide_dates"`
+}
+
+// SharedWatchlistView is a share as its viewers see it. The creator appears
+// only by display name, and items carry only the fields the share shows.
+type SharedWatchlistView struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	DisplayName string       `json:"display_name,omitempty"`
+	Mode        string       `json:"mode"`
+	Items       []SharedItem `json:"items"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
+}
+
+// SharedItem is a watchlist item as shown to viewers of a share. Personal
+// fields are left empty when the share's visibility hides them.
+type SharedItem struct {
+	MediaType        string   `json:"media_type"`
+	MovieID          int      `json:"movie_id"`
+	Title            string   `json:"title"`
+	PosterPath       string   `json:"poster_path"`
+	ReleaseDate      string   `json:"release_date"`
+	Genre            string   `json:"genre"`
+	Rating           float64  `json:"rating"`
+	Overview         string   `json:"overview"`
+	Runtime          int      `json:"runtime,omitempty"`
+	NumberOfSeasons  int      `json:"number_of_seasons,omitempty"`
+	NumberOfEpisodes int      `json:"number_of_episodes,omitempty"`
+	IMDbID           string   `json:"imdb_id,omitempty"`
+	IMDbRating       float64  `json:"imdb_rating,omitempty"`
+	Priority         string   `json:"priority,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+
+	IsWatched  *bool      `json:"is_watched,omitempty"`
+	WatchCount int        `json:"watch_count,omitempty"`
+	WatchedAt  *time.Time `json:"watched_at,omitempty"`
+	AddedAt    *time.Time `json:"added_at,omitempty"`
+	// PersonalRating is the creator's rating out of 10
+	PersonalRating float64 `json:"personal_rating,omitempty"`
+	UserNotes      string  `json:"user_notes,omitempty"`
+	Review         string  `json:"review,omitempty"`
+}
+
+// ShareViewer describes who is asking to view a share
+type ShareViewer struct {
+	// UserID is the viewer, if known. It is not authenticated, so it only
+	// unlocks private shares for their creator and never skips a password.
+	UserID    string
+	Password  string
+	IP        string
+	UserAgent string
+}
+
+// Results of an attempt to view a share
+const (
+	ShareAccessViewed        = "viewed"
+	ShareAccessCloned        = "cloned"
+	ShareAccessWrongPassword = "wrong_password"
+)
+
+// ShareAccess is one attempt to view a share
+type ShareAccess struct {
+	At        time.Time `json:"at"`
+	Result    string    `json:"result"`
+	ViewerID  string    `json:"viewer_id,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+// ShareAccessLog counts the views and clones of a share and keeps its most
+// recent access attempts, newest first
+type ShareAccessLog struct {
+	ShareID      string        `json:"share_id"`
+	ViewCount    int           `json:"view_count"`
+	CloneCount   int           `json:"clone_count"`
+	LastViewedAt *time.Time    `json:"last_viewed_at,omitempty"`
+	Entries      []ShareAccess `json:"entries"`
+}
+
+// Strategies for titles of a cloned share that are already in the target list
+const (
+	CloneSkip      = "skip"
+	CloneOverwrite = "overwrite"
+	CloneKeepBoth  = "keep_both"
+)
+
+// CloneRequest is the body of a request to copy a share into one's own lists.
+// Items go into ListID, or the default list, unless NewList is set, in which
+// case a new list named ListName (or the share's title) is created for them.
+type CloneRequest struct {
+	// Strategy is CloneSkip (the default), CloneOverwrite to refresh the
+	// metadata of existing items, or CloneKeepBoth to keep the existing items
+	// and put the shared copies of those titles in a new list of their own,
+	// since a list holds each title only once
+	Strategy string `json:"strategy,omitempty"`
+	ListID   string `json:"list_id,omitempty"`
+	NewList  bool   `json:"new_list,omitempty"`
+	ListName string `json:"list_name,omitempty"`
+}
+
+// CloneResult reports what a clone did with each item of the share
+type CloneResult struct {
+	ListID string `json:"list_id"`
+	// CopiesListID is the list created for CloneKeepBoth copies, if any
+	CopiesListID string `json:"copies_list_id,omitempty"`
+	// CopiesError says why the CloneKeepBoth copies could not be made. The
+	// rest of the clone is still saved, and the titles that were not copied
+	// are reported as skipped.
+	CopiesError string       `json:"copies_error,omitempty"`
+	Added       []CloneEntry `json:"added"`
+	Updated     []CloneEntry `json:"updated"`
+	Skipped     []CloneEntry `json:"skipped"`
+}
+
+// CloneEntry is one title of a cloned share; ItemID is the item in ListID
+// that was added, updated or left alone
+type CloneEntry struct {
+	ListID    string `json:"list_id"`
+	ItemID    string `json:"item_id"`
+	MediaType string `json:"media_type"`
+	MovieID   int    `json:"movie_id"`
+	Title     string `json:"title"`
+}
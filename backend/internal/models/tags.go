@@ -0,0 +1,20 @@
+package models
+
+// TagCount is a tag and the number of items carrying it across a user's lists
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagUpdate adds or removes tags on several items at once
+type TagUpdate struct {
+	ItemIDs []string `json:"item_ids"`
+	Tags    []string `json:"tags"`
+}
+
+// TagRename renames a tag on every item. If an item already carries the new
+// name the two tags are merged.
+type TagRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// TrashEntry is a deleted item waiting to be restored or purged
+type TrashEntry struct {
+	Item      WatchlistItem `json:"item"`
+	ListID    string        `json:"list_id"`
+	ListName  string        `json:"list_name"`
+	DeletedAt time.Time     `json:"deleted_at"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+// Trash holds a user's deleted items across all their lists
+type Trash struct {
+	UserID    string       `json:"user_id"`
+	Entries   []TrashEntry `json:"entries"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// WatchlistVersion is a snapshot of a list taken when it was saved.
+// Watchlist is left out when versions are listed.
+type WatchlistVersion struct {
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	ItemCount int        `json:"item_count"`
+	Watchlist *Watchlist `json:"watchlist,omitempty"`
+}
+
+// WatchlistDiff describes how a list changed between two versions
+type WatchlistDiff struct {
+	From    int             `json:"from"`
+	To      int             `json:"to"`
+	Added   []WatchlistItem `json:"added"`
+	Removed []WatchlistItem `json:"removed"`
+	Changed []ItemChange    `json:"changed"`
+}
+
+// ItemChange lists the fields of an item that differ between two versions
+type ItemChange struct {
+	ItemID string        `json:"item_id"`
+	Title  string        `json:"title"`
+	Fields []FieldChange `json:"fields"`
+}
+
+// FieldChange is one changed field with its old and new value
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
@@ -4,9 +4,30 @@ import (
 	"time"
 )
 
-// WatchlistItem represents a single item in a user's watchlist
+// Media types supported in a watchlist
+const (
+	MediaTypeMovie = "movie"
+	MediaTypeTV    = "tv"
+)
+
+// Item priorities
+const (
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+// DefaultListID is the id of the list served by the /api/watchlist/{userID} routes
+const DefaultListID = "default"
+
+// CurrentWatchlistSchemaVersion is the schema version written by this build
+const CurrentWatchlistSchemaVersion = 4
+
+// WatchlistItem represents a single item in a user's watchlist.
+// MovieID holds the TMDB id, which is only unique together with MediaType.
 type WatchlistItem struct {
 	ID          string    `json:"id"`
+	MediaType   string    `json:"media_type"`
 	MovieID     int       `json:"movie_id"`
 	Title       string    `json:"title"`
 	PosterPath  string    `json:"poster_path"`
@@ -18,11 +39,105 @@ type WatchlistItem struct {
 	AddedAt     time.Time `json:"added_at"`
 	WatchedAt   *time.Time `json:"watched_at,omitempty"`
 	UserNotes   string    `json:"user_notes"`
+
+	// Rank orders items manually; ranks compare as plain strings so moving
+	// an item only changes its own rank
+	Rank     string `json:"rank"`
+	Priority string `json:"priority,omitempty"`
+
+	// Tags are free-form personal labels such as "with kids" or "rewatch"
+	Tags []string `json:"tags,omitempty"`
+
+	// AddedBy is the member who added the item to a collaborative list
+	AddedBy string `json:"added_by,omitempty"`
+
+	// WatchCount is derived from the user's watch history, like IsWatched and WatchedAt
+	WatchCount int `json:"watch_count"`
+
+	// Personal data, kept separate from the provider rating
+	PersonalRating *PersonalRating `json:"personal_rating,omitempty"`
+	Review         *Review         `json:"review,omitempty"`
+
+	// Provider metadata filled server-side from TMDB and OMDB
+	IMDbID            string     `json:"imdb_id,omitempty"`
+	IMDbRating        float64    `json:"imdb_rating,omitempty"`
+	Runtime           int        `json:"runtime,omitempty"`
+	MetadataUpdatedAt *time.Time `json:"metadata_updated_at,omitempty"`
+
+	// Directors and top-billed cast from the credits, and the creators of a TV show
+	Directors []string `json:"directors,omitempty"`
+	Cast      []string `json:"cast,omitempty"`
+	Creators  []string `json:"creators,omitempty"`
+
+	// TV-only fields, filled from TMDB TV details
+	NumberOfSeasons  int `json:"number_of_seasons,omitempty"`
+	NumberOfEpisodes int `json:"number_of_episodes,omitempty"`
+
+	// Progress holds per-episode watched state for TV shows
+	Progress *TVProgress `json:"progress,omitempty"`
+
+	// Releases are dated releases from TMDB: theatrical and digital release
+	// dates for movies, the next episode to air for TV shows
+	Releases []ReleaseDate `json:"releases,omitempty"`
+}
+
+// IsTV reports whether the item is a TV show
+func (i WatchlistItem) IsTV() bool {
+	return i.MediaType == MediaTypeTV
 }
 
-// Watchlist represents a user's complete watchlist
+// TVProgress tracks which episodes of a TV show have been watched
+type TVProgress struct {
+	Seasons   []SeasonProgress `json:"seasons"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// SeasonProgress tracks the episodes of a single season
+type SeasonProgress struct {
+	SeasonNumber int               `json:"season_number"`
+	Name         string            `json:"name"`
+	Episodes     []EpisodeProgress `json:"episodes"`
+}
+
+// EpisodeProgress represents the watched state of a single episode
+type EpisodeProgress struct {
+	EpisodeNumber int        `json:"episode_number"`
+	Name          string     `json:"name"`
+	AirDate       string     `json:"air_date"`
+	Watched       bool       `json:"watched"`
+	WatchedAt     *time.Time `json:"watched_at,omitempty"`
+}
+
+// EpisodeRef identifies an episode by season and episode number
+type EpisodeRef struct {
+	Season  int    `json:"season"`
+	Episode int    `json:"episode"`
+	Name    string `json:"name,omitempty"`
+	AirDate string `json:"air_date,omitempty"`
+}
+
+// TVProgressSummary is the computed progress of a TV show
+type TVProgressSummary struct {
+	ItemID          string      `json:"item_id"`
+	WatchedEpisodes int         `json:"watched_episodes"`
+	TotalEpisodes   int         `json:"total_episodes"`
+	ProgressPercent float64     `json:"progress_percent"`
+	NextEpisode     *EpisodeRef `json:"next_episode,omitempty"`
+	IsCompleted     bool        `json:"is_completed"`
+	Progress        *TVProgress `json:"progress"`
+}
+
+// Watchlist represents one of a user's lists. Every user has a default list;
+// additional named lists carry their own id, name and display settings.
 type Watchlist struct {
+	SchemaVersion int             `json:"schema_version"`
+	ID          string          `json:"id"`
 	UserID    string          `json:"user_id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Icon        string          `json:"icon,omitempty"`
+	Position    int             `json:"position"`
+	IsDefault   bool            `json:"is_default"`
 	Items     []WatchlistItem `json:"items"`
 	CreatedAt time.Time       `json:"created_at"`
 	UpdatedAt time.Time       `json:"updated_at"`
@@ -35,6 +150,23 @@ type WatchlistStats struct {
 	UnwatchedItems int    `json:"unwatched_items"`
 	AverageRating float64 `json:"average_rating"`
 	TopGenres     []GenreCount `json:"top_genres"`
+	Movies        MediaTypeStats `json:"movies"`
+	TVShows       MediaTypeStats `json:"tv_shows"`
+
+	// Personal vs. provider ratings, both out of 10, over items the user rated
+	RatedItems            int                  `json:"rated_items"`
+	PersonalAverageRating float64              `json:"personal_average_rating"`
+	ProviderAverageRating float64              `json:"provider_average_rating"`
+	RatingDisagreements   []RatingDisagreement `json:"rating_disagreements,omitempty"`
+}
+
+// MediaTypeStats represents statistics for a single media type
+type MediaTypeStats struct {
+	TotalItems     int     `json:"total_items"`
+	WatchedItems   int     `json:"watched_items"`
+	UnwatchedItems int     `json:"unwatched_items"`
+	AverageRating  float64 `json:"average_rating"`
+	TotalEpisodes  int     `json:"total_episodes,omitempty"`
 }
 
 // GenreCount represents genre statistics
@@ -43,7 +175,8 @@ type GenreCount struct {
 	Count int    `json:"count"`
 }
 
-// ShareableWatchlist represents a watchlist that can be shared
+// ShareableWatchlist represents a watchlist that can be shared. Private
+// shares are only shown to their creator.
 type ShareableWatchlist struct {
 	ID          string          `json:"id"`
 	Title       string          `json:"title"`
@@ -53,4 +186,31 @@ type ShareableWatchlist struct {
 	CreatedAt   time.Time       `json:"created_at"`
 	IsPublic    bool            `json:"is_public"`
 	ShareToken  string          `json:"share_token"`
+
+	// Mode is ShareModeSnapshot, where Items were copied when the share was
+	// made, or ShareModeLive, where they are read from the list on every view
+	Mode      string     `json:"mode"`
+	ListID    string     `json:"list_id"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	// DisplayName and Visibility control what viewers learn about the
+	// creator and the items; see SharedWatchlistView
+	DisplayName string          `json:"display_name,omitempty"`
+	Visibility  ShareVisibility `json:"visibility"`
+	// PasswordHash is stored for password protected shares, never returned
+	PasswordHash string `json:"password_hash,omitempty"`
+
+	// Featured and unlisted shares are chosen by moderators; unlisted shares
+	// leave the discovery feed and can only be opened by their creator
+	Featured     bool       `json:"featured,omitempty"`
+	UnlistedAt   *time.Time `json:"unlisted_at,omitempty"`
+	UnlistReason string     `json:"unlist_reason,omitempty"`
+
+	// Status, HasPassword and the counts are filled in for the creator, not stored
+	Status      string `json:"status,omitempty"`
+	HasPassword bool   `json:"has_password,omitempty"`
+	ViewCount   int    `json:"view_count,omitempty"`
+	CloneCount  int    `json:"clone_count,omitempty"`
 }
\ No newline at end of file
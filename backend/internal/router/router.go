@@ -64,15 +64,100 @@ func SetupRoutes(movieHandler *handlers.MovieHandler, watchlistHandler *handlers
 	api.HandleFunc("/discover", movieHandler.DiscoverMovies).Methods("GET")
 	
 	// Watchlist routes
+	// Fixed-segment routes are registered before the {itemID} routes they overlap with
+	api.HandleFunc("/watchlist/{userID}/history", watchlistHandler.GetWatchHistory).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/history/{eventID}", watchlistHandler.UpdateWatchEvent).Methods("PUT")
+	api.HandleFunc("/watchlist/{userID}/history/{eventID}", watchlistHandler.DeleteWatchEvent).Methods("DELETE")
+	api.HandleFunc("/watchlist/{userID}/tags", watchlistHandler.GetTags).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/tags", watchlistHandler.UpdateTags(true)).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/tags/remove", watchlistHandler.UpdateTags(false)).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/tags/rename", watchlistHandler.RenameTag).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/batch", watchlistHandler.ApplyBatch).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/trash", watchlistHandler.GetTrash).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/trash", watchlistHandler.EmptyTrash).Methods("DELETE")
+	api.HandleFunc("/watchlist/{userID}/trash/{itemID}", watchlistHandler.PurgeItem).Methods("DELETE")
+	api.HandleFunc("/watchlist/{userID}/trash/{itemID}/restore", watchlistHandler.RestoreItem).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/versions", watchlistHandler.GetVersions).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/versions/diff", watchlistHandler.DiffVersions).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/versions/{version}", watchlistHandler.GetVersion).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/versions/{version}/restore", watchlistHandler.RestoreVersion).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/calendar", watchlistHandler.GetCalendarFeed).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/calendar", watchlistHandler.CreateCalendarFeed).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/calendar", watchlistHandler.DeleteCalendarFeed).Methods("DELETE")
+	api.HandleFunc("/watchlist/{userID}/calendar.ics", watchlistHandler.GetCalendar).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/exports", watchlistHandler.GetExportJobs).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/exports", watchlistHandler.CreateExportJob).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/exports/{jobID}", watchlistHandler.GetExportJob).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/exports/{jobID}/download", watchlistHandler.DownloadExport).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/shares", watchlistHandler.GetShares).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/shares/{shareID}", watchlistHandler.UpdateShare).Methods("PUT")
+	api.HandleFunc("/watchlist/{userID}/shares/{shareID}", watchlistHandler.DeleteShare).Methods("DELETE")
+	api.HandleFunc("/watchlist/{userID}/shares/{shareID}/revoke", watchlistHandler.RevokeShare).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/shares/{shareID}/token", watchlistHandler.RegenerateShareToken).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/shares/{shareID}/access", watchlistHandler.GetShareAccessLog).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/import", watchlistHandler.ImportWatchlist).Methods("POST")
+	api.HandleFunc("/watchlist/{userID}/import/{importID}", watchlistHandler.GetImport).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/import/{importID}/confirm", watchlistHandler.ConfirmImport).Methods("POST")
 	api.HandleFunc("/watchlist/{userID}", watchlistHandler.GetWatchlist).Methods("GET")
 	api.HandleFunc("/watchlist/{userID}", watchlistHandler.AddToWatchlist).Methods("POST")
 	api.HandleFunc("/watchlist/{userID}/{itemID}", watchlistHandler.RemoveFromWatchlist).Methods("DELETE")
 	api.HandleFunc("/watchlist/{userID}/{itemID}/watched", watchlistHandler.MarkAsWatched).Methods("PUT")
 	api.HandleFunc("/watchlist/{userID}/{itemID}/unwatched", watchlistHandler.MarkAsUnwatched).Methods("PUT")
+	api.HandleFunc("/watchlist/{userID}/{itemID}/position", watchlistHandler.ReorderItem).Methods("PUT")
+	api.HandleFunc("/watchlist/{userID}/{itemID}/priority", watchlistHandler.SetPriority).Methods("PUT")
+	api.HandleFunc("/watchlist/{userID}/{itemID}/review", watchlistHandler.UpdatePersonalData).Methods("PUT")
+	api.HandleFunc("/watchlist/{userID}/{itemID}/progress", watchlistHandler.GetTVProgress).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/{itemID}/progress", watchlistHandler.MarkWatchedUpTo).Methods("PUT")
+	api.HandleFunc("/watchlist/{userID}/{itemID}/seasons/{season}/watched", watchlistHandler.MarkSeasonWatched(true)).Methods("PUT")
+	api.HandleFunc("/watchlist/{userID}/{itemID}/seasons/{season}/unwatched", watchlistHandler.MarkSeasonWatched(false)).Methods("PUT")
+	api.HandleFunc("/watchlist/{userID}/{itemID}/seasons/{season}/episodes/{episode}/watched", watchlistHandler.MarkEpisodeWatched(true)).Methods("PUT")
+	api.HandleFunc("/watchlist/{userID}/{itemID}/seasons/{season}/episodes/{episode}/unwatched", watchlistHandler.MarkEpisodeWatched(false)).Methods("PUT")
 	api.HandleFunc("/watchlist/{userID}/stats", watchlistHandler.GetWatchlistStats).Methods("GET")
+	api.HandleFunc("/watchlist/{userID}/analytics", watchlistHandler.GetAnalytics).Methods("GET")
 	api.HandleFunc("/watchlist/{userID}/export", watchlistHandler.ExportWatchlist).Methods("GET")
 	api.HandleFunc("/watchlist/{userID}/share", watchlistHandler.CreateShareableWatchlist).Methods("POST")
+	
+	// Named list routes; the "default" list id maps to the watchlist above
+	api.HandleFunc("/users/{userID}/lists", watchlistHandler.GetLists).Methods("GET")
+	api.HandleFunc("/users/{userID}/lists", watchlistHandler.CreateList).Methods("POST")
+	api.HandleFunc("/users/{userID}/lists/{listID}", watchlistHandler.GetList).Methods("GET")
+	api.HandleFunc("/users/{userID}/lists/{listID}", watchlistHandler.UpdateList).Methods("PUT")
+	api.HandleFunc("/users/{userID}/lists/{listID}", watchlistHandler.DeleteList).Methods("DELETE")
+	api.HandleFunc("/users/{userID}/lists/{listID}/items", watchlistHandler.AddToList).Methods("POST")
+	api.HandleFunc("/users/{userID}/lists/{listID}/versions", watchlistHandler.GetVersions).Methods("GET")
+	api.HandleFunc("/users/{userID}/lists/{listID}/versions/diff", watchlistHandler.DiffVersions).Methods("GET")
+	api.HandleFunc("/users/{userID}/lists/{listID}/versions/{version}", watchlistHandler.GetVersion).Methods("GET")
+	api.HandleFunc("/users/{userID}/lists/{listID}/versions/{version}/restore", watchlistHandler.RestoreVersion).Methods("POST")
+	api.HandleFunc("/users/{userID}/lists/{listID}/batch", watchlistHandler.ApplyBatch).Methods("POST")
+	api.HandleFunc("/users/{userID}/lists/{listID}/tags", watchlistHandler.UpdateTags(true)).Methods("POST")
+	api.HandleFunc("/users/{userID}/lists/{listID}/tags/remove", watchlistHandler.UpdateTags(false)).Methods("POST")
+	api.HandleFunc("/users/{userID}/lists/{listID}/items/{itemID}", watchlistHandler.RemoveFromList).Methods("DELETE")
+	api.HandleFunc("/users/{userID}/lists/{listID}/items/{itemID}/position", watchlistHandler.ReorderItem).Methods("PUT")
+	api.HandleFunc("/users/{userID}/lists/{listID}/items/{itemID}/priority", watchlistHandler.SetPriority).Methods("PUT")
+	api.HandleFunc("/users/{userID}/lists/{listID}/items/{itemID}/move", watchlistHandler.TransferItem(true)).Methods("POST")
+	api.HandleFunc("/users/{userID}/lists/{listID}/items/{itemID}/copy", watchlistHandler.TransferItem(false)).Methods("POST")
+	
+	// Collaborative lists: the owner manages invites and members of a list,
+	// members reach it through its collaboration id
+	api.HandleFunc("/users/{userID}/lists/{listID}/invites", watchlistHandler.CreateInvite).Methods("POST")
+	api.HandleFunc("/users/{userID}/lists/{listID}/invites/{inviteID}", watchlistHandler.RevokeInvite).Methods("DELETE")
+	api.HandleFunc("/users/{userID}/lists/{listID}/members", watchlistHandler.GetMembers).Methods("GET")
+	api.HandleFunc("/users/{userID}/lists/{listID}/members/{memberID}", watchlistHandler.UpdateMember).Methods("PUT")
+	api.HandleFunc("/users/{userID}/lists/{listID}/members/{memberID}", watchlistHandler.RemoveMember).Methods("DELETE")
+	api.HandleFunc("/users/{userID}/invites/{token}", watchlistHandler.AcceptInvite).Methods("POST")
+	api.HandleFunc("/users/{userID}/collabs", watchlistHandler.GetCollaborations).Methods("GET")
+	api.HandleFunc("/users/{userID}/collabs/{collabID}", watchlistHandler.GetCollaborativeList).Methods("GET")
+	api.HandleFunc("/users/{userID}/collabs/{collabID}", watchlistHandler.LeaveCollaboration).Methods("DELETE")
+	api.HandleFunc("/users/{userID}/collabs/{collabID}/activity", watchlistHandler.GetCollaborationActivity).Methods("GET")
+	api.HandleFunc("/users/{userID}/collabs/{collabID}/items", watchlistHandler.AddCollaborativeItem).Methods("POST")
+	api.HandleFunc("/users/{userID}/collabs/{collabID}/items/{itemID}", watchlistHandler.RemoveCollaborativeItem).Methods("DELETE")
+	api.HandleFunc("/users/{userID}/collabs/{collabID}/items/{itemID}/watched", watchlistHandler.MarkCollaborativeWatched).Methods("PUT")
+	api.HandleFunc("/users/{userID}/collabs/{collabID}/items/{itemID}/unwatched", watchlistHandler.MarkCollaborativeUnwatched).Methods("PUT")
+	
+	api.HandleFunc("/shared", watchlistHandler.DiscoverShares).Methods("GET")
 	api.HandleFunc("/shared/{shareToken}", watchlistHandler.GetSharedWatchlist).Methods("GET")
+	api.HandleFunc("/shared/{shareToken}/clone", watchlistHandler.CloneSharedWatchlist).Methods("POST")
+	api.HandleFunc("/moderation/shared/{shareToken}", watchlistHandler.ModerateShare).Methods("PUT")
 
 	return r
 }
\ No newline at end of file
@@ -0,0 +1,586 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+const (
+	// topPeople is how many directors and actors analytics ranks
+	topPeople = 10
+	// yearTopGenres is how many genres a year in review lists
+	yearTopGenres = 3
+	// maxAnalyticsBuckets caps the time series, about a century of weeks
+	maxAnalyticsBuckets = 5200
+)
+
+// GetAnalytics describes a user's viewing habits from their watch history.
+// Titles are described by their entries on any of the user's lists; watches
+// of titles that are no longer on a list still count, without metadata.
+func (s *WatchlistService) GetAnalytics(userID string, query models.AnalyticsQuery) (*models.WatchlistAnalytics, error) {
+	lists, err := s.userLists(userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.loadWatchHistory(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.WatchlistItem
+	for _, list := range lists {
+		items = append(items, list.Items...)
+	}
+	return computeAnalytics(items, history.Events, query, time.Now())
+}
+
+// yearTally accumulates the watches of one year for its review
+type yearTally struct {
+	review     models.YearInReview
+	titles     map[titleKey]bool
+	genres     map[string]int
+	directors  map[string]int
+	actors     map[string]int
+	months     [12]int
+	bestRating float64
+}
+
+// computeAnalytics summarizes the watch events that match a query
+func computeAnalytics(items []models.WatchlistItem, events []models.WatchEvent, query models.AnalyticsQuery, now time.Time) (*models.WatchlistAnalytics, error) {
+	if query.Interval == "" {
+		query.Interval = models.IntervalMonth
+	}
+	if query.Interval != models.IntervalMonth && query.Interval != models.IntervalWeek {
+		return nil, invalidf("invalid interval %q, use %s or %s", query.Interval, models.IntervalMonth, models.IntervalWeek)
+	}
+	if query.MediaType != "" && query.MediaType != models.MediaTypeMovie && query.MediaType != models.MediaTypeTV {
+		return nil, invalidf("invalid media type %q, use %s or %s", query.MediaType, models.MediaTypeMovie, models.MediaTypeTV)
+	}
+	if query.From != nil && query.To != nil && !query.To.After(*query.From) {
+		return nil, invalidf("to must be after from")
+	}
+
+	// A title is described by its first list entry, and was added when it
+	// was first put on any list
+	titles := make(map[titleKey]*models.WatchlistItem)
+	addedAt := make(map[titleKey]time.Time)
+	for i := range items {
+		key := titleKey{items[i].MediaType, items[i].MovieID}
+		if _, ok := titles[key]; !ok {
+			titles[key] = &items[i]
+			addedAt[key] = items[i].AddedAt
+		} else if items[i].AddedAt.Before(addedAt[key]) {
+			addedAt[key] = items[i].AddedAt
+		}
+	}
+
+	// First watches are taken from the whole history, so a rewatch in range
+	// does not count as the time it took to get to a title
+	firstWatch := make(map[titleKey]time.Time)
+	var watches []models.WatchEvent
+	for _, event := range events {
+		key := titleKey{event.MediaType, event.MovieID}
+		if first, ok := firstWatch[key]; !ok || event.WatchedAt.Before(first) {
+			firstWatch[key] = event.WatchedAt
+		}
+		if query.MediaType != "" && event.MediaType != query.MediaType {
+			continue
+		}
+		if inAnalyticsRange(event.WatchedAt, query) {
+			watches = append(watches, event)
+		}
+	}
+	sort.SliceStable(watches, func(i, j int) bool {
+		return watches[i].WatchedAt.Before(watches[j].WatchedAt)
+	})
+
+	analytics := &models.WatchlistAnalytics{
+		Query:        query,
+		TotalWatches: len(watches),
+		Decades:      []models.DecadeCount{},
+	}
+
+	spent, unknown := watchedTime(watches, titles, firstWatch, query)
+	var err error
+	analytics.WatchesOverTime, err = watchBuckets(watches, spent, query, now)
+	if err != nil {
+		return nil, err
+	}
+
+	years := make(map[int]*yearTally)
+	tally := func(at time.Time) *yearTally {
+		year := years[at.Year()]
+		if year == nil {
+			year = &yearTally{
+				review:    models.YearInReview{Year: at.Year()},
+				titles:    make(map[titleKey]bool),
+				genres:    make(map[string]int),
+				directors: make(map[string]int),
+				actors:    make(map[string]int),
+			}
+			years[at.Year()] = year
+		}
+		return year
+	}
+
+	var counted int
+	for _, entry := range spent {
+		analytics.Runtime.TotalMinutes += entry.minutes
+		counted += entry.count
+		tally(entry.at.UTC()).review.RuntimeMinutes += entry.minutes
+	}
+	analytics.Runtime.UnknownWatches = unknown
+	if counted > 0 {
+		analytics.Runtime.AverageMinutes = float64(analytics.Runtime.TotalMinutes) / float64(counted)
+	}
+
+	watched := make(map[titleKey]bool)
+	decades := make(map[int]int)
+	directors := make(map[string]int)
+	actors := make(map[string]int)
+	var addToWatch []float64
+	for _, event := range watches {
+		key := titleKey{event.MediaType, event.MovieID}
+		item := titles[key]
+
+		at := event.WatchedAt.UTC()
+		year := tally(at)
+		year.review.Watches++
+		year.months[at.Month()-1]++
+		if rating := watchRating(event, item); rating > year.bestRating {
+			year.bestRating = rating
+			year.review.HighestRated = event.Title
+		}
+		if !year.titles[key] {
+			year.titles[key] = true
+			if item != nil {
+				for _, genre := range strings.Split(item.Genre, ",") {
+					if genre = strings.TrimSpace(genre); genre != "" {
+						year.genres[genre]++
+					}
+				}
+				countNames(year.directors, item.Directors)
+				countNames(year.actors, item.Cast)
+			}
+		}
+
+		if watched[key] {
+			continue
+		}
+		watched[key] = true
+		if item == nil {
+			continue
+		}
+		if decade, ok := releaseDecade(item.ReleaseDate); ok {
+			decades[decade]++
+		}
+		countNames(directors, item.Directors)
+		countNames(actors, item.Cast)
+		if first := firstWatch[key]; inAnalyticsRange(first, query) && !addedAt[key].IsZero() {
+			if days := first.Sub(addedAt[key]).Hours() / 24; days >= 0 {
+				addToWatch = append(addToWatch, days)
+			}
+		}
+	}
+
+	analytics.TitlesWatched = len(watched)
+	for decade, count := range decades {
+		analytics.Decades = append(analytics.Decades, models.DecadeCount{Decade: decade, Titles: count})
+	}
+	sort.Slice(analytics.Decades, func(i, j int) bool {
+		return analytics.Decades[i].Decade < analytics.Decades[j].Decade
+	})
+	analytics.TopDirectors = topPersonCounts(directors, topPeople)
+	analytics.TopActors = topPersonCounts(actors, topPeople)
+	analytics.AddToWatch = addToWatchTime(addToWatch)
+	analytics.Streaks = watchStreaks(watchTimes(watches, titles, query), query, now)
+
+	analytics.YearsInReview = []models.YearInReview{}
+	for _, year := range years {
+		review := year.review
+		review.Titles = len(year.titles)
+		for _, genre := range rankNames(year.genres) {
+			if len(review.TopGenres) == yearTopGenres {
+				break
+			}
+			review.TopGenres = append(review.TopGenres, genre)
+		}
+		if names := rankNames(year.directors); len(names) > 0 {
+			review.TopDirector = names[0]
+		}
+		if names := rankNames(year.actors); len(names) > 0 {
+			review.TopActor = names[0]
+		}
+		busiest := 0
+		for month, count := range year.months {
+			if count > year.months[busiest] {
+				busiest = month
+			}
+		}
+		// A year can have episodes watched but no watch events
+		if year.months[busiest] > 0 {
+			review.BusiestMonth = time.Month(busiest + 1).String()
+		}
+		analytics.YearsInReview = append(analytics.YearsInReview, review)
+	}
+	sort.Slice(analytics.YearsInReview, func(i, j int) bool {
+		return analytics.YearsInReview[i].Year < analytics.YearsInReview[j].Year
+	})
+
+	return analytics, nil
+}
+
+// inAnalyticsRange reports whether t falls in the query's [From, To) range
+func inAnalyticsRange(t time.Time, query models.AnalyticsQuery) bool {
+	if query.From != nil && t.Before(*query.From) {
+		return false
+	}
+	if query.To != nil && !t.Before(*query.To) {
+		return false
+	}
+	return true
+}
+
+// timeSpent is the runtime of count movies or episodes watched at one time
+type timeSpent struct {
+	at      time.Time
+	minutes int
+	count   int
+}
+
+// watchedTime lists the time spent watching in the query's range: every
+// watch of a movie, and every episode of a show when it was watched. Watch
+// events of a show add no time of their own, except that the first watch of
+// a show without any episode marked watched counts all its episodes once.
+// It also returns how many watches and episodes have no known runtime.
+func watchedTime(watches []models.WatchEvent, titles map[titleKey]*models.WatchlistItem, firstWatch map[titleKey]time.Time, query models.AnalyticsQuery) ([]timeSpent, int) {
+	var spent []timeSpent
+	unknown := 0
+	for _, event := range watches {
+		key := titleKey{event.MediaType, event.MovieID}
+		item := titles[key]
+		count := 1
+		if event.MediaType == models.MediaTypeTV {
+			if (item != nil && watchedEpisodes(item) > 0) || !event.WatchedAt.Equal(firstWatch[key]) {
+				continue
+			}
+			if item != nil {
+				count = item.NumberOfEpisodes
+			}
+		}
+		if item == nil || item.Runtime == 0 || count == 0 {
+			unknown++
+			continue
+		}
+		spent = append(spent, timeSpent{at: event.WatchedAt, minutes: item.Runtime * count, count: count})
+	}
+
+	if query.MediaType == models.MediaTypeMovie {
+		return spent, unknown
+	}
+	for _, item := range titles {
+		if !item.IsTV() || item.Progress == nil {
+			continue
+		}
+		for _, season := range item.Progress.Seasons {
+			for _, episode := range season.Episodes {
+				if !episode.Watched || episode.WatchedAt == nil || !inAnalyticsRange(*episode.WatchedAt, query) {
+					continue
+				}
+				if item.Runtime == 0 {
+					unknown++
+					continue
+				}
+				spent = append(spent, timeSpent{at: *episode.WatchedAt, minutes: item.Runtime, count: 1})
+			}
+		}
+	}
+	return spent, unknown
+}
+
+// watchedEpisodes counts the episodes of a show marked watched
+func watchedEpisodes(item *models.WatchlistItem) int {
+	if item.Progress == nil {
+		return 0
+	}
+	watched := 0
+	for _, season := range item.Progress.Seasons {
+		for _, episode := range season.Episodes {
+			if episode.Watched {
+				watched++
+			}
+		}
+	}
+	return watched
+}
+
+// watchRating returns the personal rating out of 10 given with a watch, or
+// else the rating of the title, or 0 if neither is rated
+func watchRating(event models.WatchEvent, item *models.WatchlistItem) float64 {
+	if event.Rating != nil {
+		return event.Rating.OutOfTen()
+	}
+	if item != nil && item.PersonalRating != nil {
+		return item.PersonalRating.OutOfTen()
+	}
+	return 0
+}
+
+// releaseDecade returns the decade of a YYYY-MM-DD release date
+func releaseDecade(releaseDate string) (int, bool) {
+	if len(releaseDate) < 4 {
+		return 0, false
+	}
+	var year int
+	if _, err := fmt.Sscanf(releaseDate[:4], "%d", &year); err != nil || year <= 0 {
+		return 0, false
+	}
+	return year / 10 * 10, true
+}
+
+// countNames adds one to the count of every name
+func countNames(counts map[string]int, names []string) {
+	for _, name := range names {
+		if name != "" {
+			counts[name]++
+		}
+	}
+}
+
+// rankNames orders names by count, most counted first, then by name
+func rankNames(counts map[string]int) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// topPersonCounts returns the limit most counted people
+func topPersonCounts(counts map[string]int, limit int) []models.PersonCount {
+	people := []models.PersonCount{}
+	for _, name := range rankNames(counts) {
+		if len(people) == limit {
+			break
+		}
+		people = append(people, models.PersonCount{Name: name, Titles: counts[name]})
+	}
+	return people
+}
+
+// addToWatchTime summarizes the days titles waited before their first watch
+func addToWatchTime(days []float64) models.AddToWatchTime {
+	summary := models.AddToWatchTime{Titles: len(days)}
+	if len(days) == 0 {
+		return summary
+	}
+	sort.Float64s(days)
+	var total float64
+	for _, d := range days {
+		total += d
+	}
+	summary.AverageDays = total / float64(len(days))
+	if mid := len(days) / 2; len(days)%2 == 1 {
+		summary.MedianDays = days[mid]
+	} else {
+		summary.MedianDays = (days[mid-1] + days[mid]) / 2
+	}
+	return summary
+}
+
+// watchBuckets counts watches and time spent per month or week. Buckets span
+// the query range, or the watches when it is open, and empty buckets are included.
+func watchBuckets(watches []models.WatchEvent, spent []timeSpent, query models.AnalyticsQuery, now time.Time) ([]models.TimeBucket, error) {
+	buckets := []models.TimeBucket{}
+
+	// Episodes can be watched outside the span of the watch events
+	var earliest, latest time.Time
+	extend := func(at time.Time) {
+		if earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+		if at.After(latest) {
+			latest = at
+		}
+	}
+	for _, event := range watches {
+		extend(event.WatchedAt)
+	}
+	for _, entry := range spent {
+		extend(entry.at)
+	}
+
+	var first, last time.Time
+	switch {
+	case query.From != nil:
+		first = *query.From
+	case !earliest.IsZero():
+		first = earliest
+	default:
+		return buckets, nil
+	}
+	if query.To != nil {
+		last = query.To.Add(-time.Nanosecond)
+	} else {
+		last = now
+		if latest.After(last) {
+			last = latest
+		}
+	}
+
+	index := make(map[int64]int)
+	for start := bucketStart(first, query.Interval); !start.After(last); start = nextBucket(start, query.Interval) {
+		if len(buckets) == maxAnalyticsBuckets {
+			return nil, invalidf("date range is too wide for %s buckets", query.Interval)
+		}
+		index[start.Unix()] = len(buckets)
+		buckets = append(buckets, models.TimeBucket{Start: start, Label: bucketLabel(start, query.Interval)})
+	}
+
+	for _, event := range watches {
+		if i, ok := index[bucketStart(event.WatchedAt, query.Interval).Unix()]; ok {
+			buckets[i].Watches++
+		}
+	}
+	for _, entry := range spent {
+		if i, ok := index[bucketStart(entry.at, query.Interval).Unix()]; ok {
+			buckets[i].RuntimeMinutes += entry.minutes
+		}
+	}
+	return buckets, nil
+}
+
+// bucketStart returns the start of the UTC month, or Monday-based week, of t
+func bucketStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	if interval == models.IntervalWeek {
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		sinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -sinceMonday)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// nextBucket returns the start of the bucket after the one starting at start
+func nextBucket(start time.Time, interval string) time.Time {
+	if interval == models.IntervalWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// bucketLabel names a bucket by its month, or by its ISO week
+func bucketLabel(start time.Time, interval string) string {
+	if interval == models.IntervalWeek {
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return start.Format("2006-01")
+}
+
+// watchTimes returns when anything was watched in the query's range, in
+// order: every watch event, and every episode of a show when it was watched
+func watchTimes(watches []models.WatchEvent, titles map[titleKey]*models.WatchlistItem, query models.AnalyticsQuery) []time.Time {
+	times := make([]time.Time, 0, len(watches))
+	for _, event := range watches {
+		times = append(times, event.WatchedAt)
+	}
+
+	if query.MediaType != models.MediaTypeMovie {
+		for _, item := range titles {
+			if !item.IsTV() || item.Progress == nil {
+				continue
+			}
+			for _, season := range item.Progress.Seasons {
+				for _, episode := range season.Episodes {
+					if episode.Watched && episode.WatchedAt != nil && inAnalyticsRange(*episode.WatchedAt, query) {
+						times = append(times, *episode.WatchedAt)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	return times
+}
+
+// watchStreaks finds runs of consecutive UTC days and weeks with a watch,
+// given the sorted times of the watches. Current streaks are measured up to
+// the end of the range, or now.
+func watchStreaks(times []time.Time, query models.AnalyticsQuery, now time.Time) models.WatchStreaks {
+	streaks := models.WatchStreaks{}
+	if len(times) == 0 {
+		return streaks
+	}
+	lastWatched := times[len(times)-1]
+	streaks.LastWatchedAt = &lastWatched
+
+	end := now
+	if query.To != nil && query.To.Before(now) {
+		end = query.To.Add(-time.Nanosecond)
+	}
+
+	var days, weeks []int64
+	for _, at := range times {
+		day := dayIndex(at)
+		if len(days) == 0 || days[len(days)-1] != day {
+			days = append(days, day)
+		}
+		// Day 0 was a Thursday, so weeks start on Mondays
+		if week := floorDiv(day+3, 7); len(weeks) == 0 || weeks[len(weeks)-1] != week {
+			weeks = append(weeks, week)
+		}
+	}
+	today := dayIndex(end)
+	streaks.CurrentDays, streaks.LongestDays = runs(days, today)
+	streaks.CurrentWeeks, streaks.LongestWeeks = runs(weeks, floorDiv(today+3, 7))
+	return streaks
+}
+
+// runs returns the length of the run of consecutive indexes that reaches
+// current or the index before it, and of the longest run. Indexes are
+// sorted and distinct.
+func runs(indexes []int64, current int64) (int, int) {
+	var longest, length int
+	for i, index := range indexes {
+		if i > 0 && index == indexes[i-1]+1 {
+			length++
+		} else {
+			length = 1
+		}
+		if length > longest {
+			longest = length
+		}
+	}
+	if last := indexes[len(indexes)-1]; last < current-1 {
+		return 0, longest
+	}
+	return length, longest
+}
+
+// dayIndex returns the number of UTC days from the Unix epoch to t
+func dayIndex(t time.Time) int64 {
+	return floorDiv(t.Unix(), 24*60*60)
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
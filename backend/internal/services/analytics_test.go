@@ -0,0 +1,194 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func mustParseDay(value string) time.Time {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func analyticsFixture() ([]models.WatchlistItem, []models.WatchEvent) {
+	items := []models.WatchlistItem{
+		{MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", ReleaseDate: "1995-12-15", Genre: "Crime, Drama",
+			Runtime: 170, Directors: []string{"Michael Mann"}, Cast: []string{"Al Pacino", "Robert De Niro"}, AddedAt: mustParseDay("2024-01-01")},
+		{MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Collateral", ReleaseDate: "2004-08-06", Genre: "Crime",
+			Runtime: 120, Directors: []string{"Michael Mann"}, Cast: []string{"Tom Cruise"}, AddedAt: mustParseDay("2024-01-11")},
+		{MediaType: models.MediaTypeTV, MovieID: 1, Title: "Chernobyl", ReleaseDate: "2019-05-06", Genre: "Drama",
+			Runtime: 60, NumberOfEpisodes: 5, Creators: []string{"Craig Mazin"}, AddedAt: mustParseDay("2024-02-01")},
+		// The same title on a second list was added earlier
+		{MediaType: models.MediaTypeTV, MovieID: 1, Title: "Chernobyl", AddedAt: mustParseDay("2023-12-01")},
+	}
+	rating := &models.PersonalRating{Value: 9, Scale: 10}
+	events := []models.WatchEvent{
+		{MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", WatchedAt: mustParseDay("2024-01-05")},
+		{MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Collateral", WatchedAt: mustParseDay("2024-01-21"), Rating: rating},
+		{MediaType: models.MediaTypeTV, MovieID: 1, Title: "Chernobyl", WatchedAt: mustParseDay("2024-03-10")},
+		{MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", WatchedAt: mustParseDay("2024-03-11")},
+		{MediaType: models.MediaTypeMovie, MovieID: 9, Title: "Removed", WatchedAt: mustParseDay("2024-03-12")},
+		{MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Collateral", WatchedAt: mustParseDay("2025-01-02")},
+	}
+	return items, events
+}
+
+func TestComputeAnalytics(t *testing.T) {
+	items, events := analyticsFixture()
+	to := mustParseDay("2024-04-01")
+	analytics, err := computeAnalytics(items, events, models.AnalyticsQuery{To: &to}, mustParseDay("2025-06-01"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, analytics.TotalWatches)
+	assert.Equal(t, 4, analytics.TitlesWatched)
+	assert.Equal(t, models.IntervalMonth, analytics.Query.Interval)
+
+	// Buckets run from the first watch to the end of the range
+	require.Len(t, analytics.WatchesOverTime, 3)
+	assert.Equal(t, "2024-01", analytics.WatchesOverTime[0].Label)
+	assert.Equal(t, 2, analytics.WatchesOverTime[0].Watches)
+	assert.Equal(t, 290, analytics.WatchesOverTime[0].RuntimeMinutes)
+	assert.Equal(t, 0, analytics.WatchesOverTime[1].Watches, "empty months are included")
+	assert.Equal(t, 3, analytics.WatchesOverTime[2].Watches)
+
+	assert.Equal(t, 170+120+300+170, analytics.Runtime.TotalMinutes, "a show counts all its episodes")
+	assert.Equal(t, 1, analytics.Runtime.UnknownWatches)
+	assert.InDelta(t, 95, analytics.Runtime.AverageMinutes, 0.001, "three movies and five episodes")
+
+	assert.Equal(t, []models.DecadeCount{{Decade: 1990, Titles: 1}, {Decade: 2000, Titles: 1}, {Decade: 2010, Titles: 1}}, analytics.Decades)
+	assert.Equal(t, models.PersonCount{Name: "Michael Mann", Titles: 2}, analytics.TopDirectors[0], "rewatches count once")
+	assert.Len(t, analytics.TopActors, 3)
+
+	// Heat after 4 days, Collateral after 10 and Chernobyl after 100 from
+	// its earliest list entry
+	assert.Equal(t, 3, analytics.AddToWatch.Titles)
+	assert.InDelta(t, 38, analytics.AddToWatch.AverageDays, 0.001)
+	assert.InDelta(t, 10, analytics.AddToWatch.MedianDays, 0.001)
+
+	assert.Equal(t, 0, analytics.Streaks.CurrentDays, "the range ended weeks after the last watch")
+	assert.Equal(t, 3, analytics.Streaks.LongestDays)
+	assert.Equal(t, 2, analytics.Streaks.LongestWeeks)
+	assert.Equal(t, mustParseDay("2024-03-12"), *analytics.Streaks.LastWatchedAt)
+
+	require.Len(t, analytics.YearsInReview, 1)
+	review := analytics.YearsInReview[0]
+	assert.Equal(t, 2024, review.Year)
+	assert.Equal(t, 5, review.Watches)
+	assert.Equal(t, 4, review.Titles)
+	assert.Equal(t, []string{"Crime", "Drama"}, review.TopGenres)
+	assert.Equal(t, "Michael Mann", review.TopDirector)
+	assert.Equal(t, "March", review.BusiestMonth)
+	assert.Equal(t, "Collateral", review.HighestRated)
+}
+
+func TestComputeAnalyticsFilters(t *testing.T) {
+	items, events := analyticsFixture()
+
+	from, to := mustParseDay("2024-03-01"), mustParseDay("2024-03-12")
+	analytics, err := computeAnalytics(items, events, models.AnalyticsQuery{
+		From: &from, To: &to, MediaType: models.MediaTypeMovie, Interval: models.IntervalWeek,
+	}, mustParseDay("2025-06-01"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, analytics.TotalWatches, "only movies watched in range")
+	assert.Equal(t, 0, analytics.AddToWatch.Titles, "Heat was first watched before the range")
+	assert.Equal(t, "2024-W09", analytics.WatchesOverTime[0].Label)
+	assert.Equal(t, mustParseDay("2024-02-26"), analytics.WatchesOverTime[0].Start, "weeks start on Monday")
+	assert.Len(t, analytics.WatchesOverTime, 3)
+	assert.Equal(t, 1, analytics.Streaks.CurrentDays, "a watch on the last day of the range is current")
+
+	_, err = computeAnalytics(items, events, models.AnalyticsQuery{Interval: "day"}, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = computeAnalytics(items, events, models.AnalyticsQuery{MediaType: "book"}, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = computeAnalytics(items, events, models.AnalyticsQuery{From: &to, To: &from}, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	empty, err := computeAnalytics(nil, nil, models.AnalyticsQuery{}, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, empty.WatchesOverTime)
+	assert.Nil(t, empty.Streaks.LastWatchedAt)
+}
+
+func TestComputeAnalyticsTVRuntime(t *testing.T) {
+	watchedAt := func(day string) *time.Time {
+		at := mustParseDay(day)
+		return &at
+	}
+	items := []models.WatchlistItem{
+		{MediaType: models.MediaTypeTV, MovieID: 1, Title: "Chernobyl", Runtime: 60, NumberOfEpisodes: 3,
+			Progress: &models.TVProgress{Seasons: []models.SeasonProgress{{SeasonNumber: 1, Episodes: []models.EpisodeProgress{
+				{EpisodeNumber: 1, Watched: true, WatchedAt: watchedAt("2024-01-30")},
+				{EpisodeNumber: 2, Watched: true, WatchedAt: watchedAt("2024-02-02")},
+				{EpisodeNumber: 3},
+			}}}}},
+		{MediaType: models.MediaTypeTV, MovieID: 2, Title: "The Wire", Runtime: 50, NumberOfEpisodes: 10},
+	}
+	events := []models.WatchEvent{
+		{MediaType: models.MediaTypeTV, MovieID: 1, Title: "Chernobyl", WatchedAt: mustParseDay("2024-02-03")},
+		{MediaType: models.MediaTypeTV, MovieID: 2, Title: "The Wire", WatchedAt: mustParseDay("2024-02-05")},
+		{MediaType: models.MediaTypeTV, MovieID: 2, Title: "The Wire", WatchedAt: mustParseDay("2024-02-20")},
+	}
+
+	analytics, err := computeAnalytics(items, events, models.AnalyticsQuery{}, mustParseDay("2024-03-01"))
+	require.NoError(t, err)
+	assert.Equal(t, 2*60+10*50, analytics.Runtime.TotalMinutes, "episodes count when watched, and a rewatch adds nothing")
+	require.Len(t, analytics.WatchesOverTime, 3)
+	assert.Equal(t, "2024-01", analytics.WatchesOverTime[0].Label, "buckets start at the first episode")
+	assert.Equal(t, 0, analytics.WatchesOverTime[0].Watches)
+	assert.Equal(t, 60, analytics.WatchesOverTime[0].RuntimeMinutes)
+	assert.Equal(t, 60+500, analytics.WatchesOverTime[1].RuntimeMinutes)
+	assert.Equal(t, 2, analytics.Streaks.LongestDays, "episodes watched count towards streaks")
+
+	from := mustParseDay("2024-02-01")
+	analytics, err = computeAnalytics(items, events, models.AnalyticsQuery{From: &from}, mustParseDay("2024-03-01"))
+	require.NoError(t, err)
+	assert.Equal(t, 60+500, analytics.Runtime.TotalMinutes, "only episodes watched in range")
+	assert.Equal(t, mustParseDay("2024-02-20"), *analytics.Streaks.LastWatchedAt)
+	to := mustParseDay("2024-02-03")
+	analytics, err = computeAnalytics(items, events, models.AnalyticsQuery{To: &to}, mustParseDay("2024-03-01"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, analytics.Streaks.CurrentDays, "an episode on the last day of the range is current")
+	assert.Equal(t, mustParseDay("2024-02-02"), *analytics.Streaks.LastWatchedAt)
+
+	analytics, err = computeAnalytics(items, events, models.AnalyticsQuery{MediaType: models.MediaTypeMovie}, mustParseDay("2024-03-01"))
+	require.NoError(t, err)
+	assert.Zero(t, analytics.Runtime.TotalMinutes)
+}
+
+func TestWatchStreaks(t *testing.T) {
+	var times []time.Time
+	for _, d := range []string{"2024-05-01", "2024-05-02", "2024-05-02", "2024-05-10", "2024-05-11", "2024-05-12", "2024-05-13"} {
+		times = append(times, mustParseDay(d).Add(20*time.Hour))
+	}
+
+	streaks := watchStreaks(times, models.AnalyticsQuery{}, mustParseDay("2024-05-14").Add(9*time.Hour))
+	assert.Equal(t, 4, streaks.CurrentDays, "a streak lasts until a full day is missed")
+	assert.Equal(t, 4, streaks.LongestDays)
+	assert.Equal(t, 3, streaks.CurrentWeeks)
+
+	streaks = watchStreaks(times, models.AnalyticsQuery{}, mustParseDay("2024-05-16"))
+	assert.Equal(t, 0, streaks.CurrentDays)
+	assert.Equal(t, 3, streaks.CurrentWeeks, "this week still continues the weekly streak")
+}
+
+func TestGetAnalytics(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", Runtime: 170, AddedAt: mustParseDay("2024-01-01")},
+	})
+	watchedAt := mustParseDay("2024-01-03")
+	_, err := s.MarkAsWatched("user", "movie_1", models.WatchEventInput{WatchedAt: &watchedAt})
+	require.NoError(t, err)
+
+	analytics, err := s.GetAnalytics("user", models.AnalyticsQuery{MediaType: models.MediaTypeMovie})
+	require.NoError(t, err)
+	assert.Equal(t, 1, analytics.TotalWatches)
+	assert.Equal(t, 170, analytics.Runtime.TotalMinutes)
+	assert.InDelta(t, 2, analytics.AddToWatch.AverageDays, 0.001)
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// maxBatchOperations bounds the size of a single batch request
+const maxBatchOperations = 500
+
+// ApplyBatch applies a list of operations to one of a user's lists with a
+// single load and save of each file involved. Operations run in order against
+// the in-memory state, so later ones see the effect of earlier ones. If any
+// operation fails, or the request is a dry run, nothing is saved.
+func (s *WatchlistService) ApplyBatch(userID, listID string, req models.BatchRequest) (*models.BatchResponse, error) {
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+	if len(req.Operations) == 0 {
+		return nil, invalidf("at least one operation is required")
+	}
+	if len(req.Operations) > maxBatchOperations {
+		return nil, invalidf("a batch can have at most %d operations", maxBatchOperations)
+	}
+
+	// Metadata for new items is fetched before taking the lock
+	fetched := make(map[int]*models.WatchlistItem)
+	fetchErrs := make(map[int]error)
+	for i, op := range req.Operations {
+		if op.Op != models.BatchOpAdd {
+			continue
+		}
+		item, err := s.prepareItem(op.MediaType, op.ID)
+		if err != nil {
+			fetchErrs[i] = err
+			continue
+		}
+		fetched[i] = item
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.loadWatchHistory(userID)
+	if err != nil {
+		return nil, err
+	}
+	// The owner's changes to a collaborative list are attributed to them
+	collab, err := s.findCollaboration(userID, list.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &batchState{
+		service: s,
+		userID:  userID,
+		list:    list,
+		history: history,
+		collab:  collab,
+		targets: make(map[string]*models.Watchlist),
+	}
+
+	resp := &models.BatchResponse{DryRun: req.DryRun}
+	failed := false
+	for i, op := range req.Operations {
+		result := models.BatchResult{Index: i, Op: op.Op}
+		var err error
+		if fetchErr, ok := fetchErrs[i]; ok {
+			err = fetchErr
+		} else {
+			err = batch.apply(op, fetched[i], &result)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			failed = true
+		} else {
+			result.OK = true
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	if failed || req.DryRun {
+		return resp, nil
+	}
+
+	if err := batch.save(); err != nil {
+		return nil, err
+	}
+	resp.Applied = true
+	batch.recordActivity()
+
+	s.logger.Success("Applied %d batch operation(s) to list %s for user %s", len(req.Operations), listID, userID)
+	return resp, nil
+}
+
+// batchState holds the files touched by a batch while it runs
+type batchState struct {
+	service        *WatchlistService
+	userID         string
+	list           *models.Watchlist
+	history        *models.WatchHistory
+	historyChanged bool
+	trash          *models.Trash // loaded by the first remove
+	targets        map[string]*models.Watchlist
+	collab         *models.Collaboration // nil unless the list is collaborative
+	activity       []models.ListActivity
+}
+
+// apply runs one operation against the in-memory state and fills in its result
+func (b *batchState) apply(op models.BatchOperation, fetched *models.WatchlistItem, result *models.BatchResult) error {
+	if op.Op == models.BatchOpAdd {
+		item := *fetched
+		if b.collab != nil {
+			item.AddedBy = b.userID
+		}
+		if err := b.service.insertItem(b.list, &item); err != nil {
+			return err
+		}
+		result.Item = &item
+		b.addActivity(models.ActivityAdded, &item)
+		return nil
+	}
+
+	if op.ItemID == "" {
+		return fmt.Errorf("item_id is required")
+	}
+	item := findItem(b.list, op.ItemID)
+	if item == nil {
+		return fmt.Errorf("item %s not found in watchlist", op.ItemID)
+	}
+
+	switch op.Op {
+	case models.BatchOpRemove:
+		if b.trash == nil {
+			trash, err := b.service.loadTrash(b.userID)
+			if err != nil {
+				return err
+			}
+			b.trash = trash
+		}
+		addToTrash(b.trash, b.list, []models.WatchlistItem{*item}, time.Now(), b.service.trashRetention)
+		b.addActivity(models.ActivityRemoved, item)
+		removeItem(b.list, op.ItemID)
+
+	case models.BatchOpWatched:
+		input := models.WatchEventInput{}
+		if op.Watch != nil {
+			input = *op.Watch
+		}
+		event, err := b.service.newWatchEvent(item, input)
+		if err != nil {
+			return err
+		}
+		b.history.Events = append(b.history.Events, event)
+		b.historyChanged = true
+		result.Event = &event
+		b.addActivity(models.ActivityWatched, item)
+
+	case models.BatchOpUnwatched:
+		if err := removeLatestWatch(b.history, titleKey{item.MediaType, item.MovieID}); err != nil {
+			return err
+		}
+		b.historyChanged = true
+		b.addActivity(models.ActivityUnwatched, item)
+
+	case models.BatchOpTag, models.BatchOpUntag:
+		tags, err := normalizeTags(op.Tags)
+		if err != nil {
+			return err
+		}
+		// Work on a copy so a failed operation leaves the item untouched
+		updated := *item
+		updated.Tags = append([]string(nil), item.Tags...)
+		if op.Op == models.BatchOpTag {
+			if err := addItemTags(&updated, tags); err != nil {
+				return err
+			}
+		} else {
+			removeItemTags(&updated, tags)
+		}
+		*item = updated
+		result.Item = &updated
+
+	case models.BatchOpMove:
+		target, err := b.target(op.TargetListID)
+		if err != nil {
+			return err
+		}
+		moved, err := b.service.transferBetween(b.list, target, op.ItemID, true)
+		if err != nil {
+			return err
+		}
+		result.Item = moved
+
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	return nil
+}
+
+// addActivity notes a change to a collaborative list, to be recorded once
+// the batch is saved
+func (b *batchState) addActivity(action string, item *models.WatchlistItem) {
+	if b.collab == nil {
+		return
+	}
+	b.activity = append(b.activity, models.ListActivity{Action: action, UserID: b.userID, ItemID: item.ID, Title: item.Title})
+}
+
+// recordActivity adds the changes of a saved batch to the collaborative
+// list's activity. The caller must hold the service lock. The changes are
+// saved by now, so failures are only logged.
+func (b *batchState) recordActivity() {
+	if b.collab == nil || len(b.activity) == 0 {
+		return
+	}
+	s := b.service
+	collab, err := s.loadCollaboration(b.collab.ID)
+	if err == nil {
+		now := time.Now()
+		for _, activity := range b.activity {
+			activity.At = now
+			addActivity(collab, activity)
+		}
+		collab.UpdatedAt = now
+		err = s.saveCollaboration(collab)
+	}
+	if err != nil {
+		s.logger.Warning("Failed to record activity on collaborative list %s: %v", b.collab.ID, err)
+	}
+}
+
+// target loads a move target once per batch
+func (b *batchState) target(listID string) (*models.Watchlist, error) {
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+	if listID == b.list.ID {
+		return nil, fmt.Errorf("source and target lists are the same")
+	}
+	if target, ok := b.targets[listID]; ok {
+		return target, nil
+	}
+
+	target, err := b.service.GetList(b.userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	b.targets[listID] = target
+	return target, nil
+}
+
+// save writes every file the batch changed. The files are written one after
+// another, so if one fails, those already written are put back as they were
+// on disk before the batch.
+func (b *batchState) save() error {
+	s := b.service
+	var undo []func() error
+	rollback := func(err error) error {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if restoreErr := undo[i](); restoreErr != nil {
+				s.logger.Error("Failed to roll back batch for user %s: %v", b.userID, restoreErr)
+			}
+		}
+		return err
+	}
+
+	lists := make([]*models.Watchlist, 0, len(b.targets)+1)
+	for _, target := range b.targets {
+		lists = append(lists, target)
+	}
+	lists = append(lists, b.list)
+
+	now := time.Now()
+	for _, list := range lists {
+		original, err := s.GetList(b.userID, list.ID)
+		if err != nil {
+			return rollback(err)
+		}
+		undo = append(undo, func() error { return s.writeWatchlist(original) })
+
+		list.UpdatedAt = now
+		if err := s.writeWatchlist(list); err != nil {
+			return rollback(err)
+		}
+	}
+
+	if b.trash != nil {
+		original, err := s.loadTrash(b.userID)
+		if err != nil {
+			return rollback(err)
+		}
+		undo = append(undo, func() error { return s.saveTrash(original) })
+
+		if err := s.saveTrash(b.trash); err != nil {
+			return rollback(err)
+		}
+	}
+
+	if b.historyChanged {
+		original, err := s.loadWatchHistory(b.userID)
+		if err != nil {
+			return rollback(err)
+		}
+		undo = append(undo, func() error { return s.saveWatchHistory(original) })
+
+		b.history.UpdatedAt = now
+		if err := s.saveWatchHistory(b.history); err != nil {
+			return rollback(err)
+		}
+	}
+
+	// Versions are only recorded once every file is written, so a rolled
+	// back batch never shows up as one
+	for _, list := range lists {
+		s.saveVersion(list)
+	}
+	return nil
+}
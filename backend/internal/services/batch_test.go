@@ -0,0 +1,139 @@
+package services
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func batchTestItems() []models.WatchlistItem {
+	return []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien"},
+		{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Heat"},
+		{ID: "c", MediaType: models.MediaTypeTV, MovieID: 1, Title: "Dark"},
+	}
+}
+
+func TestApplyBatchSavesAllOperations(t *testing.T) {
+	s := newTestService(t, batchTestItems())
+
+	resp, err := s.ApplyBatch("user", "", models.BatchRequest{Operations: []models.BatchOperation{
+		{Op: models.BatchOpWatched, ItemID: "a"},
+		{Op: models.BatchOpTag, ItemID: "b", Tags: []string{"rewatch"}},
+		{Op: models.BatchOpRemove, ItemID: "c"},
+	}})
+	require.NoError(t, err)
+	assert.True(t, resp.Applied)
+	require.Len(t, resp.Results, 3)
+	for _, result := range resp.Results {
+		assert.True(t, result.OK, result.Error)
+	}
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	require.Len(t, watchlist.Items, 2)
+	assert.True(t, watchlist.Items[0].IsWatched)
+	assert.Equal(t, []string{"rewatch"}, watchlist.Items[1].Tags)
+}
+
+func TestApplyBatchFailureAndDryRunSaveNothing(t *testing.T) {
+	s := newTestService(t, batchTestItems())
+
+	// Adding needs a metadata provider, which the test service lacks
+	resp, err := s.ApplyBatch("user", "", models.BatchRequest{Operations: []models.BatchOperation{
+		{Op: models.BatchOpRemove, ItemID: "a"},
+		{Op: models.BatchOpAdd, MediaType: models.MediaTypeMovie, ID: 3},
+		{Op: models.BatchOpRemove, ItemID: "a"},
+	}})
+	require.NoError(t, err)
+	assert.False(t, resp.Applied)
+	assert.True(t, resp.Results[0].OK)
+	assert.False(t, resp.Results[1].OK)
+	assert.False(t, resp.Results[2].OK, "the item was already removed earlier in the batch")
+
+	resp, err = s.ApplyBatch("user", "", models.BatchRequest{DryRun: true, Operations: []models.BatchOperation{
+		{Op: models.BatchOpRemove, ItemID: "b"},
+	}})
+	require.NoError(t, err)
+	assert.False(t, resp.Applied)
+	assert.True(t, resp.Results[0].OK)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Len(t, watchlist.Items, 3)
+}
+
+func TestApplyBatchRollsBackOnSaveFailure(t *testing.T) {
+	s := newTestService(t, batchTestItems())
+	name := "Heists"
+	heists, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+
+	// A directory in the way of the default list's temporary file makes its save fail
+	require.NoError(t, os.Mkdir(s.listFilePath("user", "")+".tmp", 0755))
+
+	_, err = s.ApplyBatch("user", "", models.BatchRequest{Operations: []models.BatchOperation{
+		{Op: models.BatchOpMove, ItemID: "b", TargetListID: heists.ID},
+		{Op: models.BatchOpWatched, ItemID: "a"},
+	}})
+	require.Error(t, err)
+
+	target, err := s.GetList("user", heists.ID)
+	require.NoError(t, err)
+	assert.Empty(t, target.Items, "the move target is restored")
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Len(t, watchlist.Items, 3)
+	history, err := s.GetWatchHistory("user", "")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	versions, err := s.GetVersions("user", heists.ID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 1, "the rolled back move target is not a version")
+
+	_, err = s.ApplyBatch("user", "", models.BatchRequest{})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.ApplyBatch("user", "missing", models.BatchRequest{Operations: []models.BatchOperation{{Op: models.BatchOpWatched, ItemID: "a"}}})
+	assert.ErrorIs(t, err, ErrListNotFound)
+}
+
+func TestApplyBatchAttributesOwnerChanges(t *testing.T) {
+	s := withProvider(newTestService(t, batchTestItems()[1:]), heatResponses)
+	invite, err := s.CreateInvite("user", models.DefaultListID, models.InviteRequest{Role: models.RoleViewer})
+	require.NoError(t, err)
+	summary, err := s.AcceptInvite("alex", invite.Token)
+	require.NoError(t, err)
+
+	resp, err := s.ApplyBatch("user", "", models.BatchRequest{Operations: []models.BatchOperation{
+		{Op: models.BatchOpAdd, MediaType: models.MediaTypeMovie, ID: 1},
+		{Op: models.BatchOpWatched, ItemID: "b"},
+		{Op: models.BatchOpRemove, ItemID: "c"},
+	}})
+	require.NoError(t, err)
+	require.True(t, resp.Applied)
+	assert.Equal(t, "user", resp.Results[0].Item.AddedBy, "batch-added items are credited like single adds")
+
+	activity, err := s.GetCollaborationActivity("alex", summary.ID)
+	require.NoError(t, err)
+	require.Len(t, activity, 4)
+	assert.Equal(t, models.ActivityRemoved, activity[0].Action)
+	assert.Equal(t, "Dark", activity[0].Title)
+	assert.Equal(t, models.ActivityWatched, activity[1].Action)
+	assert.Equal(t, models.ActivityAdded, activity[2].Action)
+	assert.Equal(t, models.ActivityJoined, activity[3].Action)
+	for _, entry := range activity[:3] {
+		assert.Equal(t, "user", entry.UserID)
+	}
+
+	// A dry run records nothing
+	_, err = s.ApplyBatch("user", "", models.BatchRequest{DryRun: true, Operations: []models.BatchOperation{
+		{Op: models.BatchOpRemove, ItemID: "b"},
+	}})
+	require.NoError(t, err)
+	activity, err = s.GetCollaborationActivity("alex", summary.ID)
+	require.NoError(t, err)
+	assert.Len(t, activity, 4)
+}
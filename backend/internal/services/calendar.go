@@ -0,0 +1,105 @@
+package services
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// Errors returned for calendar subscriptions, so handlers can pick a status code
+var (
+	ErrCalendarNotFound     = errors.New("calendar feed not found")
+	ErrInvalidCalendarToken = errors.New("invalid calendar token")
+)
+
+// GetCalendarFeed returns a user's calendar subscription
+func (s *WatchlistService) GetCalendarFeed(userID string) (*models.CalendarFeed, error) {
+	feed, err := s.loadCalendarFeed(userID)
+	if err != nil {
+		return nil, err
+	}
+	if feed == nil {
+		return nil, ErrCalendarNotFound
+	}
+	return feed, nil
+}
+
+// CreateCalendarFeed creates a calendar subscription with a new secret
+// token. An existing subscription is replaced, so the old URL stops working.
+func (s *WatchlistService) CreateCalendarFeed(userID string) (*models.CalendarFeed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	feed := &models.CalendarFeed{
+		UserID:    userID,
+		Token:     s.generateShareToken(),
+		CreatedAt: time.Now(),
+	}
+
+	data, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal calendar feed: %w", err)
+	}
+	if err := ioutil.WriteFile(s.calendarFilePath(userID), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+// DeleteCalendarFeed revokes a user's calendar subscription
+func (s *WatchlistService) DeleteCalendarFeed(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.calendarFilePath(userID))
+	if os.IsNotExist(err) {
+		return ErrCalendarNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+	return nil
+}
+
+// GetCalendarWatchlist returns the watchlist behind a calendar subscription,
+// after checking the subscription token
+func (s *WatchlistService) GetCalendarWatchlist(userID, token string) (*models.Watchlist, error) {
+	feed, err := s.loadCalendarFeed(userID)
+	if err != nil {
+		return nil, err
+	}
+	if feed == nil || token == "" || subtle.ConstantTimeCompare([]byte(feed.Token), []byte(token)) != 1 {
+		return nil, ErrInvalidCalendarToken
+	}
+	return s.GetWatchlist(userID)
+}
+
+// loadCalendarFeed reads a user's calendar subscription, or returns nil if there is none
+func (s *WatchlistService) loadCalendarFeed(userID string) (*models.CalendarFeed, error) {
+	data, err := ioutil.ReadFile(s.calendarFilePath(userID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar feed: %w", err)
+	}
+
+	var feed models.CalendarFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal calendar feed: %w", err)
+	}
+	return &feed, nil
+}
+
+// calendarFilePath returns where a user's calendar subscription is stored
+func (s *WatchlistService) calendarFilePath(userID string) string {
+	return filepath.Join(s.dataDir, fmt.Sprintf("calendar_%s.json", userID))
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestMovieReleases(t *testing.T) {
+	data := map[string]interface{}{
+		"results": []interface{}{
+			map[string]interface{}{
+				"iso_3166_1": "GB",
+				"release_dates": []interface{}{
+					map[string]interface{}{"type": 3.0, "release_date": "2030-01-10T00:00:00.000Z"},
+					map[string]interface{}{"type": 4.0, "release_date": "2030-03-01T00:00:00.000Z"},
+				},
+			},
+			map[string]interface{}{
+				"iso_3166_1": "US",
+				"release_dates": []interface{}{
+					map[string]interface{}{"type": 1.0, "release_date": "2029-12-01T00:00:00.000Z"},
+					map[string]interface{}{"type": 3.0, "release_date": "2030-01-20T00:00:00.000Z"},
+					map[string]interface{}{"type": 2.0, "release_date": "2030-01-17T00:00:00.000Z"},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []models.ReleaseDate{
+		{Type: models.ReleaseTheatrical, Date: "2030-01-17", Country: "US"},
+		{Type: models.ReleaseDigital, Date: "2030-03-01", Country: "GB"},
+	}, movieReleases(data))
+}
+
+func TestTVReleases(t *testing.T) {
+	assert.Nil(t, tvReleases(map[string]interface{}{"next_episode_to_air": nil}))
+	assert.Equal(t, []models.ReleaseDate{
+		{Type: models.ReleaseEpisode, Date: "2030-05-02", SeasonNumber: 3, EpisodeNumber: 4, EpisodeName: "The Return"},
+	}, tvReleases(map[string]interface{}{
+		"next_episode_to_air": map[string]interface{}{
+			"air_date": "2030-05-02", "season_number": 3.0, "episode_number": 4.0, "name": "The Return",
+		},
+	}))
+}
+
+func calendarWatchlist() *models.Watchlist {
+	return &models.Watchlist{
+		UserID: "u1",
+		Name:   "Watchlist",
+		Items: []models.WatchlistItem{
+			{
+				ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heist, Part; Two",
+				Overview: "A long overview that goes on and on, well past the seventy-five octet line limit of iCalendar — with ünïcödé.",
+				Releases: []models.ReleaseDate{
+					{Type: models.ReleaseTheatrical, Date: "2030-01-17", Country: "US"},
+					{Type: models.ReleaseDigital, Date: "2030-03-01", Country: "US"},
+				},
+			},
+			{
+				ID: "tv_2", MediaType: models.MediaTypeTV, MovieID: 2, Title: "Show", IsWatched: true,
+				Releases: []models.ReleaseDate{{Type: models.ReleaseEpisode, Date: "2030-05-02", SeasonNumber: 3, EpisodeNumber: 4, EpisodeName: "The Return"}},
+			},
+			{ID: "movie_3", MediaType: models.MediaTypeMovie, MovieID: 3, Title: "Watched", IsWatched: true, ReleaseDate: "2030-02-01"},
+			{ID: "movie_4", MediaType: models.MediaTypeMovie, MovieID: 4, Title: "Old", ReleaseDate: "1999-02-01"},
+			{ID: "movie_5", MediaType: models.MediaTypeMovie, MovieID: 5, Title: "Unrefreshed", ReleaseDate: "2031-07-04"},
+		},
+	}
+}
+
+func TestExportToICS(t *testing.T) {
+	exporter := newTestExportService(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, exporter.ExportToICS(&buf, calendarWatchlist()))
+	ics := buf.String()
+
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 4, strings.Count(ics, "BEGIN:VEVENT"))
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), icsLineLimit, line)
+	}
+
+	// Unfold continuation lines before looking at values
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	assert.Contains(t, unfolded, "UID:u1-movie-1-theatrical@relax-and-watch\r\n")
+	assert.Contains(t, unfolded, "UID:u1-movie-1-digital@relax-and-watch\r\n")
+	assert.Contains(t, unfolded, "UID:u1-tv-2-episode-s03e04@relax-and-watch\r\n")
+	assert.Contains(t, unfolded, "UID:u1-movie-5-theatrical@relax-and-watch\r\n")
+	assert.Contains(t, unfolded, `SUMMARY:Heist\, Part\; Two (in theaters)`)
+	assert.Contains(t, unfolded, "SUMMARY:Show S03E04: The Return\r\n")
+	assert.Contains(t, unfolded, "DTSTART;VALUE=DATE:20300117\r\nDTEND;VALUE=DATE:20300118\r\n")
+	assert.Contains(t, unfolded, "ünïcödé.")
+	assert.NotContains(t, unfolded, "Watched (in theaters)")
+	assert.NotContains(t, unfolded, "Old (in theaters)")
+
+	// UIDs survive date changes
+	moved := calendarWatchlist()
+	moved.Items[0].Releases[0].Date = "2030-02-14"
+	buf.Reset()
+	require.NoError(t, exporter.ExportToICS(&buf, moved))
+	assert.Contains(t, strings.ReplaceAll(buf.String(), "\r\n ", ""), "UID:u1-movie-1-theatrical@relax-and-watch\r\n")
+}
+
+func TestCalendarFeedToken(t *testing.T) {
+	service := newTestService(t, nil)
+
+	_, err := service.GetCalendarWatchlist("u1", "anything")
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+
+	feed, err := service.CreateCalendarFeed("u1")
+	require.NoError(t, err)
+	require.NotEmpty(t, feed.Token)
+
+	_, err = service.GetCalendarWatchlist("u1", feed.Token)
+	assert.NoError(t, err)
+	_, err = service.GetCalendarWatchlist("u1", "")
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+	_, err = service.GetCalendarWatchlist("u2", feed.Token)
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+
+	regenerated, err := service.CreateCalendarFeed("u1")
+	require.NoError(t, err)
+	_, err = service.GetCalendarWatchlist("u1", feed.Token)
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken, "old token is revoked")
+	_, err = service.GetCalendarWatchlist("u1", regenerated.Token)
+	assert.NoError(t, err)
+
+	require.NoError(t, service.DeleteCalendarFeed("u1"))
+	_, err = service.GetCalendarWatchlist("u1", regenerated.Token)
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+	assert.ErrorIs(t, service.DeleteCalendarFeed("u1"), ErrCalendarNotFound)
+	_, err = service.GetCalendarFeed("u1")
+	assert.ErrorIs(t, err, ErrCalendarNotFound)
+}
+
+func TestHasUpcomingRelease(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.True(t, hasUpcomingRelease(models.WatchlistItem{ReleaseDate: "2030-01-01"}, now))
+	assert.False(t, hasUpcomingRelease(models.WatchlistItem{ReleaseDate: "2029-12-31"}, now))
+	assert.False(t, hasUpcomingRelease(models.WatchlistItem{}, now))
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"strings"
+
+	"r.a.w/backend/internal/models"
+)
+
+// CloneSharedWatchlist copies the items of a share into one of the viewer's
+// lists. Only what viewers of the share can see is copied, and only as
+// metadata: the creator's notes, ratings and watched state stay with them.
+func (s *WatchlistService) CloneSharedWatchlist(shareToken string, viewer models.ShareViewer, req models.CloneRequest) (*models.CloneResult, error) {
+	if viewer.UserID == "" {
+		return nil, invalidf("user ID is required to clone a shared watchlist")
+	}
+	if req.Strategy == "" {
+		req.Strategy = models.CloneSkip
+	}
+	switch req.Strategy {
+	case models.CloneSkip, models.CloneOverwrite, models.CloneKeepBoth:
+	default:
+		return nil, invalidf("invalid strategy %q, use %s, %s or %s", req.Strategy, models.CloneSkip, models.CloneOverwrite, models.CloneKeepBoth)
+	}
+	if req.NewList && req.ListID != "" {
+		return nil, invalidf("list_id cannot be combined with new_list")
+	}
+
+	// The clone is only logged once it succeeded
+	share, err := s.openShare(shareToken, viewer, "")
+	if err != nil {
+		return nil, err
+	}
+	view := sharedView(share)
+
+	listID := req.ListID
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+	newListID := ""
+	if req.NewList {
+		name := strings.TrimSpace(req.ListName)
+		if name == "" {
+			name = view.Title
+		}
+		if name == "" {
+			name = "Shared list"
+		}
+		description := view.Description
+		list, err := s.CreateList(viewer.UserID, models.ListInput{Name: &name, Description: &description})
+		if err != nil {
+			return nil, err
+		}
+		listID = list.ID
+		newListID = list.ID
+	}
+
+	result := &models.CloneResult{
+		ListID:  listID,
+		Added:   []models.CloneEntry{},
+		Updated: []models.CloneEntry{},
+		Skipped: []models.CloneEntry{},
+	}
+	var copies []models.SharedItem
+	var kept []models.CloneEntry
+	err = s.updateList(viewer.UserID, listID, func(watchlist *models.Watchlist) error {
+		for _, shared := range view.Items {
+			entry := models.CloneEntry{ListID: listID, MediaType: shared.MediaType, MovieID: shared.MovieID, Title: shared.Title}
+
+			if existing := findTitle(watchlist, shared.MediaType, shared.MovieID); existing != nil {
+				entry.ItemID = existing.ID
+				switch req.Strategy {
+				case models.CloneOverwrite:
+					copySharedMetadata(existing, shared)
+					result.Updated = append(result.Updated, entry)
+				case models.CloneKeepBoth:
+					copies = append(copies, shared)
+					kept = append(kept, entry)
+				default:
+					result.Skipped = append(result.Skipped, entry)
+				}
+				continue
+			}
+
+			item := &models.WatchlistItem{MediaType: shared.MediaType, MovieID: shared.MovieID}
+			copySharedMetadata(item, shared)
+			if err := s.insertItem(watchlist, item); err != nil {
+				return err
+			}
+			entry.ItemID = item.ID
+			result.Added = append(result.Added, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		s.deleteFailedCloneList(viewer.UserID, newListID)
+		return nil, err
+	}
+	s.logShareAccess(share.ID, viewer, models.ShareAccessCloned)
+
+	// The merge is saved by now, so a failure to make the copies is reported
+	// in the result rather than failing the clone
+	if len(copies) > 0 {
+		if err := s.cloneCopies(viewer.UserID, view, copies, result); err != nil {
+			s.logger.Warning("Failed to copy %d titles of share %s for user %s: %v", len(copies), share.ID, viewer.UserID, err)
+			result.CopiesError = err.Error()
+			result.Skipped = append(result.Skipped, kept...)
+		}
+	}
+
+	s.logger.Success("Cloned share %s into list %s for user %s: %d added, %d updated, %d skipped",
+		share.ID, listID, viewer.UserID, len(result.Added), len(result.Updated), len(result.Skipped))
+	return result, nil
+}
+
+// cloneCopies puts the shared titles that were already in the target list
+// into a new list, so that CloneKeepBoth keeps both without a list holding a
+// title twice
+func (s *WatchlistService) cloneCopies(userID string, view *models.SharedWatchlistView, copies []models.SharedItem, result *models.CloneResult) error {
+	name := view.Title
+	if name == "" {
+		name = "Shared list"
+	}
+	name += " (copies)"
+	description := view.Description
+	list, err := s.CreateList(userID, models.ListInput{Name: &name, Description: &description})
+	if err != nil {
+		return err
+	}
+
+	var added []models.CloneEntry
+	err = s.updateList(userID, list.ID, func(watchlist *models.Watchlist) error {
+		for _, shared := range copies {
+			item := &models.WatchlistItem{MediaType: shared.MediaType, MovieID: shared.MovieID}
+			copySharedMetadata(item, shared)
+			if err := s.insertItem(watchlist, item); err != nil {
+				return err
+			}
+			added = append(added, models.CloneEntry{
+				ListID: list.ID, ItemID: item.ID, MediaType: shared.MediaType, MovieID: shared.MovieID, Title: shared.Title,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		s.deleteFailedCloneList(userID, list.ID)
+		return err
+	}
+	result.CopiesListID = list.ID
+	result.Added = append(result.Added, added...)
+	return nil
+}
+
+// deleteFailedCloneList deletes a list created for a clone that then failed,
+// so that no empty list is left behind. It does nothing for an empty listID.
+func (s *WatchlistService) deleteFailedCloneList(userID, listID string) {
+	if listID == "" {
+		return
+	}
+	if err := s.DeleteList(userID, listID); err != nil {
+		s.logger.Warning("Failed to delete list %s of failed clone for user %s: %v", listID, userID, err)
+	}
+}
+
+// copySharedMetadata copies the provider metadata of a shared item onto item
+func copySharedMetadata(item *models.WatchlistItem, shared models.SharedItem) {
+	item.Title = shared.Title
+	item.PosterPath = shared.PosterPath
+	item.ReleaseDate = shared.ReleaseDate
+	item.Genre = shared.Genre
+	item.Rating = shared.Rating
+	item.Overview = shared.Overview
+	item.Runtime = shared.Runtime
+	item.NumberOfSeasons = shared.NumberOfSeasons
+	item.NumberOfEpisodes = shared.NumberOfEpisodes
+	item.IMDbID = shared.IMDbID
+	item.IMDbRating = shared.IMDbRating
+}
+
+// findTitle returns the first item of a list with the given title, or nil
+func findTitle(watchlist *models.Watchlist, mediaType string, movieID int) *models.WatchlistItem {
+	for i := range watchlist.Items {
+		if watchlist.Items[i].MediaType == mediaType && watchlist.Items[i].MovieID == movieID {
+			return &watchlist.Items[i]
+		}
+	}
+	return nil
+}
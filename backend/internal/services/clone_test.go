@@ -0,0 +1,156 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+// newCloneFixture shares a list of two titles by "user" and gives "alex" a
+// list that already holds one of them
+func newCloneFixture(t *testing.T) (*WatchlistService, *models.ShareableWatchlist) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", Overview: "Updated overview",
+			UserNotes: "private", Tags: []string{"mine"}},
+		{ID: "tv_2", MediaType: models.MediaTypeTV, MovieID: 2, Title: "The Wire"},
+	})
+	_, err := s.MarkAsWatched("user", "movie_1", models.WatchEventInput{})
+	require.NoError(t, err)
+	share, err := s.CreateShareableWatchlist("user", models.ShareRequest{Title: "Crime", IsPublic: true})
+	require.NoError(t, err)
+
+	require.NoError(t, s.updateWatchlist("alex", func(w *models.Watchlist) error {
+		w.Items = append(w.Items, models.WatchlistItem{ID: "mine", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", UserNotes: "alex's note"})
+		return nil
+	}))
+	return s, share
+}
+
+func TestCloneStrategies(t *testing.T) {
+	alex := models.ShareViewer{UserID: "alex"}
+
+	s, share := newCloneFixture(t)
+	result, err := s.CloneSharedWatchlist(share.ShareToken, alex, models.CloneRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultListID, result.ListID)
+	require.Len(t, result.Added, 1)
+	assert.Equal(t, "The Wire", result.Added[0].Title)
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, "mine", result.Skipped[0].ItemID)
+	assert.Empty(t, result.Updated)
+
+	list, err := s.GetWatchlist("alex")
+	require.NoError(t, err)
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, "alex's note", list.Items[0].UserNotes)
+	assert.Empty(t, list.Items[1].UserNotes)
+
+	s, share = newCloneFixture(t)
+	result, err = s.CloneSharedWatchlist(share.ShareToken, alex, models.CloneRequest{Strategy: models.CloneOverwrite})
+	require.NoError(t, err)
+	assert.Len(t, result.Added, 1)
+	require.Len(t, result.Updated, 1)
+	list, err = s.GetWatchlist("alex")
+	require.NoError(t, err)
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, "Updated overview", list.Items[0].Overview, "overwrite refreshes metadata")
+	assert.Equal(t, "alex's note", list.Items[0].UserNotes, "but keeps the caller's own data")
+
+	s, share = newCloneFixture(t)
+	result, err = s.CloneSharedWatchlist(share.ShareToken, alex, models.CloneRequest{Strategy: models.CloneKeepBoth})
+	require.NoError(t, err)
+	require.Len(t, result.Added, 2)
+	assert.Equal(t, models.DefaultListID, result.Added[0].ListID)
+	assert.Equal(t, result.CopiesListID, result.Added[1].ListID, "the title alex already has is copied to a new list")
+	list, err = s.GetWatchlist("alex")
+	require.NoError(t, err)
+	assert.Len(t, list.Items, 2)
+	copies, err := s.GetList("alex", result.CopiesListID)
+	require.NoError(t, err)
+	assert.Equal(t, "Crime (copies)", copies.Name)
+	require.Len(t, copies.Items, 1)
+	assert.Equal(t, "Updated overview", copies.Items[0].Overview)
+
+	// Both lists still export and restore
+	for _, listID := range []string{models.DefaultListID, result.CopiesListID} {
+		export, err := s.ExportData("alex", listID)
+		require.NoError(t, err)
+		restored, _, err := s.RestoreExport("alex", listID, export, false)
+		require.NoError(t, err)
+		assert.Len(t, restored.Items, len(export.Watchlist.Items))
+	}
+
+	_, err = s.CloneSharedWatchlist(share.ShareToken, alex, models.CloneRequest{Strategy: "merge"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.CloneSharedWatchlist(share.ShareToken, models.ShareViewer{}, models.CloneRequest{})
+	assert.ErrorIs(t, err, ErrInvalidInput, "cloning needs a user to clone for")
+}
+
+func TestCloneIntoNewList(t *testing.T) {
+	s, share := newCloneFixture(t)
+
+	result, err := s.CloneSharedWatchlist(share.ShareToken, models.ShareViewer{UserID: "alex"}, models.CloneRequest{NewList: true})
+	require.NoError(t, err)
+	assert.Len(t, result.Added, 2)
+
+	list, err := s.GetList("alex", result.ListID)
+	require.NoError(t, err)
+	assert.Equal(t, "Crime", list.Name)
+	require.Len(t, list.Items, 2)
+	for _, item := range list.Items {
+		assert.Empty(t, item.UserNotes, "the creator's notes are not copied")
+		assert.Empty(t, item.Tags)
+		assert.False(t, item.IsWatched, "the creator's watched state is not copied")
+	}
+
+	log, err := s.GetShareAccessLog("user", share.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, log.CloneCount)
+	assert.Zero(t, log.ViewCount)
+}
+
+func TestCloneKeepsMergeWhenCopiesFail(t *testing.T) {
+	// A list from before titles were deduplicated can hold one twice, and a
+	// list of copies can't
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+		{ID: "movie_1b", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+		{ID: "tv_2", MediaType: models.MediaTypeTV, MovieID: 2, Title: "The Wire"},
+	})
+	share, err := s.CreateShareableWatchlist("user", models.ShareRequest{Title: "Crime", IsPublic: true})
+	require.NoError(t, err)
+	require.NoError(t, s.updateWatchlist("alex", func(w *models.Watchlist) error {
+		w.Items = append(w.Items, models.WatchlistItem{ID: "mine", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"})
+		return nil
+	}))
+
+	result, err := s.CloneSharedWatchlist(share.ShareToken, models.ShareViewer{UserID: "alex"}, models.CloneRequest{Strategy: models.CloneKeepBoth})
+	require.NoError(t, err, "the merge was saved, so the clone is not reported as failed")
+	assert.NotEmpty(t, result.CopiesError)
+	assert.Empty(t, result.CopiesListID)
+	require.Len(t, result.Added, 1)
+	assert.Equal(t, "The Wire", result.Added[0].Title)
+	require.Len(t, result.Skipped, 2, "the titles that were not copied are reported")
+	assert.Equal(t, "mine", result.Skipped[0].ItemID)
+
+	lists, err := s.GetLists("alex")
+	require.NoError(t, err)
+	assert.Len(t, lists, 1, "the list made for the copies is deleted")
+	log, err := s.GetShareAccessLog("user", share.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, log.CloneCount)
+}
+
+func TestFailedCloneIsNotCounted(t *testing.T) {
+	s, share := newCloneFixture(t)
+
+	_, err := s.CloneSharedWatchlist(share.ShareToken, models.ShareViewer{UserID: "alex"}, models.CloneRequest{ListID: "missing"})
+	assert.ErrorIs(t, err, ErrListNotFound)
+
+	log, err := s.GetShareAccessLog("user", share.ID)
+	require.NoError(t, err)
+	assert.Zero(t, log.CloneCount, "only clones that succeed are counted")
+	assert.Empty(t, log.Entries)
+}
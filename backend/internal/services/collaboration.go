@@ -0,0 +1,672 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// Errors returned for collaborative lists. Users who are not members of a
+// list get ErrCollabNotFound, so they cannot learn that it exists.
+var (
+	ErrCollabNotFound  = errors.New("collaborative list not found")
+	ErrCollabForbidden = errors.New("your role does not allow this change")
+	ErrInviteNotFound  = errors.New("invite not found")
+	ErrInviteExpired   = errors.New("invite has expired")
+	ErrMemberNotFound  = errors.New("member not found")
+)
+
+const (
+	// defaultInviteTTL is how long an invite stays valid when no expiry is given
+	defaultInviteTTL = 7 * 24 * time.Hour
+	// collabActivityLimit is how many activity entries are kept per list
+	collabActivityLimit = 200
+)
+
+// CreateInvite invites someone to one of the owner's lists with the given
+// role, making the list collaborative if it is not already
+func (s *WatchlistService) CreateInvite(ownerID, listID string, req models.InviteRequest) (*models.ListInvite, error) {
+	if req.Role != models.RoleEditor && req.Role != models.RoleViewer {
+		return nil, invalidf("invalid role %q, use %s or %s", req.Role, models.RoleEditor, models.RoleViewer)
+	}
+	now := time.Now()
+	expiresAt := now.Add(defaultInviteTTL)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return nil, invalidf("expiry must be in the future")
+		}
+		expiresAt = *req.ExpiresAt
+	}
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.GetList(ownerID, listID); err != nil {
+		return nil, err
+	}
+	collab, err := s.findCollaboration(ownerID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if collab == nil {
+		collab = &models.Collaboration{
+			ID:      s.generateID(),
+			OwnerID: ownerID,
+			ListID:  listID,
+			Members: []models.ListMember{
+				{UserID: ownerID, Role: models.RoleOwner, JoinedAt: now},
+			},
+			CreatedAt: now,
+		}
+	}
+
+	// Expired invites are dropped whenever a new one is made
+	pending := collab.Invites[:0]
+	for _, invite := range collab.Invites {
+		if now.Before(invite.ExpiresAt) {
+			pending = append(pending, invite)
+		}
+	}
+	collab.Invites = pending
+
+	invite := models.ListInvite{
+		ID:        s.generateID(),
+		Token:     s.generateShareToken(),
+		Role:      req.Role,
+		CreatedBy: ownerID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	collab.Invites = append(collab.Invites, invite)
+	collab.UpdatedAt = now
+	if err := s.saveCollaboration(collab); err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// GetCollaboration returns the members and pending invites of one of the
+// owner's lists
+func (s *WatchlistService) GetCollaboration(ownerID, listID string) (*models.Collaboration, error) {
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+	collab, err := s.findCollaboration(ownerID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if collab == nil {
+		return nil, ErrCollabNotFound
+	}
+	collab.Activity = nil
+	return collab, nil
+}
+
+// RevokeInvite withdraws a pending invite to one of the owner's lists
+func (s *WatchlistService) RevokeInvite(ownerID, listID, inviteID string) error {
+	return s.updateOwnedCollaboration(ownerID, listID, func(collab *models.Collaboration) error {
+		for i, invite := range collab.Invites {
+			if invite.ID == inviteID {
+				collab.Invites = append(collab.Invites[:i], collab.Invites[i+1:]...)
+				return nil
+			}
+		}
+		return ErrInviteNotFound
+	})
+}
+
+// UpdateMemberRole changes the role of a member of one of the owner's lists
+func (s *WatchlistService) UpdateMemberRole(ownerID, listID, memberID, role string) (*models.ListMember, error) {
+	if role != models.RoleEditor && role != models.RoleViewer {
+		return nil, invalidf("invalid role %q, use %s or %s", role, models.RoleEditor, models.RoleViewer)
+	}
+
+	var updated models.ListMember
+	err := s.updateOwnedCollaboration(ownerID, listID, func(collab *models.Collaboration) error {
+		member := findMember(collab, memberID)
+		if member == nil {
+			return ErrMemberNotFound
+		}
+		if member.Role == models.RoleOwner {
+			return invalidf("the owner's role cannot be changed")
+		}
+		member.Role = role
+		updated = *member
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// RemoveMember removes a member from one of the owner's lists
+func (s *WatchlistService) RemoveMember(ownerID, listID, memberID string) error {
+	return s.updateOwnedCollaboration(ownerID, listID, func(collab *models.Collaboration) error {
+		if memberID == ownerID {
+			return invalidf("the owner cannot be removed")
+		}
+		return removeMember(collab, memberID)
+	})
+}
+
+// AcceptInvite makes a user a member of the list an invite was made for.
+// Invites can be accepted once.
+func (s *WatchlistService) AcceptInvite(userID, token string) (*models.CollaborationSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collabs, err := s.loadCollaborations()
+	if err != nil {
+		return nil, err
+	}
+	for i := range collabs {
+		collab := &collabs[i]
+		for j, invite := range collab.Invites {
+			if invite.Token != token {
+				continue
+			}
+			now := time.Now()
+			if !now.Before(invite.ExpiresAt) {
+				return nil, ErrInviteExpired
+			}
+			if findMember(collab, userID) != nil {
+				return nil, invalidf("already a member of this list")
+			}
+
+			collab.Members = append(collab.Members, models.ListMember{
+				UserID:    userID,
+				Role:      invite.Role,
+				InvitedBy: invite.CreatedBy,
+				JoinedAt:  now,
+			})
+			collab.Invites = append(collab.Invites[:j], collab.Invites[j+1:]...)
+			addActivity(collab, models.ListActivity{At: now, UserID: userID, Action: models.ActivityJoined})
+			collab.UpdatedAt = now
+			if err := s.saveCollaboration(collab); err != nil {
+				return nil, err
+			}
+			return s.collaborationSummary(collab, userID)
+		}
+	}
+	return nil, ErrInviteNotFound
+}
+
+// GetCollaborations returns the collaborative lists a user belongs to,
+// including their own, most recently changed first
+func (s *WatchlistService) GetCollaborations(userID string) ([]models.CollaborationSummary, error) {
+	collabs, err := s.loadCollaborations()
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []models.CollaborationSummary{}
+	for i := range collabs {
+		if findMember(&collabs[i], userID) == nil {
+			continue
+		}
+		summary, err := s.collaborationSummary(&collabs[i], userID)
+		if err != nil {
+			s.logger.Warning("Skipping collaborative list %s for user %s: %v", collabs[i].ID, userID, err)
+			continue
+		}
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+	})
+	return summaries, nil
+}
+
+// GetCollaborativeList returns a collaborative list as seen by one of its
+// members, with every member's watched state. Other members don't see the
+// owner's episode progress, ratings or reviews.
+func (s *WatchlistService) GetCollaborativeList(userID, collabID string) (*models.CollaborativeList, error) {
+	collab, role, err := s.memberCollaboration(userID, collabID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.GetList(collab.OwnerID, collab.ListID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The list comes with the owner's watched state; show the viewer's own
+	history, err := s.loadWatchHistory(userID)
+	if err != nil {
+		return nil, err
+	}
+	applyWatchHistory(list, history)
+
+	watched := make(map[titleKey][]models.MemberWatch)
+	for _, member := range collab.Members {
+		history, err := s.loadWatchHistory(member.UserID)
+		if err != nil {
+			return nil, err
+		}
+		latest := make(map[titleKey]models.MemberWatch)
+		for _, event := range history.Events {
+			key := titleKey{event.MediaType, event.MovieID}
+			watch := latest[key]
+			watch.WatchCount++
+			if event.WatchedAt.After(watch.WatchedAt) {
+				watch.WatchedAt = event.WatchedAt
+			}
+			latest[key] = watch
+		}
+		for key, watch := range latest {
+			watch.UserID = member.UserID
+			watched[key] = append(watched[key], watch)
+		}
+	}
+
+	view := &models.CollaborativeList{
+		ID:          collab.ID,
+		OwnerID:     collab.OwnerID,
+		ListID:      collab.ListID,
+		Name:        list.Name,
+		Description: list.Description,
+		Role:        role,
+		Members:     collab.Members,
+		Items:       make([]models.CollaborativeItem, 0, len(list.Items)),
+		UpdatedAt:   list.UpdatedAt,
+	}
+	for _, item := range list.Items {
+		watchedBy := watched[titleKey{item.MediaType, item.MovieID}]
+		if watchedBy == nil {
+			watchedBy = []models.MemberWatch{}
+		}
+		// Episode progress, ratings and reviews are the owner's own
+		if userID != collab.OwnerID {
+			item.Progress = nil
+			item.PersonalRating = nil
+			item.Review = nil
+		}
+		view.Items = append(view.Items, models.CollaborativeItem{WatchlistItem: item, WatchedBy: watchedBy})
+	}
+	return view, nil
+}
+
+// AddCollaborativeItem adds a title to a collaborative list on behalf of an
+// editor, see AddToList
+func (s *WatchlistService) AddCollaborativeItem(userID, collabID, mediaType string, tmdbID int) (*models.WatchlistItem, error) {
+	collab, err := s.editorCollaboration(userID, collabID)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.prepareItem(mediaType, tmdbID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.insertCollaborativeItem(collab, userID, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// insertCollaborativeItem adds a prepared item to a collaborative list,
+// credited to the member who added it
+func (s *WatchlistService) insertCollaborativeItem(collab *models.Collaboration, userID string, item *models.WatchlistItem) error {
+	item.AddedBy = userID
+	err := s.updateList(collab.OwnerID, collab.ListID, func(watchlist *models.Watchlist) error {
+		return s.insertItem(watchlist, item)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.recordActivity(collab.ID, models.ListActivity{UserID: userID, Action: models.ActivityAdded, ItemID: item.ID, Title: item.Title})
+	return nil
+}
+
+// RemoveCollaborativeItem removes an item from a collaborative list on
+// behalf of an editor. The item goes to the owner's trash.
+func (s *WatchlistService) RemoveCollaborativeItem(userID, collabID, itemID string) error {
+	collab, err := s.editorCollaboration(userID, collabID)
+	if err != nil {
+		return err
+	}
+
+	item, err := s.removeToTrash(collab.OwnerID, collab.ListID, itemID)
+	if err != nil {
+		return err
+	}
+
+	s.recordActivity(collab.ID, models.ListActivity{UserID: userID, Action: models.ActivityRemoved, ItemID: itemID, Title: item.Title})
+	return nil
+}
+
+// MarkCollaborativeWatched records that an editor watched an item of a
+// collaborative list, in the editor's own watch history
+func (s *WatchlistService) MarkCollaborativeWatched(userID, collabID, itemID string, input models.WatchEventInput) (*models.WatchEvent, error) {
+	collab, item, err := s.collaborativeItem(userID, collabID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := s.recordWatch(userID, item, input)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordActivity(collab.ID, models.ListActivity{UserID: userID, Action: models.ActivityWatched, ItemID: item.ID, Title: item.Title})
+	return event, nil
+}
+
+// MarkCollaborativeUnwatched undoes an editor's most recent watch of an item
+// of a collaborative list
+func (s *WatchlistService) MarkCollaborativeUnwatched(userID, collabID, itemID string) error {
+	collab, item, err := s.collaborativeItem(userID, collabID, itemID)
+	if err != nil {
+		return err
+	}
+
+	err = s.updateWatchHistory(userID, func(history *models.WatchHistory) error {
+		return removeLatestWatch(history, titleKey{item.MediaType, item.MovieID})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.recordActivity(collab.ID, models.ListActivity{UserID: userID, Action: models.ActivityUnwatched, ItemID: item.ID, Title: item.Title})
+	return nil
+}
+
+// GetCollaborationActivity returns the recent changes to a collaborative
+// list, newest first
+func (s *WatchlistService) GetCollaborationActivity(userID, collabID string) ([]models.ListActivity, error) {
+	collab, _, err := s.memberCollaboration(userID, collabID)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := make([]models.ListActivity, 0, len(collab.Activity))
+	for i := len(collab.Activity) - 1; i >= 0; i-- {
+		activity = append(activity, collab.Activity[i])
+	}
+	return activity, nil
+}
+
+// LeaveCollaboration removes a member from a collaborative list at their own
+// request. Owners cannot leave their lists.
+func (s *WatchlistService) LeaveCollaboration(userID, collabID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collab, err := s.loadCollaboration(collabID)
+	if err != nil {
+		return err
+	}
+	if findMember(collab, userID) == nil {
+		return ErrCollabNotFound
+	}
+	if collab.OwnerID == userID {
+		return invalidf("the owner cannot leave the list")
+	}
+	if err := removeMember(collab, userID); err != nil {
+		return err
+	}
+	now := time.Now()
+	addActivity(collab, models.ListActivity{At: now, UserID: userID, Action: models.ActivityLeft})
+	collab.UpdatedAt = now
+	return s.saveCollaboration(collab)
+}
+
+// memberCollaboration loads a collaborative list and the user's role in it
+func (s *WatchlistService) memberCollaboration(userID, collabID string) (*models.Collaboration, string, error) {
+	collab, err := s.loadCollaboration(collabID)
+	if err != nil {
+		return nil, "", err
+	}
+	member := findMember(collab, userID)
+	if member == nil {
+		return nil, "", ErrCollabNotFound
+	}
+	return collab, member.Role, nil
+}
+
+// editorCollaboration loads a collaborative list the user may change
+func (s *WatchlistService) editorCollaboration(userID, collabID string) (*models.Collaboration, error) {
+	collab, role, err := s.memberCollaboration(userID, collabID)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.RoleViewer {
+		return nil, ErrCollabForbidden
+	}
+	return collab, nil
+}
+
+// collaborativeItem finds an item of a collaborative list the user may change
+func (s *WatchlistService) collaborativeItem(userID, collabID, itemID string) (*models.Collaboration, *models.WatchlistItem, error) {
+	collab, err := s.editorCollaboration(userID, collabID)
+	if err != nil {
+		return nil, nil, err
+	}
+	list, err := s.GetList(collab.OwnerID, collab.ListID)
+	if err != nil {
+		return nil, nil, err
+	}
+	item := findItem(list, itemID)
+	if item == nil {
+		return nil, nil, ErrItemNotFound
+	}
+	return collab, item, nil
+}
+
+// collaborationSummary describes a collaborative list for one of its members
+func (s *WatchlistService) collaborationSummary(collab *models.Collaboration, userID string) (*models.CollaborationSummary, error) {
+	list, err := s.GetList(collab.OwnerID, collab.ListID)
+	if err != nil {
+		return nil, err
+	}
+	summary := &models.CollaborationSummary{
+		ID:        collab.ID,
+		OwnerID:   collab.OwnerID,
+		ListID:    collab.ListID,
+		Name:      list.Name,
+		ItemCount: len(list.Items),
+		UpdatedAt: collab.UpdatedAt,
+	}
+	if list.UpdatedAt.After(summary.UpdatedAt) {
+		summary.UpdatedAt = list.UpdatedAt
+	}
+	if member := findMember(collab, userID); member != nil {
+		summary.Role = member.Role
+	}
+	return summary, nil
+}
+
+// updateOwnedCollaboration loads the collaboration of one of the owner's
+// lists, applies fn and saves the result under the service lock
+func (s *WatchlistService) updateOwnedCollaboration(ownerID, listID string, fn func(*models.Collaboration) error) error {
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collab, err := s.findCollaboration(ownerID, listID)
+	if err != nil {
+		return err
+	}
+	if collab == nil {
+		return ErrCollabNotFound
+	}
+	if err := fn(collab); err != nil {
+		return err
+	}
+	collab.UpdatedAt = time.Now()
+	return s.saveCollaboration(collab)
+}
+
+// recordActivity adds an entry to a collaborative list's activity. The
+// change it records has already been made, so failures are only logged.
+func (s *WatchlistService) recordActivity(collabID string, activity models.ListActivity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collab, err := s.loadCollaboration(collabID)
+	if err == nil {
+		activity.At = time.Now()
+		addActivity(collab, activity)
+		collab.UpdatedAt = activity.At
+		err = s.saveCollaboration(collab)
+	}
+	if err != nil {
+		s.logger.Warning("Failed to record activity on collaborative list %s: %v", collabID, err)
+	}
+}
+
+// recordOwnerActivity records a change the owner made to one of their lists
+// outside the collaborative endpoints, if the list is collaborative, so that
+// members see the owner's changes too. Like recordActivity it only logs failures.
+func (s *WatchlistService) recordOwnerActivity(ownerID, listID string, activity models.ListActivity) {
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+
+	s.mu.Lock()
+	collab, err := s.findCollaboration(ownerID, listID)
+	s.mu.Unlock()
+	if err != nil {
+		s.logger.Warning("Failed to look up collaboration on list %s for user %s: %v", listID, ownerID, err)
+		return
+	}
+	if collab == nil {
+		return
+	}
+
+	activity.UserID = ownerID
+	s.recordActivity(collab.ID, activity)
+}
+
+// addActivity appends an activity entry, dropping the oldest beyond the limit
+func addActivity(collab *models.Collaboration, activity models.ListActivity) {
+	collab.Activity = append(collab.Activity, activity)
+	if len(collab.Activity) > collabActivityLimit {
+		collab.Activity = collab.Activity[len(collab.Activity)-collabActivityLimit:]
+	}
+}
+
+// findMember returns a member of a collaborative list, or nil
+func findMember(collab *models.Collaboration, userID string) *models.ListMember {
+	for i := range collab.Members {
+		if collab.Members[i].UserID == userID {
+			return &collab.Members[i]
+		}
+	}
+	return nil
+}
+
+// removeMember splices a member out of a collaborative list
+func removeMember(collab *models.Collaboration, userID string) error {
+	for i, member := range collab.Members {
+		if member.UserID == userID {
+			collab.Members = append(collab.Members[:i], collab.Members[i+1:]...)
+			return nil
+		}
+	}
+	return ErrMemberNotFound
+}
+
+// findCollaboration returns the collaboration of one of the owner's lists,
+// or nil if the list is not collaborative
+func (s *WatchlistService) findCollaboration(ownerID, listID string) (*models.Collaboration, error) {
+	collabs, err := s.loadCollaborations()
+	if err != nil {
+		return nil, err
+	}
+	for i := range collabs {
+		if collabs[i].OwnerID == ownerID && collabs[i].ListID == listID {
+			return &collabs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// deleteCollaboration stops a list from being collaborative, when the list
+// itself is deleted. The caller must hold the service lock.
+func (s *WatchlistService) deleteCollaboration(ownerID, listID string) error {
+	collab, err := s.findCollaboration(ownerID, listID)
+	if err != nil || collab == nil {
+		return err
+	}
+	if err := os.Remove(s.collabFilePath(collab.ID)); err != nil {
+		return fmt.Errorf("failed to delete collaborative list: %w", err)
+	}
+	return nil
+}
+
+// loadCollaborations reads every stored collaboration
+func (s *WatchlistService) loadCollaborations() ([]models.Collaboration, error) {
+	files, err := ioutil.ReadDir(s.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %w", err)
+	}
+
+	var collabs []models.Collaboration
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, "collab_") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		collab, err := s.loadCollaboration(strings.TrimSuffix(strings.TrimPrefix(name, "collab_"), ".json"))
+		if err != nil {
+			continue
+		}
+		collabs = append(collabs, *collab)
+	}
+	return collabs, nil
+}
+
+// loadCollaboration reads a collaboration by id
+func (s *WatchlistService) loadCollaboration(collabID string) (*models.Collaboration, error) {
+	if collabID == "" || strings.ContainsAny(collabID, `/\.`) {
+		return nil, ErrCollabNotFound
+	}
+
+	data, err := ioutil.ReadFile(s.collabFilePath(collabID))
+	if os.IsNotExist(err) {
+		return nil, ErrCollabNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collaborative list: %w", err)
+	}
+
+	var collab models.Collaboration
+	if err := json.Unmarshal(data, &collab); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal collaborative list: %w", err)
+	}
+	return &collab, nil
+}
+
+// saveCollaboration writes a collaboration to file
+func (s *WatchlistService) saveCollaboration(collab *models.Collaboration) error {
+	data, err := json.MarshalIndent(collab, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal collaborative list: %w", err)
+	}
+	if err := ioutil.WriteFile(s.collabFilePath(collab.ID), data, 0644); err != nil {
+		return fmt.Errorf("failed to save collaborative list: %w", err)
+	}
+	return nil
+}
+
+// collabFilePath returns where a collaboration is stored
+func (s *WatchlistService) collabFilePath(collabID string) string {
+	return filepath.Join(s.dataDir, fmt.Sprintf("collab_%s.json", collabID))
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestCollaborationMembership(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+
+	_, err := s.CreateInvite("user", models.DefaultListID, models.InviteRequest{Role: models.RoleOwner})
+	assert.ErrorIs(t, err, ErrInvalidInput, "only editor and viewer invites can be made")
+	_, err = s.GetCollaboration("user", models.DefaultListID)
+	assert.ErrorIs(t, err, ErrCollabNotFound, "lists are not collaborative until someone is invited")
+
+	editorInvite, err := s.CreateInvite("user", models.DefaultListID, models.InviteRequest{Role: models.RoleEditor})
+	require.NoError(t, err)
+	viewerInvite, err := s.CreateInvite("user", models.DefaultListID, models.InviteRequest{Role: models.RoleViewer})
+	require.NoError(t, err)
+
+	_, err = s.AcceptInvite("alex", "unknown")
+	assert.ErrorIs(t, err, ErrInviteNotFound)
+	summary, err := s.AcceptInvite("alex", editorInvite.Token)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleEditor, summary.Role)
+	assert.Equal(t, 1, summary.ItemCount)
+	_, err = s.AcceptInvite("sam", editorInvite.Token)
+	assert.ErrorIs(t, err, ErrInviteNotFound, "invites can be used once")
+	_, err = s.AcceptInvite("sam", viewerInvite.Token)
+	require.NoError(t, err)
+
+	collab, err := s.GetCollaboration("user", models.DefaultListID)
+	require.NoError(t, err)
+	require.Len(t, collab.Members, 3)
+	assert.Equal(t, models.RoleOwner, collab.Members[0].Role)
+	assert.Empty(t, collab.Invites)
+
+	collabs, err := s.GetCollaborations("sam")
+	require.NoError(t, err)
+	require.Len(t, collabs, 1)
+	assert.Equal(t, summary.ID, collabs[0].ID)
+	collabs, err = s.GetCollaborations("nobody")
+	require.NoError(t, err)
+	assert.Empty(t, collabs)
+
+	member, err := s.UpdateMemberRole("user", models.DefaultListID, "sam", models.RoleEditor)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleEditor, member.Role)
+	_, err = s.UpdateMemberRole("user", models.DefaultListID, "user", models.RoleViewer)
+	assert.ErrorIs(t, err, ErrInvalidInput, "the owner keeps their role")
+	_, err = s.UpdateMemberRole("user", models.DefaultListID, "nobody", models.RoleViewer)
+	assert.ErrorIs(t, err, ErrMemberNotFound)
+	_, err = s.UpdateMemberRole("alex", models.DefaultListID, "sam", models.RoleViewer)
+	assert.ErrorIs(t, err, ErrCollabNotFound, "only the owner manages members")
+
+	require.NoError(t, s.RemoveMember("user", models.DefaultListID, "sam"))
+	_, err = s.GetCollaborativeList("sam", summary.ID)
+	assert.ErrorIs(t, err, ErrCollabNotFound)
+	assert.ErrorIs(t, s.LeaveCollaboration("user", summary.ID), ErrInvalidInput, "owners cannot leave")
+	require.NoError(t, s.LeaveCollaboration("alex", summary.ID))
+	_, err = s.GetCollaborativeList("alex", summary.ID)
+	assert.ErrorIs(t, err, ErrCollabNotFound)
+
+	// Expired invites cannot be accepted
+	soon := time.Now().Add(20 * time.Millisecond)
+	expiring, err := s.CreateInvite("user", models.DefaultListID, models.InviteRequest{Role: models.RoleViewer, ExpiresAt: &soon})
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	_, err = s.AcceptInvite("sam", expiring.Token)
+	assert.ErrorIs(t, err, ErrInviteExpired)
+}
+
+func TestCollaborativeEditing(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+		{ID: "movie_2", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Ronin"},
+	})
+	editorInvite, err := s.CreateInvite("user", models.DefaultListID, models.InviteRequest{Role: models.RoleEditor})
+	require.NoError(t, err)
+	viewerInvite, err := s.CreateInvite("user", models.DefaultListID, models.InviteRequest{Role: models.RoleViewer})
+	require.NoError(t, err)
+	summary, err := s.AcceptInvite("alex", editorInvite.Token)
+	require.NoError(t, err)
+	_, err = s.AcceptInvite("sam", viewerInvite.Token)
+	require.NoError(t, err)
+	collabID := summary.ID
+
+	// Viewers can look but not change anything
+	_, err = s.AddCollaborativeItem("sam", collabID, models.MediaTypeMovie, 3)
+	assert.ErrorIs(t, err, ErrCollabForbidden)
+	assert.ErrorIs(t, s.RemoveCollaborativeItem("sam", collabID, "movie_1"), ErrCollabForbidden)
+	_, err = s.MarkCollaborativeWatched("sam", collabID, "movie_1", models.WatchEventInput{})
+	assert.ErrorIs(t, err, ErrCollabForbidden)
+	_, err = s.AddCollaborativeItem("stranger", collabID, models.MediaTypeMovie, 3)
+	assert.ErrorIs(t, err, ErrCollabNotFound)
+
+	// Editors change the owner's list, and are credited for it
+	collab, _, err := s.memberCollaboration("alex", collabID)
+	require.NoError(t, err)
+	added := &models.WatchlistItem{MediaType: models.MediaTypeMovie, MovieID: 3, Title: "Thief"}
+	require.NoError(t, s.insertCollaborativeItem(collab, "alex", added))
+	assert.Equal(t, "alex", added.AddedBy)
+	require.NoError(t, s.RemoveCollaborativeItem("alex", collabID, "movie_2"))
+	owned, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	require.Len(t, owned.Items, 2)
+	assert.Equal(t, "alex", owned.Items[1].AddedBy)
+
+	// Watched state is kept per member
+	_, err = s.MarkCollaborativeWatched("alex", collabID, "movie_1", models.WatchEventInput{})
+	require.NoError(t, err)
+	_, err = s.MarkAsWatched("user", "movie_1", models.WatchEventInput{})
+	require.NoError(t, err)
+
+	list, err := s.GetCollaborativeList("alex", collabID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleEditor, list.Role)
+	require.Len(t, list.Items, 2)
+	assert.True(t, list.Items[0].IsWatched)
+	require.Len(t, list.Items[0].WatchedBy, 2)
+	assert.Equal(t, "user", list.Items[0].WatchedBy[0].UserID)
+	assert.Equal(t, "alex", list.Items[0].WatchedBy[1].UserID)
+	assert.Empty(t, list.Items[1].WatchedBy)
+
+	list, err = s.GetCollaborativeList("sam", collabID)
+	require.NoError(t, err)
+	assert.False(t, list.Items[0].IsWatched, "each member sees their own watched state")
+
+	require.NoError(t, s.MarkCollaborativeUnwatched("alex", collabID, "movie_1"))
+	list, err = s.GetCollaborativeList("alex", collabID)
+	require.NoError(t, err)
+	assert.False(t, list.Items[0].IsWatched)
+	assert.Len(t, list.Items[0].WatchedBy, 1)
+
+	activity, err := s.GetCollaborationActivity("sam", collabID)
+	require.NoError(t, err)
+	var actions []string
+	for _, entry := range activity {
+		assert.NotEmpty(t, entry.UserID)
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{
+		models.ActivityUnwatched, models.ActivityWatched, models.ActivityWatched, models.ActivityRemoved,
+		models.ActivityAdded, models.ActivityJoined, models.ActivityJoined,
+	}, actions)
+	assert.Equal(t, "user", activity[1].UserID, "the owner's changes through the list endpoints are attributed too")
+}
+
+func TestCollaborativeListHidesOwnerPersonalData(t *testing.T) {
+	s := newTVProgressService(t)
+	_, err := s.MarkEpisodeWatched("user", "tv_1", 1, 1, true)
+	require.NoError(t, err)
+	rating, review := 4.5, "Harrowing"
+	_, err = s.UpdatePersonalData("user", "tv_1", models.PersonalDataUpdate{Rating: &rating, Review: &review})
+	require.NoError(t, err)
+
+	invite, err := s.CreateInvite("user", models.DefaultListID, models.InviteRequest{Role: models.RoleEditor})
+	require.NoError(t, err)
+	summary, err := s.AcceptInvite("alex", invite.Token)
+	require.NoError(t, err)
+
+	list, err := s.GetCollaborativeList("alex", summary.ID)
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Nil(t, list.Items[0].Progress, "members don't see the owner's episode progress")
+	assert.Nil(t, list.Items[0].PersonalRating)
+	assert.Nil(t, list.Items[0].Review)
+
+	list, err = s.GetCollaborativeList("user", summary.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, list.Items[0].Progress, "the owner still sees their own")
+	assert.NotNil(t, list.Items[0].PersonalRating)
+	assert.NotNil(t, list.Items[0].Review)
+}
+
+func TestOwnerChangesAreAttributed(t *testing.T) {
+	s := withProvider(newTestService(t, nil), heatResponses)
+	invite, err := s.CreateInvite("user", models.DefaultListID, models.InviteRequest{Role: models.RoleViewer})
+	require.NoError(t, err)
+	summary, err := s.AcceptInvite("alex", invite.Token)
+	require.NoError(t, err)
+
+	item, err := s.AddToWatchlist("user", models.MediaTypeMovie, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "user", item.AddedBy)
+	require.NoError(t, s.RemoveFromWatchlist("user", item.ID))
+
+	activity, err := s.GetCollaborationActivity("alex", summary.ID)
+	require.NoError(t, err)
+	require.Len(t, activity, 3)
+	assert.Equal(t, models.ActivityRemoved, activity[0].Action)
+	assert.Equal(t, models.ActivityAdded, activity[1].Action)
+	assert.Equal(t, "user", activity[1].UserID)
+	assert.Equal(t, "Heat", activity[1].Title)
+
+	// Lists that are not collaborative credit no one
+	name := "Solo"
+	solo, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+	item, err = s.AddToList("user", solo.ID, models.MediaTypeMovie, 1)
+	require.NoError(t, err)
+	assert.Empty(t, item.AddedBy)
+}
+
+func TestDeletingListEndsCollaboration(t *testing.T) {
+	s := newTestService(t, nil)
+	name := "Movie night"
+	list, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+	invite, err := s.CreateInvite("user", list.ID, models.InviteRequest{Role: models.RoleEditor})
+	require.NoError(t, err)
+	summary, err := s.AcceptInvite("alex", invite.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "Movie night", summary.Name)
+
+	require.NoError(t, s.DeleteList("user", list.ID))
+	_, err = s.GetCollaborativeList("alex", summary.ID)
+	assert.ErrorIs(t, err, ErrCollabNotFound)
+	collabs, err := s.GetCollaborations("alex")
+	require.NoError(t, err)
+	assert.Empty(t, collabs)
+}
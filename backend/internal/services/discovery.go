@@ -0,0 +1,223 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+const (
+	// defaultDiscoveryPageSize is the page size of the discovery feed when
+	// no limit is given
+	defaultDiscoveryPageSize = 20
+	// listingPosters is how many posters a listing previews
+	listingPosters = 4
+)
+
+// discoveryCursor marks the last listing of a page by its sort values
+type discoveryCursor struct {
+	Sort       string    `json:"s"`
+	Popularity int       `json:"p"`
+	CreatedAt  time.Time `json:"c"`
+	Token      string    `json:"t"`
+}
+
+// DiscoverShares lists public shares for the discovery feed: shares that
+// are active, not password protected and not unlisted by a moderator.
+// Recent shares come first unless sorted by popularity, which counts both
+// views and clones.
+func (s *WatchlistService) DiscoverShares(query models.DiscoveryQuery) (*models.DiscoveryPage, error) {
+	if query.Sort == "" {
+		query.Sort = models.DiscoverRecent
+	}
+	if query.Sort != models.DiscoverRecent && query.Sort != models.DiscoverPopular {
+		return nil, invalidf("invalid sort %q, use %s or %s", query.Sort, models.DiscoverRecent, models.DiscoverPopular)
+	}
+	if query.Limit < 0 {
+		return nil, invalidf("limit cannot be negative")
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultDiscoveryPageSize
+	}
+	if query.Limit > maxPageSize {
+		query.Limit = maxPageSize
+	}
+
+	shares, err := s.loadShares()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	text := strings.ToLower(strings.TrimSpace(query.Text))
+	listings := []models.SharedListing{}
+	for i := range shares {
+		share := &shares[i]
+		if !share.IsPublic || share.PasswordHash != "" || share.UnlistedAt != nil || shareStatus(share, now) != models.ShareActive {
+			continue
+		}
+		if query.Featured && !share.Featured {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(share.Title), text) && !strings.Contains(strings.ToLower(share.Description), text) {
+			continue
+		}
+		if _, err := s.resolveShare(share); err != nil {
+			continue
+		}
+		listings = append(listings, s.sharedListing(share))
+	}
+
+	compare := func(a, b *models.SharedListing) int {
+		if query.Sort == models.DiscoverPopular {
+			if c := compareInts(listingPopularity(b), listingPopularity(a)); c != 0 {
+				return c
+			}
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			if a.CreatedAt.After(b.CreatedAt) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.ShareToken, b.ShareToken)
+	}
+	sort.SliceStable(listings, func(i, j int) bool {
+		return compare(&listings[i], &listings[j]) < 0
+	})
+	total := len(listings)
+
+	if query.Cursor != "" {
+		cursor, err := decodeDiscoveryCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != query.Sort {
+			return nil, invalidf("cursor does not match the requested sort order")
+		}
+		last := &models.SharedListing{ShareToken: cursor.Token, CreatedAt: cursor.CreatedAt, ViewCount: cursor.Popularity}
+		start := sort.Search(len(listings), func(i int) bool {
+			return compare(&listings[i], last) > 0
+		})
+		listings = listings[start:]
+	}
+
+	page := &models.DiscoveryPage{Total: total}
+	if len(listings) > query.Limit {
+		listings = listings[:query.Limit]
+		last := listings[len(listings)-1]
+		next, err := encodeDiscoveryCursor(discoveryCursor{
+			Sort:       query.Sort,
+			Popularity: listingPopularity(&last),
+			CreatedAt:  last.CreatedAt,
+			Token:      last.ShareToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		page.Next = next
+	}
+	page.Listings = listings
+	return page, nil
+}
+
+// ModerateShare features a share or unlists it. An unlisted share leaves the
+// discovery feed and only its creator can still open it. Moderators find
+// shares through the feed, so they are identified by token.
+func (s *WatchlistService) ModerateShare(shareToken string, update models.ModerationUpdate) (*models.ShareableWatchlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shares, err := s.loadShares()
+	if err != nil {
+		return nil, err
+	}
+	for i := range shares {
+		share := &shares[i]
+		if share.ShareToken != shareToken {
+			continue
+		}
+
+		if update.Featured != nil {
+			share.Featured = *update.Featured
+		}
+		if update.Unlisted != nil {
+			if *update.Unlisted {
+				if share.UnlistedAt == nil {
+					now := time.Now()
+					share.UnlistedAt = &now
+				}
+				share.UnlistReason = update.Reason
+				share.Featured = false
+			} else {
+				share.UnlistedAt = nil
+				share.UnlistReason = ""
+			}
+		}
+		if err := s.saveShare(share); err != nil {
+			return nil, err
+		}
+		return s.ownerShare(share, time.Now()), nil
+	}
+	return nil, ErrShareNotFound
+}
+
+// sharedListing describes a resolved share for the discovery feed
+func (s *WatchlistService) sharedListing(share *models.ShareableWatchlist) models.SharedListing {
+	listing := models.SharedListing{
+		ShareToken:  share.ShareToken,
+		Title:       share.Title,
+		Description: share.Description,
+		DisplayName: share.DisplayName,
+		Mode:        share.Mode,
+		ItemCount:   len(share.Items),
+		Featured:    share.Featured,
+		CreatedAt:   share.CreatedAt,
+		UpdatedAt:   share.UpdatedAt,
+	}
+	for _, item := range share.Items {
+		if len(listing.PosterPaths) == listingPosters {
+			break
+		}
+		if item.PosterPath != "" {
+			listing.PosterPaths = append(listing.PosterPaths, item.PosterPath)
+		}
+	}
+	if log, err := s.loadShareAccessLog(share.ID); err == nil {
+		listing.ViewCount = log.ViewCount
+		listing.CloneCount = log.CloneCount
+	}
+	return listing
+}
+
+// listingPopularity ranks listings for the popular sort order
+func listingPopularity(listing *models.SharedListing) int {
+	return listing.ViewCount + listing.CloneCount
+}
+
+// encodeDiscoveryCursor serializes a cursor into an opaque URL-safe token
+func encodeDiscoveryCursor(cursor discoveryCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeDiscoveryCursor parses a token produced by encodeDiscoveryCursor
+func decodeDiscoveryCursor(token string) (*discoveryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalidf("invalid cursor")
+	}
+	var cursor discoveryCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, invalidf("invalid cursor")
+	}
+	return &cursor, nil
+}
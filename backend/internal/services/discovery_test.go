@@ -0,0 +1,140 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestDiscoverShares(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", PosterPath: "/heat.jpg"},
+	})
+
+	create := func(req models.ShareRequest) *models.ShareableWatchlist {
+		share, err := s.CreateShareableWatchlist("user", req)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond) // distinct creation times
+		return share
+	}
+	noir := create(models.ShareRequest{Title: "Noir nights", IsPublic: true, DisplayName: "Film Fan"})
+	heist := create(models.ShareRequest{Title: "Heists", Description: "Crime and NOIR capers", IsPublic: true})
+	comedy := create(models.ShareRequest{Title: "Comedy", IsPublic: true, Mode: models.ShareModeLive})
+	create(models.ShareRequest{Title: "Private noir"})
+	create(models.ShareRequest{Title: "Locked noir", IsPublic: true, Password: "secret"})
+	revoked := create(models.ShareRequest{Title: "Old noir", IsPublic: true})
+	_, err := s.RevokeShare("user", revoked.ID)
+	require.NoError(t, err)
+
+	page, err := s.DiscoverShares(models.DiscoveryQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.Total, "private, protected and revoked shares are not listed")
+	require.Len(t, page.Listings, 3)
+	assert.Equal(t, comedy.ShareToken, page.Listings[0].ShareToken, "recent shares come first")
+	assert.Equal(t, "Film Fan", page.Listings[2].DisplayName)
+	assert.Equal(t, 1, page.Listings[2].ItemCount)
+	assert.Equal(t, []string{"/heat.jpg"}, page.Listings[2].PosterPaths)
+
+	page, err = s.DiscoverShares(models.DiscoveryQuery{Text: "noir"})
+	require.NoError(t, err)
+	require.Len(t, page.Listings, 2, "search matches titles and descriptions")
+	assert.Equal(t, heist.ShareToken, page.Listings[0].ShareToken)
+
+	// Views and clones make a share popular
+	for i := 0; i < 2; i++ {
+		_, err = s.GetSharedWatchlist(noir.ShareToken, models.ShareViewer{})
+		require.NoError(t, err)
+	}
+	_, err = s.CloneSharedWatchlist(heist.ShareToken, models.ShareViewer{UserID: "alex"}, models.CloneRequest{})
+	require.NoError(t, err)
+	page, err = s.DiscoverShares(models.DiscoveryQuery{Sort: models.DiscoverPopular})
+	require.NoError(t, err)
+	require.Len(t, page.Listings, 3)
+	assert.Equal(t, noir.ShareToken, page.Listings[0].ShareToken)
+	assert.Equal(t, 2, page.Listings[0].ViewCount)
+	assert.Equal(t, heist.ShareToken, page.Listings[1].ShareToken)
+	assert.Equal(t, 1, page.Listings[1].CloneCount)
+
+	_, err = s.DiscoverShares(models.DiscoveryQuery{Sort: "random"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestDiscoverSharesPagination(t *testing.T) {
+	s := newTestService(t, nil)
+	for i := 0; i < 5; i++ {
+		_, err := s.CreateShareableWatchlist("user", models.ShareRequest{Title: "List", IsPublic: true})
+		require.NoError(t, err)
+	}
+
+	for _, sortOrder := range []string{models.DiscoverRecent, models.DiscoverPopular} {
+		seen := map[string]bool{}
+		query := models.DiscoveryQuery{Sort: sortOrder, Limit: 2}
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 5)
+			page, err := s.DiscoverShares(query)
+			require.NoError(t, err)
+			assert.Equal(t, 5, page.Total)
+			for _, listing := range page.Listings {
+				assert.False(t, seen[listing.ShareToken], "pages never overlap")
+				seen[listing.ShareToken] = true
+			}
+			if page.Next == "" {
+				break
+			}
+			query.Cursor = page.Next
+		}
+		assert.Len(t, seen, 5)
+	}
+
+	_, err := s.DiscoverShares(models.DiscoveryQuery{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestModerateShare(t *testing.T) {
+	s := newTestService(t, nil)
+	share, err := s.CreateShareableWatchlist("user", models.ShareRequest{Title: "Spam", IsPublic: true})
+	require.NoError(t, err)
+	other, err := s.CreateShareableWatchlist("user", models.ShareRequest{Title: "Classics", IsPublic: true})
+	require.NoError(t, err)
+
+	featured := true
+	_, err = s.ModerateShare(other.ShareToken, models.ModerationUpdate{Featured: &featured})
+	require.NoError(t, err)
+	page, err := s.DiscoverShares(models.DiscoveryQuery{Featured: true})
+	require.NoError(t, err)
+	require.Len(t, page.Listings, 1)
+	assert.Equal(t, other.ShareToken, page.Listings[0].ShareToken)
+	assert.True(t, page.Listings[0].Featured)
+
+	unlisted := true
+	moderated, err := s.ModerateShare(share.ShareToken, models.ModerationUpdate{Unlisted: &unlisted, Reason: "spam"})
+	require.NoError(t, err)
+	assert.NotNil(t, moderated.UnlistedAt)
+	assert.Equal(t, "spam", moderated.UnlistReason)
+	assert.Empty(t, moderated.PasswordHash)
+
+	page, err = s.DiscoverShares(models.DiscoveryQuery{})
+	require.NoError(t, err)
+	require.Len(t, page.Listings, 1, "unlisted shares leave the feed")
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{})
+	assert.ErrorIs(t, err, ErrShareGone, "and can no longer be opened by link")
+	_, err = s.CloneSharedWatchlist(share.ShareToken, models.ShareViewer{UserID: "alex"}, models.CloneRequest{})
+	assert.ErrorIs(t, err, ErrShareGone)
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{UserID: "user"})
+	assert.NoError(t, err, "except by their creator")
+
+	unlisted = false
+	_, err = s.ModerateShare(share.ShareToken, models.ModerationUpdate{Unlisted: &unlisted})
+	require.NoError(t, err)
+	page, err = s.DiscoverShares(models.DiscoveryQuery{})
+	require.NoError(t, err)
+	assert.Len(t, page.Listings, 2)
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{})
+	assert.NoError(t, err)
+
+	_, err = s.ModerateShare("unknown", models.ModerationUpdate{Featured: &featured})
+	assert.ErrorIs(t, err, ErrShareNotFound)
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"bytes"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// ExportData builds a full-fidelity export of one of a user's lists, including
+// the watch events of its titles
+func (s *WatchlistService) ExportData(userID, listID string) (*models.WatchlistExport, error) {
+	watchlist, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.loadWatchHistory(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WatchlistExport{
+		ExportSchemaVersion: models.ExportSchemaVersion,
+		Generator:           models.ExportGenerator,
+		ExportedAt:          time.Now().UTC(),
+		Watchlist:           watchlist,
+		WatchEvents:         listWatchEvents(watchlist, history),
+	}, nil
+}
+
+// RestoreExport replaces a list's details and items with those of an export,
+// keeping item ids, ranks and personal data as they were exported. Ranks that
+// don't fit the rank format are replaced, keeping the items' order. Items whose
+// id is taken by one of the user's other lists get a new one, since item ids
+// are unique across a user's lists. Exported watch events are merged into the
+// user's history by id. The list keeps its own id, owner and position.
+// Items of the list whose title is not in the export go to the trash, and
+// their number is returned. With dryRun set the export is only validated.
+func (s *WatchlistService) RestoreExport(userID, listID string, export *models.WatchlistExport, dryRun bool) (*models.Watchlist, int, error) {
+	if err := validateExport(export); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, 0, err
+	}
+	history, err := s.loadWatchHistory(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The original list is also what is put back if a later save fails
+	original, err := s.GetList(userID, list.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	exported := make(map[titleKey]bool, len(export.Watchlist.Items))
+	for _, item := range export.Watchlist.Items {
+		exported[titleKey{item.MediaType, item.MovieID}] = true
+	}
+	var dropped []models.WatchlistItem
+	for _, item := range original.Items {
+		if !exported[titleKey{item.MediaType, item.MovieID}] {
+			dropped = append(dropped, item)
+		}
+	}
+
+	list.Name = export.Watchlist.Name
+	list.Description = export.Watchlist.Description
+	list.Icon = export.Watchlist.Icon
+	list.Items = append([]models.WatchlistItem{}, export.Watchlist.Items...)
+	if err := s.renewTakenItemIDs(userID, list); err != nil {
+		return nil, 0, err
+	}
+
+	index := make(map[string]int, len(history.Events))
+	for i, event := range history.Events {
+		index[event.ID] = i
+	}
+	for _, event := range export.WatchEvents {
+		if i, ok := index[event.ID]; ok {
+			history.Events[i] = event
+		} else {
+			index[event.ID] = len(history.Events)
+			history.Events = append(history.Events, event)
+		}
+	}
+
+	// Ranks written outside the service may not fit the rank format; the
+	// items then keep their order but are ranked again
+	sortByRank(list.Items)
+	if !ranksUsable(list.Items) {
+		rerank(list.Items)
+	}
+	applyWatchHistory(list, history)
+	if dryRun {
+		return list, len(dropped), nil
+	}
+
+	// The trash is saved first, and each save is undone if a later one fails
+	previousTrash, err := s.moveToTrash(userID, original, dropped...)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	list.UpdatedAt = now
+	if err := s.writeWatchlist(list); err != nil {
+		s.restoreTrash(previousTrash)
+		return nil, 0, err
+	}
+	history.UpdatedAt = now
+	if err := s.saveWatchHistory(history); err != nil {
+		if restoreErr := s.writeWatchlist(original); restoreErr != nil {
+			s.logger.Error("Failed to roll back restore of list %s for user %s: %v", list.ID, userID, restoreErr)
+		}
+		s.restoreTrash(previousTrash)
+		return nil, 0, err
+	}
+	// The version is only recorded once the restore is sure to stay
+	s.saveVersion(list)
+
+	s.logger.Success("Restored export of %d item(s) into list %s for user %s, %d moved to the trash", len(list.Items), list.ID, userID, len(dropped))
+	return list, len(dropped), nil
+}
+
+// renewTakenItemIDs gives the items of list that share an id with an item in
+// another of the user's lists a new id. The caller must hold the service lock.
+func (s *WatchlistService) renewTakenItemIDs(userID string, list *models.Watchlist) error {
+	lists, err := s.userLists(userID)
+	if err != nil {
+		return err
+	}
+	taken := make(map[string]bool)
+	for _, other := range lists {
+		if other.ID == list.ID {
+			continue
+		}
+		for _, item := range other.Items {
+			taken[item.ID] = true
+		}
+	}
+	for i := range list.Items {
+		if taken[list.Items[i].ID] {
+			list.Items[i].ID = s.generateID()
+		}
+	}
+	return nil
+}
+
+// importExport restores one of our own JSON or NDJSON exports through the import endpoint
+func (s *WatchlistService) importExport(userID, listID, format string, data []byte, dryRun bool) (*models.ImportReport, error) {
+	var export *models.WatchlistExport
+	var err error
+	if format == models.ImportFormatNDJSON {
+		export, err = decodeNDJSONExport(bytes.NewReader(data))
+	} else {
+		export, err = decodeJSONExport(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list, trashed, err := s.RestoreExport(userID, listID, export, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &models.ImportReport{
+		ID:        s.generateID(),
+		UserID:    userID,
+		ListID:    list.ID,
+		Format:    format,
+		Mode:      models.ImportModeReplace,
+		DryRun:    dryRun,
+		Matched:   len(list.Items),
+		Trashed:   trashed,
+		Rows:      []models.ImportRowResult{},
+		CreatedAt: now,
+		ExpiresAt: now,
+	}
+	if !dryRun {
+		report.Imported = len(list.Items)
+	}
+	return report, nil
+}
+
+// validateExport checks that an export can be restored without corrupting a list
+func validateExport(export *models.WatchlistExport) error {
+	if export == nil || export.Watchlist == nil {
+		return invalidf("export has no watchlist")
+	}
+	if err := checkExportVersion(export.ExportSchemaVersion); err != nil {
+		return err
+	}
+
+	ids := make(map[string]bool)
+	titles := make(map[titleKey]bool)
+	for _, item := range export.Watchlist.Items {
+		if item.ID == "" {
+			return invalidf("export contains an item without an id")
+		}
+		if item.MediaType != models.MediaTypeMovie && item.MediaType != models.MediaTypeTV {
+			return invalidf("item %s has invalid media type %q", item.ID, item.MediaType)
+		}
+		key := titleKey{item.MediaType, item.MovieID}
+		if ids[item.ID] || titles[key] {
+			return invalidf("export contains item %s more than once", item.ID)
+		}
+		ids[item.ID] = true
+		titles[key] = true
+	}
+
+	for _, event := range export.WatchEvents {
+		if event.ID == "" {
+			return invalidf("export contains a watch event without an id")
+		}
+	}
+	return nil
+}
+
+// listWatchEvents returns the history events for the titles in a list, in stored order
+func listWatchEvents(watchlist *models.Watchlist, history *models.WatchHistory) []models.WatchEvent {
+	titles := make(map[titleKey]bool, len(watchlist.Items))
+	for _, item := range watchlist.Items {
+		titles[titleKey{item.MediaType, item.MovieID}] = true
+	}
+
+	events := []models.WatchEvent{}
+	for _, event := range history.Events {
+		if titles[titleKey{event.MediaType, event.MovieID}] {
+			events = append(events, event)
+		}
+	}
+	return events
+}
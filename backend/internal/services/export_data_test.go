@@ -0,0 +1,331 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+	"r.a.w/backend/pkg/logger"
+)
+
+// randomExport builds an arbitrary but valid export from a seed. Derived
+// watched state is computed from the generated events, as the service does.
+func randomExport(seed int64) *models.WatchlistExport {
+	r := rand.New(rand.NewSource(seed))
+
+	randomString := func() string {
+		alphabet := []rune("abcXYZ 019 \"'<>&\\/\n\t,;é漢🎬")
+		runes := make([]rune, r.Intn(20))
+		for i := range runes {
+			runes[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		return string(runes)
+	}
+	randomTime := func() time.Time {
+		return time.Unix(r.Int63n(2e9), r.Int63n(1e9)).UTC()
+	}
+	randomRating := func() *models.PersonalRating {
+		if r.Intn(2) == 0 {
+			return nil
+		}
+		return &models.PersonalRating{Value: float64(1+r.Intn(10)) / 2, Scale: models.RatingScaleFive, RatedAt: randomTime()}
+	}
+	optionalTime := func() *time.Time {
+		if r.Intn(2) == 0 {
+			return nil
+		}
+		t := randomTime()
+		return &t
+	}
+
+	watchlist := &models.Watchlist{
+		SchemaVersion: models.CurrentWatchlistSchemaVersion,
+		ID:            models.DefaultListID,
+		UserID:        "user",
+		Name:          "list " + randomString(), // the default list replaces an empty name
+		Description:   randomString(),
+		Icon:          randomString(),
+		IsDefault:     true,
+		Items:         []models.WatchlistItem{},
+		CreatedAt:     randomTime(),
+		UpdatedAt:     randomTime(),
+	}
+	history := &models.WatchHistory{UserID: "user", Events: []models.WatchEvent{}}
+
+	rank := ""
+	priorities := []string{"", models.PriorityHigh, models.PriorityMedium, models.PriorityLow}
+	for i, n := 0, r.Intn(15); i < n; i++ {
+		rank = rankAfter(rank)
+		item := models.WatchlistItem{
+			ID:                fmt.Sprintf("item-%d", i),
+			MediaType:         models.MediaTypeMovie,
+			MovieID:           i + 1,
+			Title:             randomString(),
+			PosterPath:        randomString(),
+			ReleaseDate:       randomString(),
+			Genre:             randomString(),
+			Rating:            r.Float64() * 10,
+			Overview:          randomString(),
+			AddedAt:           randomTime(),
+			UserNotes:         randomString(),
+			Rank:              rank,
+			Priority:          priorities[r.Intn(len(priorities))],
+			PersonalRating:    randomRating(),
+			IMDbID:            randomString(),
+			IMDbRating:        r.Float64() * 10,
+			Runtime:           r.Intn(300),
+			MetadataUpdatedAt: optionalTime(),
+		}
+		for j, tags := 0, r.Intn(3); j < tags; j++ {
+			item.Tags = append(item.Tags, randomString()+"x")
+		}
+		if r.Intn(2) == 0 {
+			item.Review = &models.Review{Text: randomString(), CreatedAt: randomTime(), UpdatedAt: randomTime()}
+			if r.Intn(2) == 0 {
+				item.Review.History = []models.ReviewRevision{{Text: randomString(), EditedAt: randomTime()}}
+			}
+		}
+		if r.Intn(2) == 0 {
+			item.MediaType = models.MediaTypeTV
+			item.NumberOfSeasons = 1 + r.Intn(5)
+			item.NumberOfEpisodes = 1 + r.Intn(50)
+			item.Progress = &models.TVProgress{
+				UpdatedAt: randomTime(),
+				Seasons: []models.SeasonProgress{{
+					SeasonNumber: 1,
+					Name:         randomString(),
+					Episodes: []models.EpisodeProgress{
+						{EpisodeNumber: 1, Name: randomString(), AirDate: randomString(), Watched: true, WatchedAt: optionalTime()},
+						{EpisodeNumber: 2, Name: randomString()},
+					},
+				}},
+			}
+		}
+		watchlist.Items = append(watchlist.Items, item)
+
+		for j, watches := 0, r.Intn(3); j < watches; j++ {
+			history.Events = append(history.Events, models.WatchEvent{
+				ID:        fmt.Sprintf("event-%d-%d", i, j),
+				ItemID:    item.ID,
+				MediaType: item.MediaType,
+				MovieID:   item.MovieID,
+				Title:     item.Title,
+				WatchedAt: randomTime(),
+				Rating:    randomRating(),
+				Notes:     randomString(),
+				Platform:  randomString(),
+				CreatedAt: randomTime(),
+				UpdatedAt: randomTime(),
+			})
+		}
+	}
+	applyWatchHistory(watchlist, history)
+
+	return &models.WatchlistExport{
+		ExportSchemaVersion: models.ExportSchemaVersion,
+		Generator:           models.ExportGenerator,
+		ExportedAt:          randomTime(),
+		Watchlist:           watchlist,
+		WatchEvents:         history.Events,
+	}
+}
+
+func newTestExportService(t testing.TB) *ExportService {
+	appLogger, err := logger.NewLogger(filepath.Join(t.TempDir(), "export.log"))
+	require.NoError(t, err)
+	t.Cleanup(appLogger.Close)
+	return NewExportService(appLogger)
+}
+
+func TestJSONExportRoundTrip(t *testing.T) {
+	exporter := newTestExportService(t)
+
+	roundTrip := func(seed int64) bool {
+		export := randomExport(seed)
+		var buf bytes.Buffer
+		if err := exporter.ExportToJSON(&buf, export); err != nil {
+			t.Log(err)
+			return false
+		}
+		decoded, err := decodeJSONExport(buf.Bytes())
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		return reflect.DeepEqual(export, decoded)
+	}
+	assert.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 200}))
+}
+
+func TestNDJSONExportRoundTrip(t *testing.T) {
+	exporter := newTestExportService(t)
+
+	roundTrip := func(seed int64) bool {
+		export := randomExport(seed)
+		var buf bytes.Buffer
+		if err := exporter.ExportToNDJSON(&buf, export); err != nil {
+			t.Log(err)
+			return false
+		}
+		decoded, err := decodeNDJSONExport(&buf)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		return reflect.DeepEqual(export, decoded)
+	}
+	assert.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 200}))
+}
+
+func TestRestoreExportRoundTrip(t *testing.T) {
+	roundTrip := func(seed int64) bool {
+		s := newTestService(t, nil)
+		export := randomExport(seed)
+
+		if _, _, err := s.RestoreExport("user", "", export, false); err != nil {
+			t.Log(err)
+			return false
+		}
+		restored, err := s.ExportData("user", "")
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+
+		return restored.Watchlist.Name == export.Watchlist.Name &&
+			restored.Watchlist.Description == export.Watchlist.Description &&
+			restored.Watchlist.Icon == export.Watchlist.Icon &&
+			reflect.DeepEqual(export.Watchlist.Items, restored.Watchlist.Items) &&
+			reflect.DeepEqual(export.WatchEvents, restored.WatchEvents)
+	}
+	assert.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 50}))
+}
+
+func TestRestoreExportReranksForeignRanks(t *testing.T) {
+	s := newTestService(t, nil)
+	export := &models.WatchlistExport{
+		ExportSchemaVersion: models.ExportSchemaVersion,
+		Watchlist: &models.Watchlist{Name: "Edited", Items: []models.WatchlistItem{
+			{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Ronin", Rank: "B"},
+			{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", Rank: "A"},
+			{ID: "c", MediaType: models.MediaTypeMovie, MovieID: 3, Title: "Thief", Rank: "B"},
+		}},
+	}
+
+	_, _, err := s.RestoreExport("user", "", export, false)
+	require.NoError(t, err)
+	restored, err := s.ExportData("user", "")
+	require.NoError(t, err)
+	items := restored.Watchlist.Items
+	require.Len(t, items, 3)
+	assert.Equal(t, []string{"a", "b", "c"}, []string{items[0].ID, items[1].ID, items[2].ID}, "the order of the ranks is kept")
+	assert.True(t, ranksUsable(items))
+
+	item, err := s.ReorderItem("user", "", "c", models.ReorderRequest{After: "a"})
+	require.NoError(t, err)
+	assert.Greater(t, item.Rank, items[0].Rank)
+	assert.Less(t, item.Rank, items[1].Rank)
+}
+
+func TestRestoreExportRenewsTakenItemIDs(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien", UserNotes: "original"},
+	})
+	export, err := s.ExportData("user", "")
+	require.NoError(t, err)
+
+	name := "Copy"
+	copyList, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+	restored, _, err := s.RestoreExport("user", copyList.ID, export, false)
+	require.NoError(t, err)
+	require.Len(t, restored.Items, 1)
+	assert.NotEqual(t, "a", restored.Items[0].ID, "the id is taken by the default list")
+
+	list, item, err := s.findUserListItem("user", "a")
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultListID, list.ID)
+	assert.Equal(t, "original", item.UserNotes)
+
+	// Restoring into the list the export came from keeps the ids
+	restored, _, err = s.RestoreExport("user", "", export, false)
+	require.NoError(t, err)
+	assert.Equal(t, "a", restored.Items[0].ID)
+}
+
+func TestRestoreExportRollsBackListWhenHistoryFails(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien"},
+	})
+	export := &models.WatchlistExport{
+		ExportSchemaVersion: models.ExportSchemaVersion,
+		Watchlist: &models.Watchlist{Name: "Restored", Items: []models.WatchlistItem{
+			{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Heat"},
+		}},
+		WatchEvents: []models.WatchEvent{{ID: "e1", MediaType: models.MediaTypeMovie, MovieID: 2, WatchedAt: time.Now()}},
+	}
+
+	// A directory in the way of the history's temporary file makes its save fail
+	require.NoError(t, os.Mkdir(filepath.Join(s.dataDir, "watch_history_user.json.tmp"), 0755))
+	_, _, err := s.RestoreExport("user", "", export, false)
+	require.Error(t, err)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	require.Len(t, watchlist.Items, 1)
+	assert.Equal(t, "a", watchlist.Items[0].ID, "the list is put back as it was")
+	assert.NotEqual(t, "Restored", watchlist.Name)
+	trash, err := s.GetTrash("user")
+	require.NoError(t, err)
+	assert.Empty(t, trash, "the replaced item leaves the trash again")
+	versions, err := s.GetVersions("user", "")
+	require.NoError(t, err)
+	assert.Len(t, versions, 1, "the rolled back restore is not a version")
+}
+
+func TestImportExportTrashesReplacedItems(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien"},
+		{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Heat"},
+	})
+	export, err := s.ExportData("user", "")
+	require.NoError(t, err)
+	export.Watchlist.Items = export.Watchlist.Items[1:]
+	var buf bytes.Buffer
+	require.NoError(t, newTestExportService(t).ExportToJSON(&buf, export))
+
+	report, err := s.ImportWatchlist("user", "", models.ImportFormatJSON, buf.Bytes(), true)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportModeReplace, report.Mode)
+	assert.Equal(t, 1, report.Trashed)
+	trash, err := s.GetTrash("user")
+	require.NoError(t, err)
+	assert.Empty(t, trash, "a dry run only counts")
+
+	report, err = s.ImportWatchlist("user", "", models.ImportFormatJSON, buf.Bytes(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Trashed)
+	trash, err = s.GetTrash("user")
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, "a", trash[0].Item.ID, "the item left out of the file can be restored")
+	_, err = s.RestoreItem("user", "a")
+	assert.NoError(t, err)
+}
+
+func TestDecodeExportRejectsUnknownVersion(t *testing.T) {
+	_, err := decodeJSONExport([]byte(`{"export_schema_version": 99, "watchlist": {"items": []}}`))
+	assert.Error(t, err)
+
+	_, err = decodeNDJSONExport(bytes.NewReader([]byte(`{"type":"item","item":{"id":"a"}}`)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// ReportTemplateVersion is the version of the HTML report template. Bump it
+// and add templates/report.v<N>.html when the markup changes incompatibly, so
+// older templates stay available for comparison.
+const ReportTemplateVersion = 1
+
+//go:embed templates/*.html
+var reportTemplates embed.FS
+
+var reportTemplate = template.Must(
+	template.New(fmt.Sprintf("report.v%d.html", ReportTemplateVersion)).
+		Funcs(template.FuncMap{
+			"rating": func(rating float64) string { return fmt.Sprintf("%.1f", rating) },
+			"date":   reportLocales["en"].formatDate,
+		}).
+		ParseFS(reportTemplates, fmt.Sprintf("templates/report.v%d.html", ReportTemplateVersion)),
+)
+
+// Report themes
+const (
+	ThemeLight = "light"
+	ThemeDark  = "dark"
+	ThemePrint = "print"
+)
+
+// HTMLOptions controls the look and language of the HTML report
+type HTMLOptions struct {
+	// Theme is light (the default), dark or print
+	Theme string
+	// Locale is a language tag such as "es" or "fr-CA"; unsupported languages fall back to English
+	Locale string
+}
+
+// reportLabels holds the translated text of the HTML report. Entries ending
+// in a verb are fmt formats.
+type reportLabels struct {
+	Title           string
+	GeneratedOn     string
+	Statistics      string
+	TotalItems      string
+	Watched         string
+	ToWatch         string
+	AvgRating       string
+	MoviesWatched   string
+	ShowsWatched    string
+	TopGenres       string
+	Movies          string
+	TVShows         string
+	WatchedOn       string
+	SeasonsEpisodes string
+	Tags            string
+	Notes           string
+	// DateFormat receives the day, month name and year, in that order
+	DateFormat string
+	Months     [12]string
+}
+
+var reportLocales = map[string]reportLabels{
+	"en": {
+		Title:           "My Watchlist Report",
+		GeneratedOn:     "Generated on %s",
+		Statistics:      "Statistics",
+		TotalItems:      "Total Items",
+		Watched:         "Watched",
+		ToWatch:         "To Watch",
+		AvgRating:       "Avg Rating",
+		MoviesWatched:   "Movies (%d watched)",
+		ShowsWatched:    "TV Shows (%d watched)",
+		TopGenres:       "Top Genres",
+		Movies:          "Movies",
+		TVShows:         "TV Shows",
+		WatchedOn:       "on %s",
+		SeasonsEpisodes: "%d seasons • %d episodes",
+		Tags:            "Tags",
+		Notes:           "Notes",
+		DateFormat:      "%[2]s %[1]d, %[3]d",
+		Months:          [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	},
+	"es": {
+		Title:           "Informe de mi lista",
+		GeneratedOn:     "Generado el %s",
+		Statistics:      "Estadísticas",
+		TotalItems:      "Total",
+		Watched:         "Visto",
+		ToWatch:         "Por ver",
+		AvgRating:       "Valoración media",
+		MoviesWatched:   "Películas (%d vistas)",
+		ShowsWatched:    "Series (%d vistas)",
+		TopGenres:       "Géneros principales",
+		Movies:          "Películas",
+		TVShows:         "Series",
+		WatchedOn:       "el %s",
+		SeasonsEpisodes: "%d temporadas • %d episodios",
+		Tags:            "Etiquetas",
+		Notes:           "Notas",
+		DateFormat:      "%[1]d de %[2]s de %[3]d",
+		Months:          [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	},
+	"fr": {
+		Title:           "Rapport de ma liste",
+		GeneratedOn:     "Généré le %s",
+		Statistics:      "Statistiques",
+		TotalItems:      "Total",
+		Watched:         "Vu",
+		ToWatch:         "À voir",
+		AvgRating:       "Note moyenne",
+		MoviesWatched:   "Films (%d vus)",
+		ShowsWatched:    "Séries (%d vues)",
+		TopGenres:       "Genres principaux",
+		Movies:          "Films",
+		TVShows:         "Séries",
+		WatchedOn:       "le %s",
+		SeasonsEpisodes: "%d saisons • %d épisodes",
+		Tags:            "Étiquettes",
+		Notes:           "Notes",
+		DateFormat:      "%[1]d %[2]s %[3]d",
+		Months:          [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+	},
+	"de": {
+		Title:           "Mein Watchlist-Bericht",
+		GeneratedOn:     "Erstellt am %s",
+		Statistics:      "Statistiken",
+		TotalItems:      "Gesamt",
+		Watched:         "Gesehen",
+		ToWatch:         "Noch sehen",
+		AvgRating:       "Ø Bewertung",
+		MoviesWatched:   "Filme (%d gesehen)",
+		ShowsWatched:    "Serien (%d gesehen)",
+		TopGenres:       "Top-Genres",
+		Movies:          "Filme",
+		TVShows:         "Serien",
+		WatchedOn:       "am %s",
+		SeasonsEpisodes: "%d Staffeln • %d Folgen",
+		Tags:            "Tags",
+		Notes:           "Notizen",
+		DateFormat:      "%[1]d. %[2]s %[3]d",
+		Months:          [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+	},
+}
+
+// reportView is the data passed to the report template
+type reportView struct {
+	Version   int
+	Theme     string
+	Lang      string
+	Labels    reportLabels
+	Generated string
+	Stats     *models.WatchlistStats
+	Sections  []reportSection
+}
+
+// reportSection is one media-type section of the report
+type reportSection struct {
+	Heading string
+	Items   []models.WatchlistItem
+}
+
+// ExportToHTML writes a watchlist as a standalone HTML report. All user
+// supplied text is escaped by the template.
+func (s *ExportService) ExportToHTML(w io.Writer, watchlist *models.Watchlist, stats *models.WatchlistStats, opts HTMLOptions) error {
+	theme := opts.Theme
+	if theme == "" {
+		theme = ThemeLight
+	}
+	if !IsReportTheme(theme) {
+		return fmt.Errorf("unknown theme %q", opts.Theme)
+	}
+
+	lang := reportLocale(opts.Locale)
+	labels := reportLocales[lang]
+
+	var movies, shows []models.WatchlistItem
+	for _, item := range watchlist.Items {
+		if item.IsTV() {
+			shows = append(shows, item)
+		} else {
+			movies = append(movies, item)
+		}
+	}
+
+	view := reportView{
+		Version:   ReportTemplateVersion,
+		Theme:     theme,
+		Lang:      lang,
+		Labels:    labels,
+		Generated: labels.formatDate(time.Now()),
+		Stats:     stats,
+		Sections: []reportSection{
+			{Heading: labels.Movies, Items: movies},
+			{Heading: labels.TVShows, Items: shows},
+		},
+	}
+
+	// Dates depend on the locale, so the formatter is bound per render
+	tmpl, err := reportTemplate.Clone()
+	if err != nil {
+		return fmt.Errorf("failed to prepare report template: %w", err)
+	}
+	tmpl.Funcs(template.FuncMap{"date": labels.formatDate})
+
+	if err := tmpl.Execute(w, view); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return nil
+}
+
+// IsReportTheme reports whether theme is a supported HTML report theme
+func IsReportTheme(theme string) bool {
+	switch theme {
+	case ThemeLight, ThemeDark, ThemePrint:
+		return true
+	}
+	return false
+}
+
+// reportLocale maps a language tag to a supported report language
+func reportLocale(tag string) string {
+	lang := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := reportLocales[lang]; ok {
+		return lang
+	}
+	return "en"
+}
+
+// formatDate writes a date with the locale's month names
+func (l reportLabels) formatDate(t time.Time) string {
+	return fmt.Sprintf(l.DateFormat, t.Day(), l.Months[t.Month()-1], t.Year())
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func hostileWatchlist() (*models.Watchlist, *models.WatchlistStats) {
+	watchedAt := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	watchlist := &models.Watchlist{
+		UserID: "u1",
+		Items: []models.WatchlistItem{
+			{
+				ID:          "movie_1",
+				MediaType:   models.MediaTypeMovie,
+				Title:       `<script>alert("title")</script>`,
+				Overview:    `<img src=x onerror="alert('overview')">`,
+				UserNotes:   `</div><script>alert(1)</script>`,
+				Genre:       `Drama"><svg onload=alert(2)>`,
+				ReleaseDate: `2001<b>`,
+				Tags:        []string{`<iframe src="javascript:alert(3)">`},
+				IsWatched:   true,
+				WatchedAt:   &watchedAt,
+			},
+			{
+				ID:               "tv_2",
+				MediaType:        models.MediaTypeTV,
+				Title:            `Tom & Jerry's "Show"`,
+				NumberOfSeasons:  2,
+				NumberOfEpisodes: 20,
+			},
+		},
+	}
+	stats := &models.WatchlistStats{
+		TotalItems:   2,
+		WatchedItems: 1,
+		TopGenres:    []models.GenreCount{{Genre: `<script>alert("genre")</script>`, Count: 1}},
+	}
+	return watchlist, stats
+}
+
+func TestExportToHTMLEscapesUserInput(t *testing.T) {
+	exporter := newTestExportService(t)
+	watchlist, stats := hostileWatchlist()
+
+	var buf bytes.Buffer
+	require.NoError(t, exporter.ExportToHTML(&buf, watchlist, stats, HTMLOptions{}))
+	html := buf.String()
+
+	for _, raw := range []string{"<script", "<img", "<svg", "<iframe", "<b>"} {
+		assert.NotContains(t, html, raw)
+	}
+	assert.Contains(t, html, "&lt;script&gt;alert(&#34;title&#34;)&lt;/script&gt;")
+	assert.Contains(t, html, "&lt;/div&gt;&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.Contains(t, html, "Tom &amp; Jerry&#39;s &#34;Show&#34;")
+	assert.Contains(t, html, "on March 5, 2024")
+	assert.Contains(t, html, "2 seasons • 20 episodes")
+}
+
+func TestExportToHTMLThemes(t *testing.T) {
+	exporter := newTestExportService(t)
+	watchlist, stats := hostileWatchlist()
+
+	var buf bytes.Buffer
+	require.NoError(t, exporter.ExportToHTML(&buf, watchlist, stats, HTMLOptions{}))
+	assert.Contains(t, buf.String(), `<body class="theme-light">`)
+	assert.Contains(t, buf.String(), `content="relax-and-watch report v1"`)
+
+	for _, theme := range []string{ThemeDark, ThemePrint} {
+		buf.Reset()
+		require.NoError(t, exporter.ExportToHTML(&buf, watchlist, stats, HTMLOptions{Theme: theme}))
+		assert.Contains(t, buf.String(), `<body class="theme-`+theme+`">`)
+	}
+
+	buf.Reset()
+	assert.Error(t, exporter.ExportToHTML(&buf, watchlist, stats, HTMLOptions{Theme: `dark"><script>`}))
+	assert.Zero(t, buf.Len())
+}
+
+func TestExportToHTMLLocales(t *testing.T) {
+	exporter := newTestExportService(t)
+	watchlist, stats := hostileWatchlist()
+
+	tests := []struct {
+		locale, lang, title, watched string
+	}{
+		{"", "en", "My Watchlist Report", "on March 5, 2024"},
+		{"es-MX", "es", "Informe de mi lista", "el 5 de marzo de 2024"},
+		{"fr_CA", "fr", "Rapport de ma liste", "le 5 mars 2024"},
+		{"DE", "de", "Mein Watchlist-Bericht", "am 5. März 2024"},
+		{"xx", "en", "My Watchlist Report", "on March 5, 2024"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		require.NoError(t, exporter.ExportToHTML(&buf, watchlist, stats, HTMLOptions{Locale: tt.locale}))
+		html := buf.String()
+		assert.Contains(t, html, `<html lang="`+tt.lang+`">`, tt.locale)
+		assert.Contains(t, html, "<h1>"+tt.title+"</h1>", tt.locale)
+		assert.Contains(t, html, tt.watched, tt.locale)
+	}
+}
+
+func TestReportLocalesComplete(t *testing.T) {
+	for lang, labels := range reportLocales {
+		assert.NotEmpty(t, labels.Title, lang)
+		assert.Equal(t, 1, strings.Count(labels.MoviesWatched, "%d"), lang)
+		assert.Equal(t, 2, strings.Count(labels.SeasonsEpisodes, "%d"), lang)
+		for _, month := range labels.Months {
+			assert.NotEmpty(t, month, lang)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"r.a.w/backend/internal/models"
+)
+
+// calendarLookback is how long past releases stay in the calendar
+const calendarLookback = 30 * 24 * time.Hour
+
+// icsLineLimit is the maximum line length in octets before folding (RFC 5545 3.1)
+const icsLineLimit = 75
+
+// ExportToICS writes a watchlist's release dates as an iCalendar feed with
+// one all-day event per release. Event UIDs only depend on the user, the item
+// and the release, so calendar apps update events in place when dates move.
+func (s *ExportService) ExportToICS(w io.Writer, watchlist *models.Watchlist) error {
+	buf := bufio.NewWriter(w)
+	now := time.Now().UTC()
+	cutoff := now.Add(-calendarLookback).Format("2006-01-02")
+
+	writeICSLine(buf, "BEGIN", "VCALENDAR")
+	writeICSLine(buf, "VERSION", "2.0")
+	writeICSLine(buf, "PRODID", "-//"+models.ExportGenerator+"//Watchlist Calendar//EN")
+	writeICSLine(buf, "CALSCALE", "GREGORIAN")
+	writeICSLine(buf, "METHOD", "PUBLISH")
+	writeICSLine(buf, "X-WR-CALNAME", icsText(watchlist.Name+" releases"))
+	writeICSLine(buf, "REFRESH-INTERVAL;VALUE=DURATION", "PT12H")
+	writeICSLine(buf, "X-PUBLISHED-TTL", "PT12H")
+
+	for _, item := range watchlist.Items {
+		// A watched movie's later releases aren't interesting; shows keep airing
+		if item.IsWatched && !item.IsTV() {
+			continue
+		}
+
+		stamp := item.AddedAt.UTC()
+		if item.MetadataUpdatedAt != nil {
+			stamp = item.MetadataUpdatedAt.UTC()
+		}
+		if stamp.IsZero() {
+			stamp = now
+		}
+
+		for _, release := range itemReleases(item) {
+			if release.Date < cutoff {
+				continue
+			}
+			day, err := time.Parse("2006-01-02", release.Date)
+			if err != nil {
+				continue
+			}
+
+			writeICSLine(buf, "BEGIN", "VEVENT")
+			writeICSLine(buf, "UID", releaseUID(watchlist.UserID, item, release))
+			writeICSLine(buf, "DTSTAMP", stamp.Format("20060102T150405Z"))
+			writeICSLine(buf, "LAST-MODIFIED", stamp.Format("20060102T150405Z"))
+			writeICSLine(buf, "DTSTART;VALUE=DATE", day.Format("20060102"))
+			writeICSLine(buf, "DTEND;VALUE=DATE", day.AddDate(0, 0, 1).Format("20060102"))
+			writeICSLine(buf, "SUMMARY", icsText(releaseSummary(item, release)))
+			if item.Overview != "" {
+				writeICSLine(buf, "DESCRIPTION", icsText(item.Overview))
+			}
+			writeICSLine(buf, "URL", fmt.Sprintf("https://www.themoviedb.org/%s/%d", item.MediaType, item.MovieID))
+			writeICSLine(buf, "TRANSP", "TRANSPARENT")
+			writeICSLine(buf, "END", "VEVENT")
+		}
+	}
+
+	writeICSLine(buf, "END", "VCALENDAR")
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write iCalendar feed: %w", err)
+	}
+	return nil
+}
+
+// releaseUID returns the stable UID of a release event
+func releaseUID(userID string, item models.WatchlistItem, release models.ReleaseDate) string {
+	uid := fmt.Sprintf("%s-%s-%d-%s", userID, item.MediaType, item.MovieID, release.Type)
+	if release.Type == models.ReleaseEpisode {
+		uid += fmt.Sprintf("-s%02de%02d", release.SeasonNumber, release.EpisodeNumber)
+	}
+	return uid + "@" + models.ExportGenerator
+}
+
+// releaseSummary returns the event title of a release
+func releaseSummary(item models.WatchlistItem, release models.ReleaseDate) string {
+	switch release.Type {
+	case models.ReleaseEpisode:
+		summary := fmt.Sprintf("%s S%02dE%02d", item.Title, release.SeasonNumber, release.EpisodeNumber)
+		if release.EpisodeName != "" {
+			summary += ": " + release.EpisodeName
+		}
+		return summary
+	case models.ReleaseDigital:
+		return item.Title + " (digital release)"
+	default:
+		return item.Title + " (in theaters)"
+	}
+}
+
+// icsText escapes a TEXT value (RFC 5545 3.3.11)
+func icsText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(value)
+}
+
+// writeICSLine writes a content line, folding it at icsLineLimit octets
+// without splitting UTF-8 characters
+func writeICSLine(buf *bufio.Writer, name, value string) {
+	line := name + ":" + value
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts toward the limit
+		limit = icsLineLimit - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,479 @@
+package services
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"r.a.w/backend/internal/models"
+	"r.a.w/backend/pkg/logger"
+)
+
+// defaultExportArtifactTTL is how long finished exports can be downloaded
+const defaultExportArtifactTTL = 24 * time.Hour
+
+// exportQueueSize bounds the number of jobs waiting for a worker
+const exportQueueSize = 100
+
+// Errors returned by ExportJobService, so handlers can pick a status code
+var (
+	ErrExportJobNotFound = errors.New("export job not found")
+	ErrExportQueueFull   = errors.New("export queue is full, try again later")
+	ErrExportNotReady    = errors.New("export is not ready")
+	ErrExportExpired     = errors.New("export download has expired")
+)
+
+// ExportJobService runs exports in the background on a bounded pool of
+// workers. Jobs are kept in dataDir/exports, so queued jobs survive a
+// restart, and their artifacts in dataDir/exports/files.
+type ExportJobService struct {
+	dir        string
+	watchlists *WatchlistService
+	exports    *ExportService
+	logger     *logger.Logger
+	ttl        time.Duration
+	queue      chan string
+	mu         sync.Mutex
+}
+
+// NewExportJobService creates an export job service. Jobs only run once
+// StartWorkers has been called.
+func NewExportJobService(dataDir string, watchlists *WatchlistService, exports *ExportService, logger *logger.Logger) *ExportJobService {
+	dir := filepath.Join(dataDir, "exports")
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0755); err != nil {
+		logger.Error("Failed to create exports directory: %v", err)
+	}
+
+	return &ExportJobService{
+		dir:        dir,
+		watchlists: watchlists,
+		exports:    exports,
+		logger:     logger,
+		ttl:        defaultExportArtifactTTL,
+		queue:      make(chan string, exportQueueSize),
+	}
+}
+
+// SetArtifactTTL sets how long finished exports stay downloadable
+func (s *ExportJobService) SetArtifactTTL(ttl time.Duration) {
+	s.ttl = ttl
+}
+
+// CreateJob validates an export request and queues it
+func (s *ExportJobService) CreateJob(userID string, req models.ExportJobRequest) (*models.ExportJob, error) {
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+	if _, err := s.exports.Exporter(req.Format); err != nil {
+		return nil, err
+	}
+	if req.Options.Theme != "" && !IsReportTheme(req.Options.Theme) {
+		return nil, invalidf("invalid theme %q, use light, dark or print", req.Options.Theme)
+	}
+	if req.ListID == "" {
+		req.ListID = models.DefaultListID
+	}
+	if _, err := s.watchlists.GetList(userID, req.ListID); err != nil {
+		return nil, err
+	}
+
+	job := &models.ExportJob{
+		ID:        s.watchlists.generateID(),
+		UserID:    userID,
+		ListID:    req.ListID,
+		Format:    req.Format,
+		Options:   req.Options,
+		Status:    models.ExportJobQueued,
+		CreatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.saveJob(job); err != nil {
+		return nil, err
+	}
+	select {
+	case s.queue <- job.ID:
+	default:
+		os.Remove(s.jobPath(job.ID))
+		return nil, ErrExportQueueFull
+	}
+
+	return job, nil
+}
+
+// GetJob returns one of a user's export jobs
+func (s *ExportJobService) GetJob(userID, jobID string) (*models.ExportJob, error) {
+	job, err := s.loadJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, ErrExportJobNotFound
+	}
+	return job, nil
+}
+
+// GetJobs returns a user's export jobs, newest first
+func (s *ExportJobService) GetJobs(userID string) ([]models.ExportJob, error) {
+	jobs, err := s.loadJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	userJobs := []models.ExportJob{}
+	for _, job := range jobs {
+		if job.UserID == userID {
+			userJobs = append(userJobs, job)
+		}
+	}
+	sort.Slice(userJobs, func(i, j int) bool {
+		return userJobs[i].CreatedAt.After(userJobs[j].CreatedAt)
+	})
+	return userJobs, nil
+}
+
+// OpenArtifact opens the finished export of a job after checking its
+// download token and expiry. The caller closes the file.
+func (s *ExportJobService) OpenArtifact(userID, jobID, token string) (*os.File, *models.ExportJob, error) {
+	job, err := s.GetJob(userID, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != models.ExportJobDone {
+		return nil, nil, ErrExportNotReady
+	}
+	if token == "" || subtle.ConstantTimeCompare([]byte(job.DownloadToken), []byte(token)) != 1 {
+		return nil, nil, ErrExportJobNotFound
+	}
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		return nil, nil, ErrExportExpired
+	}
+
+	file, err := os.Open(s.artifactPath(job))
+	if os.IsNotExist(err) {
+		return nil, nil, ErrExportExpired
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	return file, job, nil
+}
+
+// StartWorkers requeues jobs left over from a previous run and starts n
+// workers. The returned stop function waits for running jobs to finish.
+func (s *ExportJobService) StartWorkers(n int) (stop func()) {
+	s.requeuePending()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case id := <-s.queue:
+					s.runJob(id)
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// CleanupExpired removes jobs and artifacts past their expiry. It returns
+// the number of jobs removed.
+func (s *ExportJobService) CleanupExpired() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.loadJobs()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	now := time.Now()
+	for _, job := range jobs {
+		if job.ExpiresAt == nil || now.Before(*job.ExpiresAt) {
+			continue
+		}
+		if job.Filename != "" {
+			if err := os.Remove(s.artifactPath(&job)); err != nil && !os.IsNotExist(err) {
+				s.logger.Warning("Failed to remove export artifact %s: %v", job.ID, err)
+				continue
+			}
+		}
+		if err := os.Remove(s.jobPath(job.ID)); err != nil && !os.IsNotExist(err) {
+			s.logger.Warning("Failed to remove export job %s: %v", job.ID, err)
+			continue
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// StartCleanup runs CleanupExpired every interval until the returned stop
+// function is called.
+func (s *ExportJobService) StartCleanup(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				removed, err := s.CleanupExpired()
+				if err != nil {
+					s.logger.Error("Export cleanup failed: %v", err)
+				} else if removed > 0 {
+					s.logger.Success("Removed %d expired export(s)", removed)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// runJob writes the artifact of a queued job and records the outcome
+func (s *ExportJobService) runJob(id string) {
+	job, err := s.updateJob(id, func(job *models.ExportJob) error {
+		if job.Status != models.ExportJobQueued {
+			return fmt.Errorf("export job %s is %s", id, job.Status)
+		}
+		now := time.Now()
+		job.Status = models.ExportJobRunning
+		job.StartedAt = &now
+		return nil
+	})
+	if err != nil {
+		s.logger.Warning("Skipping export job %s: %v", id, err)
+		return
+	}
+
+	exportErr := s.writeArtifact(job)
+
+	_, err = s.updateJob(id, func(job *models.ExportJob) error {
+		now := time.Now()
+		expires := now.Add(s.ttl)
+		job.FinishedAt = &now
+		job.ExpiresAt = &expires
+		if exportErr != nil {
+			job.Status = models.ExportJobFailed
+			job.Error = exportErr.Error()
+			return nil
+		}
+
+		info, err := os.Stat(s.artifactPath(job))
+		if err != nil {
+			job.Status = models.ExportJobFailed
+			job.Error = err.Error()
+			return nil
+		}
+		job.Status = models.ExportJobDone
+		job.Size = info.Size()
+		job.DownloadToken = s.watchlists.generateShareToken()
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to record result of export job %s: %v", id, err)
+		return
+	}
+
+	if exportErr != nil {
+		s.logger.Error("Export job %s for user %s failed: %v", id, job.UserID, exportErr)
+	} else {
+		s.logger.Success("Export job %s for user %s finished", id, job.UserID)
+	}
+}
+
+// writeArtifact exports a job's list to its artifact file. The file is
+// written under a temporary name, so a half-written export is never served.
+func (s *ExportJobService) writeArtifact(job *models.ExportJob) error {
+	exporter, err := s.exports.Exporter(job.Format)
+	if err != nil {
+		return err
+	}
+	export, err := s.watchlists.ExportData(job.UserID, job.ListID)
+	if err != nil {
+		return err
+	}
+
+	// The artifact path depends on these, so they are set before writing
+	s.mu.Lock()
+	job.Filename = fmt.Sprintf("watchlist_%s.%s", job.UserID, exporter.Extension())
+	if job.ListID != models.DefaultListID {
+		job.Filename = fmt.Sprintf("watchlist_%s_%s.%s", job.UserID, job.ListID, exporter.Extension())
+	}
+	job.ContentType = exporter.ContentType()
+	err = s.saveJob(job)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	path := s.artifactPath(job)
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+
+	opts := ExportOptions{
+		PDF:  PDFOptions{Posters: job.Options.Posters},
+		HTML: HTMLOptions{Theme: job.Options.Theme, Locale: job.Options.Locale},
+	}
+	err = exporter.Export(file, export, opts)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return err
+	}
+
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to save export file: %w", err)
+	}
+	return nil
+}
+
+// requeuePending queues jobs that were waiting or running when the service
+// last stopped. Jobs that no longer fit in the queue are marked failed.
+func (s *ExportJobService) requeuePending() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.loadJobs()
+	if err != nil {
+		s.logger.Error("Failed to load export jobs: %v", err)
+		return
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Status != models.ExportJobQueued && job.Status != models.ExportJobRunning {
+			continue
+		}
+
+		job.Status = models.ExportJobQueued
+		job.StartedAt = nil
+		select {
+		case s.queue <- job.ID:
+		default:
+			now := time.Now()
+			expires := now.Add(s.ttl)
+			job.Status = models.ExportJobFailed
+			job.Error = ErrExportQueueFull.Error()
+			job.FinishedAt = &now
+			job.ExpiresAt = &expires
+		}
+		if err := s.saveJob(job); err != nil {
+			s.logger.Error("Failed to requeue export job %s: %v", job.ID, err)
+		}
+	}
+}
+
+// updateJob loads a job, applies fn and saves the result under the lock
+func (s *ExportJobService) updateJob(id string, fn func(*models.ExportJob) error) (*models.ExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.loadJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(job); err != nil {
+		return nil, err
+	}
+	if err := s.saveJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// loadJobs reads every stored export job
+func (s *ExportJobService) loadJobs() ([]models.ExportJob, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exports directory: %w", err)
+	}
+
+	var jobs []models.ExportJob
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		job, err := s.loadJob(strings.TrimSuffix(file.Name(), ".json"))
+		if err != nil {
+			s.logger.Warning("Skipping export job file %s: %v", file.Name(), err)
+			continue
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+// loadJob reads an export job
+func (s *ExportJobService) loadJob(id string) (*models.ExportJob, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, ErrExportJobNotFound
+	}
+
+	data, err := ioutil.ReadFile(s.jobPath(id))
+	if os.IsNotExist(err) {
+		return nil, ErrExportJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export job: %w", err)
+	}
+
+	var job models.ExportJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal export job: %w", err)
+	}
+	return &job, nil
+}
+
+// saveJob writes an export job to file
+func (s *ExportJobService) saveJob(job *models.ExportJob) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal export job: %w", err)
+	}
+	if err := ioutil.WriteFile(s.jobPath(job.ID), data, 0644); err != nil {
+		return fmt.Errorf("failed to save export job: %w", err)
+	}
+	return nil
+}
+
+// jobPath returns where an export job is stored
+func (s *ExportJobService) jobPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// artifactPath returns where the finished export of a job is stored
+func (s *ExportJobService) artifactPath(job *models.ExportJob) string {
+	return filepath.Join(s.dir, "files", job.ID+filepath.Ext(job.Filename))
+}
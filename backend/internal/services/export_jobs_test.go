@@ -0,0 +1,116 @@
+package services
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func newTestExportJobs(t *testing.T, watchlists *WatchlistService) *ExportJobService {
+	return NewExportJobService(watchlists.dataDir, watchlists, newTestExportService(t), watchlists.logger)
+}
+
+// waitForJob polls a job until it leaves the queue
+func waitForJob(t *testing.T, jobs *ExportJobService, userID, jobID string) *models.ExportJob {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := jobs.GetJob(userID, jobID)
+		require.NoError(t, err)
+		if job.Status == models.ExportJobDone || job.Status == models.ExportJobFailed {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("export job %s did not finish", jobID)
+	return nil
+}
+
+func TestExportJob(t *testing.T) {
+	watchlists := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+	jobs := newTestExportJobs(t, watchlists)
+	stop := jobs.StartWorkers(2)
+	defer stop()
+
+	_, err := jobs.CreateJob("user", models.ExportJobRequest{Format: "xlsx"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = jobs.CreateJob("user", models.ExportJobRequest{Format: "html", Options: models.ExportJobOptions{Theme: "neon"}})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = jobs.CreateJob("user", models.ExportJobRequest{Format: "csv", ListID: "missing"})
+	assert.ErrorIs(t, err, ErrListNotFound)
+
+	job, err := jobs.CreateJob("user", models.ExportJobRequest{Format: "csv"})
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportJobQueued, job.Status)
+
+	_, err = jobs.GetJob("someone-else", job.ID)
+	assert.ErrorIs(t, err, ErrExportJobNotFound)
+
+	job = waitForJob(t, jobs, "user", job.ID)
+	require.Equal(t, models.ExportJobDone, job.Status, job.Error)
+	assert.Equal(t, "watchlist_user.csv", job.Filename)
+	assert.NotZero(t, job.Size)
+	require.NotNil(t, job.ExpiresAt)
+
+	_, _, err = jobs.OpenArtifact("user", job.ID, "wrong")
+	assert.ErrorIs(t, err, ErrExportJobNotFound)
+	_, _, err = jobs.OpenArtifact("someone-else", job.ID, job.DownloadToken)
+	assert.ErrorIs(t, err, ErrExportJobNotFound)
+
+	file, _, err := jobs.OpenArtifact("user", job.ID, job.DownloadToken)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(file)
+	file.Close()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Heat")
+
+	list, err := jobs.GetJobs("user")
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestExportJobExpiry(t *testing.T) {
+	watchlists := newTestService(t, nil)
+	jobs := newTestExportJobs(t, watchlists)
+	jobs.SetArtifactTTL(time.Millisecond)
+	stop := jobs.StartWorkers(1)
+	defer stop()
+
+	job, err := jobs.CreateJob("user", models.ExportJobRequest{Format: "json"})
+	require.NoError(t, err)
+	job = waitForJob(t, jobs, "user", job.ID)
+	require.Equal(t, models.ExportJobDone, job.Status, job.Error)
+	time.Sleep(5 * time.Millisecond)
+
+	_, _, err = jobs.OpenArtifact("user", job.ID, job.DownloadToken)
+	assert.ErrorIs(t, err, ErrExportExpired)
+
+	removed, err := jobs.CleanupExpired()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	_, err = os.Stat(jobs.artifactPath(job))
+	assert.True(t, os.IsNotExist(err))
+	_, err = jobs.GetJob("user", job.ID)
+	assert.ErrorIs(t, err, ErrExportJobNotFound)
+}
+
+func TestExportJobsResumeAfterRestart(t *testing.T) {
+	watchlists := newTestService(t, nil)
+
+	// Queued without workers, as if the server stopped before running it
+	job, err := newTestExportJobs(t, watchlists).CreateJob("user", models.ExportJobRequest{Format: "ndjson"})
+	require.NoError(t, err)
+
+	restarted := newTestExportJobs(t, watchlists)
+	stop := restarted.StartWorkers(1)
+	defer stop()
+
+	job = waitForJob(t, restarted, "user", job.ID)
+	assert.Equal(t, models.ExportJobDone, job.Status, job.Error)
+}
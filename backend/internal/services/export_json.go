@@ -0,0 +1,218 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"r.a.w/backend/internal/models"
+)
+
+// maxNDJSONLine bounds the length of one NDJSON record
+const maxNDJSONLine = 1 << 20
+
+// ExportToJSON streams a full-fidelity JSON export. Items and watch events
+// are encoded one at a time, one per line, so large lists are never held in
+// memory as a single document.
+func (s *ExportService) ExportToJSON(w io.Writer, export *models.WatchlistExport) error {
+	buf := bufio.NewWriter(w)
+
+	// The list is encoded without its items, which are spliced in where the
+	// empty items field was. A quote inside a JSON string is always escaped,
+	// so the marker can only match the field itself.
+	header := *export.Watchlist
+	header.Items = nil
+	list, err := json.Marshal(&header)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON export: %w", err)
+	}
+	itemsMarker := []byte(`"items":null`)
+	split := bytes.Index(list, itemsMarker)
+	if split < 0 {
+		return fmt.Errorf("failed to marshal JSON export: list has no items field")
+	}
+
+	fmt.Fprintf(buf, "{\n\"export_schema_version\":%d,\n", export.ExportSchemaVersion)
+	if err := writeJSONField(buf, "generator", export.Generator); err != nil {
+		return err
+	}
+	if err := writeJSONField(buf, "exported_at", export.ExportedAt); err != nil {
+		return err
+	}
+	buf.WriteString("\"watchlist\":")
+	buf.Write(list[:split])
+	buf.WriteString("\"items\":")
+	if err := writeJSONArray(buf, len(export.Watchlist.Items), func(i int) interface{} { return &export.Watchlist.Items[i] }); err != nil {
+		return err
+	}
+	buf.Write(list[split+len(itemsMarker):])
+	buf.WriteString(",\n\"watch_events\":")
+	if err := writeJSONArray(buf, len(export.WatchEvents), func(i int) interface{} { return &export.WatchEvents[i] }); err != nil {
+		return err
+	}
+	buf.WriteString("\n}\n")
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write JSON export: %w", err)
+	}
+	return nil
+}
+
+// writeJSONField writes one `"key":value,` line of a JSON object
+func writeJSONField(w *bufio.Writer, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON export field %s: %w", key, err)
+	}
+	fmt.Fprintf(w, "%q:", key)
+	w.Write(data)
+	w.WriteString(",\n")
+	return nil
+}
+
+// writeJSONArray writes n elements as a JSON array, one element per line
+func writeJSONArray(w *bufio.Writer, n int, element func(int) interface{}) error {
+	w.WriteString("[")
+	for i := 0; i < n; i++ {
+		data, err := json.Marshal(element(i))
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON export: %w", err)
+		}
+		if i > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString("\n")
+		w.Write(data)
+	}
+	if n > 0 {
+		w.WriteString("\n")
+	}
+	w.WriteString("]")
+	return nil
+}
+
+// ExportToNDJSON streams an export as newline-delimited JSON: a header record,
+// then one record per item and per watch event
+func (s *ExportService) ExportToNDJSON(w io.Writer, export *models.WatchlistExport) error {
+	buf := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buf)
+
+	header := *export.Watchlist
+	header.Items = nil
+	exportedAt := export.ExportedAt
+	err := encoder.Encode(models.NDJSONRecord{
+		Type:                models.NDJSONRecordHeader,
+		ExportSchemaVersion: export.ExportSchemaVersion,
+		Generator:           export.Generator,
+		ExportedAt:          &exportedAt,
+		Watchlist:           &header,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write NDJSON header: %w", err)
+	}
+
+	for i := range export.Watchlist.Items {
+		record := models.NDJSONRecord{Type: models.NDJSONRecordItem, Item: &export.Watchlist.Items[i]}
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write NDJSON item: %w", err)
+		}
+	}
+	for i := range export.WatchEvents {
+		record := models.NDJSONRecord{Type: models.NDJSONRecordWatchEvent, WatchEvent: &export.WatchEvents[i]}
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write NDJSON watch event: %w", err)
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write NDJSON export: %w", err)
+	}
+	return nil
+}
+
+// decodeJSONExport reads an export written by ExportToJSON
+func decodeJSONExport(data []byte) (*models.WatchlistExport, error) {
+	var export models.WatchlistExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, invalidf("failed to read JSON export: %v", err)
+	}
+	if export.Watchlist == nil {
+		return nil, invalidf("JSON export has no watchlist")
+	}
+	if err := checkExportVersion(export.ExportSchemaVersion); err != nil {
+		return nil, err
+	}
+	if export.Watchlist.Items == nil {
+		export.Watchlist.Items = []models.WatchlistItem{}
+	}
+	if export.WatchEvents == nil {
+		export.WatchEvents = []models.WatchEvent{}
+	}
+	return &export, nil
+}
+
+// decodeNDJSONExport reads an export written by ExportToNDJSON
+func decodeNDJSONExport(r io.Reader) (*models.WatchlistExport, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+
+	var export *models.WatchlistExport
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var record models.NDJSONRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, invalidf("invalid NDJSON record on line %d: %v", line, err)
+		}
+
+		if export == nil {
+			if record.Type != models.NDJSONRecordHeader || record.Watchlist == nil || record.ExportedAt == nil {
+				return nil, invalidf("NDJSON export must start with a header record")
+			}
+			if err := checkExportVersion(record.ExportSchemaVersion); err != nil {
+				return nil, err
+			}
+			export = &models.WatchlistExport{
+				ExportSchemaVersion: record.ExportSchemaVersion,
+				Generator:           record.Generator,
+				ExportedAt:          *record.ExportedAt,
+				Watchlist:           record.Watchlist,
+				WatchEvents:         []models.WatchEvent{},
+			}
+			export.Watchlist.Items = []models.WatchlistItem{}
+			continue
+		}
+
+		switch {
+		case record.Type == models.NDJSONRecordItem && record.Item != nil:
+			export.Watchlist.Items = append(export.Watchlist.Items, *record.Item)
+		case record.Type == models.NDJSONRecordWatchEvent && record.WatchEvent != nil:
+			export.WatchEvents = append(export.WatchEvents, *record.WatchEvent)
+		default:
+			return nil, invalidf("unexpected NDJSON record %q on line %d", record.Type, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, invalidf("failed to read NDJSON export: %v", err)
+	}
+	if export == nil {
+		return nil, invalidf("NDJSON export is empty")
+	}
+
+	return export, nil
+}
+
+// checkExportVersion rejects exports from newer, unknown schema versions
+func checkExportVersion(version int) error {
+	if version < 1 || version > models.ExportSchemaVersion {
+		return invalidf("unsupported export schema version %d", version)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"r.a.w/backend/internal/models"
+)
+
+// PDFOptions controls optional parts of the PDF report
+type PDFOptions struct {
+	// Posters adds a thumbnail to each table row when the poster is in the local cache
+	Posters bool
+}
+
+// Report layout, in millimetres on A4 portrait
+const (
+	pdfMargin      = 15.0
+	pdfRowHeight   = 7.0
+	pdfPosterRow   = 18.0
+	pdfPosterWidth = 11.0
+	pdfChartGenres = 10
+)
+
+// pdfColumn is one column of the item table
+type pdfColumn struct {
+	title string
+	width float64
+	value func(models.WatchlistItem) string
+}
+
+var pdfColumns = []pdfColumn{
+	{"Title", 58, func(i models.WatchlistItem) string { return i.Title }},
+	{"Type", 16, func(i models.WatchlistItem) string { return mediaTypeLabel(i.MediaType) }},
+	{"Year", 12, func(i models.WatchlistItem) string {
+		if year := yearOf(i.ReleaseDate); year > 0 {
+			return strconv.Itoa(year)
+		}
+		return ""
+	}},
+	{"Genre", 42, func(i models.WatchlistItem) string { return i.Genre }},
+	{"Rating", 14, func(i models.WatchlistItem) string { return fmt.Sprintf("%.1f", i.Rating) }},
+	{"Status", 18, func(i models.WatchlistItem) string {
+		if i.IsWatched {
+			return "Watched"
+		}
+		return "To Watch"
+	}},
+	{"Added", 20, func(i models.WatchlistItem) string { return i.AddedAt.Format("2006-01-02") }},
+}
+
+// pdfTableColumns returns the item table's columns. With posters the poster
+// column takes its width from the title, so the table still fits the page.
+func pdfTableColumns(posters bool) []pdfColumn {
+	if !posters {
+		return pdfColumns
+	}
+	columns := append([]pdfColumn(nil), pdfColumns...)
+	columns[0].width -= pdfPosterWidth + 2
+	return columns
+}
+
+// ExportToPDF writes a watchlist as a paginated PDF report: a stats page with
+// a genre breakdown chart, followed by a table of all items. PDF offsets are
+// only known once the document is complete, so it is rendered in memory first.
+func (s *ExportService) ExportToPDF(w io.Writer, watchlist *models.Watchlist, stats *models.WatchlistStats, opts PDFOptions) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.AliasNbPages("")
+	pdf.SetTitle("My Watchlist Report", true)
+	pdf.SetCreator(models.ExportGenerator, true)
+
+	// Core fonts are Latin-1; characters outside it are replaced
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(136, 136, 136)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+	s.writePDFStats(pdf, tr, watchlist, stats)
+	s.writePDFGenreChart(pdf, tr, watchlist.Items)
+
+	pdf.AddPage()
+	s.writePDFTable(pdf, tr, watchlist.Items, opts)
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to render PDF: %w", err)
+	}
+	return nil
+}
+
+// writePDFStats renders the report header and the stats grid
+func (s *ExportService) writePDFStats(pdf *gofpdf.Fpdf, tr func(string) string, watchlist *models.Watchlist, stats *models.WatchlistStats) {
+	pdf.SetFont("Helvetica", "B", 22)
+	pdf.SetTextColor(51, 51, 51)
+	pdf.CellFormat(0, 12, tr(watchlist.Name), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(102, 102, 102)
+	pdf.CellFormat(0, 6, "Generated on "+time.Now().Format("January 2, 2006"), "", 1, "C", false, 0, "")
+	pdf.Ln(8)
+
+	cells := []struct{ value, label string }{
+		{strconv.Itoa(stats.TotalItems), "Total Items"},
+		{strconv.Itoa(stats.WatchedItems), "Watched"},
+		{strconv.Itoa(stats.UnwatchedItems), "To Watch"},
+		{fmt.Sprintf("%.1f", stats.AverageRating), "Avg Rating"},
+		{strconv.Itoa(stats.Movies.TotalItems), fmt.Sprintf("Movies (%d watched)", stats.Movies.WatchedItems)},
+		{strconv.Itoa(stats.TVShows.TotalItems), fmt.Sprintf("TV Shows (%d watched)", stats.TVShows.WatchedItems)},
+	}
+	if stats.RatedItems > 0 {
+		cells = append(cells,
+			struct{ value, label string }{strconv.Itoa(stats.RatedItems), "Rated by You"},
+			struct{ value, label string }{fmt.Sprintf("%.1f", stats.PersonalAverageRating), "Your Avg (of 10)"},
+		)
+	}
+
+	pageWidth, _ := pdf.GetPageSize()
+	columns := 3
+	cellWidth := (pageWidth - 2*pdfMargin) / float64(columns)
+	for i, cell := range cells {
+		x := pdfMargin + float64(i%columns)*cellWidth
+		y := pdf.GetY()
+		pdf.SetFillColor(245, 245, 245)
+		pdf.Rect(x+1, y, cellWidth-2, 20, "F")
+
+		pdf.SetXY(x, y+3)
+		pdf.SetFont("Helvetica", "B", 16)
+		pdf.SetTextColor(233, 69, 96)
+		pdf.CellFormat(cellWidth, 8, cell.value, "", 0, "C", false, 0, "")
+		pdf.SetXY(x, y+11)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(102, 102, 102)
+		pdf.CellFormat(cellWidth, 6, cell.label, "", 0, "C", false, 0, "")
+
+		if i%columns == columns-1 || i == len(cells)-1 {
+			pdf.SetXY(pdfMargin, y+23)
+		} else {
+			pdf.SetXY(x+cellWidth, y)
+		}
+	}
+	pdf.Ln(6)
+}
+
+// writePDFGenreChart renders a horizontal bar chart of the most common genres
+func (s *ExportService) writePDFGenreChart(pdf *gofpdf.Fpdf, tr func(string) string, items []models.WatchlistItem) {
+	genres := genreBreakdown(items)
+	if len(genres) == 0 {
+		return
+	}
+	if len(genres) > pdfChartGenres {
+		genres = genres[:pdfChartGenres]
+	}
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetTextColor(51, 51, 51)
+	pdf.CellFormat(0, 10, "Genre Breakdown", "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	pageWidth, _ := pdf.GetPageSize()
+	labelWidth := 45.0
+	countWidth := 12.0
+	barMax := pageWidth - 2*pdfMargin - labelWidth - countWidth
+	most := float64(genres[0].Count)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, genre := range genres {
+		y := pdf.GetY()
+		pdf.SetTextColor(51, 51, 51)
+		pdf.CellFormat(labelWidth, 7, fitPDFText(pdf, tr(genre.Genre), labelWidth-2), "", 0, "L", false, 0, "")
+
+		width := barMax * float64(genre.Count) / most
+		pdf.SetFillColor(233, 69, 96)
+		pdf.Rect(pdfMargin+labelWidth, y+1.5, width, 4, "F")
+
+		pdf.SetXY(pdfMargin+labelWidth+width+2, y)
+		pdf.CellFormat(countWidth, 7, strconv.Itoa(genre.Count), "", 1, "L", false, 0, "")
+	}
+}
+
+// writePDFTable renders every item as a table row, repeating the header on each page
+func (s *ExportService) writePDFTable(pdf *gofpdf.Fpdf, tr func(string) string, items []models.WatchlistItem, opts PDFOptions) {
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetTextColor(51, 51, 51)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Items (%d)", len(items)), "", 1, "L", false, 0, "")
+
+	rowHeight := pdfRowHeight
+	if opts.Posters {
+		rowHeight = pdfPosterRow
+	}
+	columns := pdfTableColumns(opts.Posters)
+
+	header := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(233, 69, 96)
+		pdf.SetTextColor(255, 255, 255)
+		if opts.Posters {
+			pdf.CellFormat(pdfPosterWidth+2, pdfRowHeight, "", "", 0, "L", true, 0, "")
+		}
+		for _, column := range columns {
+			pdf.CellFormat(column.width, pdfRowHeight, column.title, "", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(51, 51, 51)
+	}
+	header()
+
+	_, pageHeight := pdf.GetPageSize()
+	for i, item := range items {
+		if pdf.GetY()+rowHeight > pageHeight-pdfMargin {
+			pdf.AddPage()
+			header()
+		}
+
+		fill := i%2 == 1
+		pdf.SetFillColor(248, 248, 248)
+		y := pdf.GetY()
+		if opts.Posters {
+			pdf.CellFormat(pdfPosterWidth+2, rowHeight, "", "", 0, "L", fill, 0, "")
+			if poster := s.cachedPoster(item.PosterPath); poster != "" && s.registerPDFPoster(pdf, poster) {
+				pdf.ImageOptions(poster, pdfMargin+1, y+1, pdfPosterWidth, rowHeight-2, false, gofpdf.ImageOptions{ReadDpi: false}, 0, "")
+			}
+		}
+		for _, column := range columns {
+			text := fitPDFText(pdf, tr(column.value(item)), column.width-2)
+			pdf.CellFormat(column.width, rowHeight, text, "", 0, "L", fill, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+// cachedPoster returns the local path of an item's poster, or "" if it isn't
+// cached or isn't an image format the PDF writer supports
+func (s *ExportService) cachedPoster(posterPath string) string {
+	if s.posterCacheDir == "" || posterPath == "" {
+		return ""
+	}
+
+	name := filepath.Base(posterPath)
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".jpeg", ".png":
+	default:
+		return ""
+	}
+
+	path := filepath.Join(s.posterCacheDir, name)
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return ""
+	}
+	return path
+}
+
+// registerPDFPoster loads a cached poster into the PDF and reports whether it
+// can be drawn. The PDF writer fails the whole document on an image it can't
+// read, so a corrupt or unsupported poster is skipped and its error cleared.
+func (s *ExportService) registerPDFPoster(pdf *gofpdf.Fpdf, path string) bool {
+	if !pdf.Ok() {
+		return false
+	}
+	pdf.RegisterImageOptions(path, gofpdf.ImageOptions{ReadDpi: false})
+	if err := pdf.Error(); err != nil {
+		s.logger.Warning("Skipping unreadable poster %s: %v", path, err)
+		pdf.ClearError()
+		return false
+	}
+	return true
+}
+
+// fitPDFText shortens text with an ellipsis so it fits within width. The
+// text has already been translated to the font's single-byte encoding, so
+// it is trimmed byte by byte rather than as UTF-8.
+func fitPDFText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
+
+// genreBreakdown counts every genre across items, most common first
+func genreBreakdown(items []models.WatchlistItem) []models.GenreCount {
+	counts := make(map[string]int)
+	for _, item := range items {
+		for _, genre := range strings.Split(item.Genre, ",") {
+			if genre = strings.TrimSpace(genre); genre != "" {
+				counts[genre]++
+			}
+		}
+	}
+
+	genres := make([]models.GenreCount, 0, len(counts))
+	for genre, count := range counts {
+		genres = append(genres, models.GenreCount{Genre: genre, Count: count})
+	}
+	sort.Slice(genres, func(i, j int) bool {
+		if genres[i].Count != genres[j].Count {
+			return genres[i].Count > genres[j].Count
+		}
+		return genres[i].Genre < genres[j].Genre
+	})
+	return genres
+}
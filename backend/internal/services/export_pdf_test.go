@@ -0,0 +1,113 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestExportToPDF(t *testing.T) {
+	exporter := newTestExportService(t)
+	exporter.SetPosterCacheDir(t.TempDir())
+
+	watchlist := &models.Watchlist{UserID: "u1", Name: "Watchlist"}
+	for i := 0; i < 120; i++ {
+		watchlist.Items = append(watchlist.Items, models.WatchlistItem{
+			ID:          fmt.Sprintf("movie_%d", i),
+			MediaType:   "movie",
+			Title:       fmt.Sprintf("Amélie and a very long title that will not fit in its column %d", i),
+			Genre:       "Comedy, Romance",
+			ReleaseDate: "2001-04-25",
+			Rating:      7.9,
+			PosterPath:  "/missing.jpg",
+			AddedAt:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			IsWatched:   i%2 == 0,
+		})
+	}
+	stats := &models.WatchlistStats{TotalItems: 120, WatchedItems: 60, UnwatchedItems: 60}
+
+	for _, posters := range []bool{false, true} {
+		var buf bytes.Buffer
+		require.NoError(t, exporter.ExportToPDF(&buf, watchlist, stats, PDFOptions{Posters: posters}))
+		data := buf.Bytes()
+		assert.True(t, bytes.HasPrefix(data, []byte("%PDF-")))
+		assert.Greater(t, bytes.Count(data, []byte("/Type /Page\n")), 3, "items should span several pages")
+	}
+}
+
+func TestPDFTableFitsPage(t *testing.T) {
+	const contentWidth = 210 - 2*pdfMargin
+	for _, posters := range []bool{false, true} {
+		width := 0.0
+		if posters {
+			width = pdfPosterWidth + 2
+		}
+		for _, column := range pdfTableColumns(posters) {
+			width += column.width
+		}
+		assert.Equal(t, contentWidth, width, "posters: %v", posters)
+	}
+	assert.Equal(t, 58.0, pdfColumns[0].width, "the shared columns are not changed")
+}
+
+func TestFitPDFTextKeepsAccents(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 8)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	title := tr("Amélie Poulain et le fabuleux destin du café des Deux Moulins à Montmartre")
+	fitted := fitPDFText(pdf, title, 40)
+	assert.True(t, strings.HasSuffix(fitted, "..."), fitted)
+	assert.True(t, strings.HasPrefix(title, strings.TrimSuffix(fitted, "...")), "only whole bytes of the translated title are kept")
+	assert.Contains(t, fitted, tr("é"))
+	assert.NotContains(t, fitted, "\uFFFD")
+	assert.LessOrEqual(t, pdf.GetStringWidth(fitted), 40.0)
+}
+
+func TestExportToPDFSkipsUnreadablePosters(t *testing.T) {
+	exporter := newTestExportService(t)
+	cacheDir := t.TempDir()
+	exporter.SetPosterCacheDir(cacheDir)
+
+	poster := image.NewRGBA(image.Rect(0, 0, 20, 30))
+	for x := 0; x < 20; x++ {
+		for y := 0; y < 30; y++ {
+			poster.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, poster))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cacheDir, "good.png"), encoded.Bytes(), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cacheDir, "corrupt.jpg"), []byte("not a jpeg"), 0644))
+
+	watchlist := &models.Watchlist{UserID: "u1", Name: "Watchlist", Items: []models.WatchlistItem{
+		{ID: "movie_1", MediaType: "movie", Title: "Heat", PosterPath: "/corrupt.jpg"},
+		{ID: "movie_2", MediaType: "movie", Title: "Ronin", PosterPath: "/good.png"},
+	}}
+	stats := &models.WatchlistStats{TotalItems: 2, UnwatchedItems: 2}
+
+	var buf bytes.Buffer
+	require.NoError(t, exporter.ExportToPDF(&buf, watchlist, stats, PDFOptions{Posters: true}))
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("/Subtype /Image")), "only the readable poster is drawn")
+}
+
+func TestGenreBreakdown(t *testing.T) {
+	genres := genreBreakdown([]models.WatchlistItem{
+		{Genre: "Drama, Comedy"},
+		{Genre: "Drama"},
+		{Genre: ""},
+	})
+	assert.Equal(t, []models.GenreCount{{Genre: "Drama", Count: 2}, {Genre: "Comedy", Count: 1}}, genres)
+}
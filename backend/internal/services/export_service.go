@@ -1,11 +1,12 @@
 package services
 
 import (
-	"bytes"
 	"encoding/csv"
 	"fmt"
+	"io"
+	"sort"
 	"strconv"
-	"time"
+	"strings"
 
 	"r.a.w/backend/internal/models"
 	"r.a.w/backend/pkg/logger"
@@ -13,24 +14,110 @@ import (
 
 // ExportService handles exporting watchlists to various formats
 type ExportService struct {
-	logger *logger.Logger
+	logger         *logger.Logger
+	posterCacheDir string
+	exporters      map[string]Exporter
 }
 
-// NewExportService creates a new export service
+// Exporter writes a watchlist in one export format
+type Exporter interface {
+	// ContentType is the MIME type of the output
+	ContentType() string
+	// Extension is the file name extension, without the dot
+	Extension() string
+	// Export writes the export to w. Output may already have been written when it fails.
+	Export(w io.Writer, export *models.WatchlistExport, opts ExportOptions) error
+}
+
+// ExportOptions holds the format specific export settings
+type ExportOptions struct {
+	PDF  PDFOptions
+	HTML HTMLOptions
+}
+
+// NewExportService creates a new export service with the built-in formats registered
 func NewExportService(logger *logger.Logger) *ExportService {
-	return &ExportService{
-		logger: logger,
+	s := &ExportService{
+		logger:    logger,
+		exporters: make(map[string]Exporter),
+	}
+	
+	s.RegisterExporter("csv", &formatExporter{"text/csv", "csv", func(w io.Writer, export *models.WatchlistExport, opts ExportOptions) error {
+		return s.ExportToCSV(w, export.Watchlist)
+	}})
+	s.RegisterExporter("pdf", &formatExporter{"application/pdf", "pdf", func(w io.Writer, export *models.WatchlistExport, opts ExportOptions) error {
+		return s.ExportToPDF(w, export.Watchlist, computeStats(export.Watchlist), opts.PDF)
+	}})
+	s.RegisterExporter("html", &formatExporter{"text/html; charset=utf-8", "html", func(w io.Writer, export *models.WatchlistExport, opts ExportOptions) error {
+		return s.ExportToHTML(w, export.Watchlist, computeStats(export.Watchlist), opts.HTML)
+	}})
+	s.RegisterExporter("ics", &formatExporter{"text/calendar; charset=utf-8", "ics", func(w io.Writer, export *models.WatchlistExport, opts ExportOptions) error {
+		return s.ExportToICS(w, export.Watchlist)
+	}})
+	s.RegisterExporter("json", &formatExporter{"application/json", "json", func(w io.Writer, export *models.WatchlistExport, opts ExportOptions) error {
+		return s.ExportToJSON(w, export)
+	}})
+	s.RegisterExporter("ndjson", &formatExporter{"application/x-ndjson", "ndjson", func(w io.Writer, export *models.WatchlistExport, opts ExportOptions) error {
+		return s.ExportToNDJSON(w, export)
+	}})
+	
+	return s
+}
+
+// RegisterExporter makes an export format available under name, replacing
+// any exporter registered under the same name
+func (s *ExportService) RegisterExporter(format string, exporter Exporter) {
+	s.exporters[format] = exporter
+}
+
+// Exporter returns the exporter registered for a format
+func (s *ExportService) Exporter(format string) (Exporter, error) {
+	exporter, ok := s.exporters[format]
+	if !ok {
+		return nil, invalidf("unsupported export format %q, use one of: %s", format, strings.Join(s.Formats(), ", "))
 	}
+	return exporter, nil
 }
 
-// ExportToCSV exports a watchlist to CSV format
-func (s *ExportService) ExportToCSV(watchlist *models.Watchlist) ([]byte, error) {
-	var buf bytes.Buffer
-	writer := csv.NewWriter(&buf)
+// Formats returns the names of all registered export formats, sorted
+func (s *ExportService) Formats() []string {
+	formats := make([]string, 0, len(s.exporters))
+	for format := range s.exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// formatExporter adapts one of the ExportTo methods to the Exporter interface
+type formatExporter struct {
+	contentType string
+	extension   string
+	export      func(io.Writer, *models.WatchlistExport, ExportOptions) error
+}
+
+func (e *formatExporter) ContentType() string { return e.contentType }
+func (e *formatExporter) Extension() string   { return e.extension }
+
+func (e *formatExporter) Export(w io.Writer, export *models.WatchlistExport, opts ExportOptions) error {
+	return e.export(w, export, opts)
+}
+
+// SetPosterCacheDir sets the directory holding cached poster images, named
+// after the file name of their TMDB poster path. PDF exports only use posters
+// found there; they never download images.
+func (s *ExportService) SetPosterCacheDir(dir string) {
+	s.posterCacheDir = dir
+}
+
+// ExportToCSV writes a watchlist in CSV format
+func (s *ExportService) ExportToCSV(w io.Writer, watchlist *models.Watchlist) error {
+	writer := csv.NewWriter(w)
 	
 	// Write header
 	header := []string{
 		"Title",
+		"Type",
 		"Release Date",
 		"Genre",
 		"Rating",
@@ -39,10 +126,12 @@ func (s *ExportService) ExportToCSV(watchlist *models.Watchlist) ([]byte, error)
 		"Watched Date",
 		"Notes",
 		"Overview",
+		"Seasons",
+		"Episodes",
 	}
 	
 	if err := writer.Write(header); err != nil {
-		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
 	
 	// Write data rows
@@ -57,8 +146,15 @@ func (s *ExportService) ExportToCSV(watchlist *models.Watchlist) ([]byte, error)
 			}
 		}
 		
+		seasons, episodes := "", ""
+		if item.IsTV() {
+			seasons = strconv.Itoa(item.NumberOfSeasons)
+			episodes = strconv.Itoa(item.NumberOfEpisodes)
+		}
+		
 		row := []string{
 			item.Title,
+			mediaTypeLabel(item.MediaType),
 			item.ReleaseDate,
 			item.Genre,
 			fmt.Sprintf("%.1f", item.Rating),
@@ -67,138 +163,27 @@ func (s *ExportService) ExportToCSV(watchlist *models.Watchlist) ([]byte, error)
 			watchedDate,
 			item.UserNotes,
 			item.Overview,
+			seasons,
+			episodes,
 		}
 		
 		if err := writer.Write(row); err != nil {
-			return nil, fmt.Errorf("failed to write CSV row: %w", err)
+			return fmt.Errorf("failed to write CSV row: %w", err)
 		}
 	}
 	
 	writer.Flush()
 	if err := writer.Error(); err != nil {
-		return nil, fmt.Errorf("CSV writer error: %w", err)
+		return fmt.Errorf("CSV writer error: %w", err)
 	}
 	
-	s.logger.Success("Watchlist exported to CSV for user %s", watchlist.UserID)
-	return buf.Bytes(), nil
-}
-
-// ExportToPDF exports a watchlist to PDF format (simplified HTML-based approach)
-func (s *ExportService) ExportToPDF(watchlist *models.Watchlist, stats *models.WatchlistStats) ([]byte, error) {
-	// Generate HTML content that can be converted to PDF
-	html := s.generateHTMLReport(watchlist, stats)
-	
-	// For now, return HTML bytes. In a production environment, you would use a library like wkhtmltopdf
-	// or a Go PDF library to convert HTML to PDF
-	s.logger.Success("Watchlist exported to HTML/PDF for user %s", watchlist.UserID)
-	return []byte(html), nil
+	return nil
 }
 
-// generateHTMLReport generates an HTML report of the watchlist
-func (s *ExportService) generateHTMLReport(watchlist *models.Watchlist, stats *models.WatchlistStats) string {
-	html := `<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <title>My Watchlist Report</title>
-    <style>
-        body { font-family: Arial, sans-serif; margin: 20px; color: #333; }
-        .header { text-align: center; margin-bottom: 30px; }
-        .stats { background: #f5f5f5; padding: 20px; border-radius: 8px; margin-bottom: 30px; }
-        .stats-grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(200px, 1fr)); gap: 15px; }
-        .stat-item { text-align: center; }
-        .stat-number { font-size: 24px; font-weight: bold; color: #e94560; }
-        .stat-label { font-size: 14px; color: #666; }
-        .movies-section { margin-top: 30px; }
-        .movie-item { border-bottom: 1px solid #eee; padding: 15px 0; display: flex; align-items: flex-start; }
-        .movie-info { flex: 1; }
-        .movie-title { font-size: 18px; font-weight: bold; margin-bottom: 5px; }
-        .movie-details { color: #666; font-size: 14px; margin-bottom: 5px; }
-        .movie-overview { color: #888; font-size: 13px; line-height: 1.4; }
-        .status-watched { color: #28a745; font-weight: bold; }
-        .status-unwatched { color: #ffc107; font-weight: bold; }
-        .rating { color: #e94560; font-weight: bold; }
-        .genres { margin-top: 20px; }
-        .genre-item { display: inline-block; background: #e94560; color: white; padding: 5px 10px; margin: 2px; border-radius: 15px; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="header">
-        <h1>My Watchlist Report</h1>
-        <p>Generated on ` + time.Now().Format("January 2, 2006") + `</p>
-    </div>
-    
-    <div class="stats">
-        <h2>Statistics</h2>
-        <div class="stats-grid">
-            <div class="stat-item">
-                <div class="stat-number">` + strconv.Itoa(stats.TotalItems) + `</div>
-                <div class="stat-label">Total Movies</div>
-            </div>
-            <div class="stat-item">
-                <div class="stat-number">` + strconv.Itoa(stats.WatchedItems) + `</div>
-                <div class="stat-label">Watched</div>
-            </div>
-            <div class="stat-item">
-                <div class="stat-number">` + strconv.Itoa(stats.UnwatchedItems) + `</div>
-                <div class="stat-label">To Watch</div>
-            </div>
-            <div class="stat-item">
-                <div class="stat-number">` + fmt.Sprintf("%.1f", stats.AverageRating) + `</div>
-                <div class="stat-label">Avg Rating</div>
-            </div>
-        </div>`
-	
-	if len(stats.TopGenres) > 0 {
-		html += `
-        <div class="genres">
-            <h3>Top Genres</h3>`
-		for _, genre := range stats.TopGenres {
-			html += `<span class="genre-item">` + genre.Genre + ` (` + strconv.Itoa(genre.Count) + `)</span>`
-		}
-		html += `
-        </div>`
+// mediaTypeLabel returns a human readable label for a media type
+func mediaTypeLabel(mediaType string) string {
+	if mediaType == models.MediaTypeTV {
+		return "TV Show"
 	}
-	
-	html += `
-    </div>
-    
-    <div class="movies-section">
-        <h2>Movies (` + strconv.Itoa(len(watchlist.Items)) + `)</h2>`
-	
-	for _, item := range watchlist.Items {
-		status := `<span class="status-unwatched">To Watch</span>`
-		watchedInfo := ""
-		
-		if item.IsWatched {
-			status = `<span class="status-watched">Watched</span>`
-			if item.WatchedAt != nil {
-				watchedInfo = " on " + item.WatchedAt.Format("Jan 2, 2006")
-			}
-		}
-		
-		html += `
-        <div class="movie-item">
-            <div class="movie-info">
-                <div class="movie-title">` + item.Title + `</div>
-                <div class="movie-details">
-                    ` + item.ReleaseDate + ` • ` + item.Genre + ` • <span class="rating">★ ` + fmt.Sprintf("%.1f", item.Rating) + `</span> • ` + status + watchedInfo + `
-                </div>`
-		
-		if item.UserNotes != "" {
-			html += `<div class="movie-details"><strong>Notes:</strong> ` + item.UserNotes + `</div>`
-		}
-		
-		html += `
-                <div class="movie-overview">` + item.Overview + `</div>
-            </div>
-        </div>`
-	}
-	
-	html += `
-    </div>
-</body>
-</html>`
-	
-	return html
+	return "Movie"
 }
\ No newline at end of file
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+// largeExport builds an export of n items with one watch event per watched item
+func largeExport(n int) *models.WatchlistExport {
+	added := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	export := &models.WatchlistExport{
+		ExportSchemaVersion: models.ExportSchemaVersion,
+		Generator:           models.ExportGenerator,
+		ExportedAt:          added,
+		Watchlist:           &models.Watchlist{ID: models.DefaultListID, UserID: "bench", Name: "Watchlist", IsDefault: true},
+	}
+	for i := 0; i < n; i++ {
+		item := models.WatchlistItem{
+			ID:          fmt.Sprintf("movie_%d", i),
+			MediaType:   models.MediaTypeMovie,
+			MovieID:     i,
+			Title:       fmt.Sprintf("Movie number %d", i),
+			ReleaseDate: fmt.Sprintf("%d-06-01", 1950+i%80),
+			Genre:       []string{"Drama", "Comedy, Romance", "Action, Thriller", "Animation"}[i%4],
+			Rating:      float64(i%100) / 10,
+			Overview:    "An overview long enough to be representative of the text TMDB returns for a title.",
+			AddedAt:     added.Add(time.Duration(i) * time.Minute),
+			Rank:        fmt.Sprintf("%08d", i),
+			Tags:        []string{"tag"},
+		}
+		if i%3 == 0 {
+			watchedAt := item.AddedAt.Add(24 * time.Hour)
+			item.IsWatched = true
+			item.WatchedAt = &watchedAt
+			export.WatchEvents = append(export.WatchEvents, models.WatchEvent{
+				ID: fmt.Sprintf("event_%d", i), MediaType: item.MediaType, MovieID: item.MovieID, WatchedAt: watchedAt,
+			})
+		}
+		export.Watchlist.Items = append(export.Watchlist.Items, item)
+	}
+	return export
+}
+
+func TestExportFormats(t *testing.T) {
+	exporter := newTestExportService(t)
+	export := largeExport(50)
+
+	assert.Equal(t, []string{"csv", "html", "ics", "json", "ndjson", "pdf"}, exporter.Formats())
+	for _, format := range exporter.Formats() {
+		e, err := exporter.Exporter(format)
+		require.NoError(t, err)
+		assert.Equal(t, format, e.Extension())
+		assert.NotEmpty(t, e.ContentType())
+
+		var buf bytes.Buffer
+		require.NoError(t, e.Export(&buf, export, ExportOptions{}), format)
+		assert.NotZero(t, buf.Len(), format)
+	}
+
+	_, err := exporter.Exporter("xlsx")
+	assert.Error(t, err)
+}
+
+type countingExporter struct{}
+
+func (countingExporter) ContentType() string { return "text/plain" }
+func (countingExporter) Extension() string   { return "txt" }
+
+func (countingExporter) Export(w io.Writer, export *models.WatchlistExport, opts ExportOptions) error {
+	_, err := fmt.Fprintf(w, "%d items\n", len(export.Watchlist.Items))
+	return err
+}
+
+func TestRegisterExporter(t *testing.T) {
+	exporter := newTestExportService(t)
+	exporter.RegisterExporter("txt", countingExporter{})
+
+	e, err := exporter.Exporter("txt")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, e.Export(&buf, largeExport(3), ExportOptions{}))
+	assert.Equal(t, "3 items\n", buf.String())
+	assert.Contains(t, exporter.Formats(), "txt")
+}
+
+func TestJSONExportStreamsValidJSON(t *testing.T) {
+	exporter := newTestExportService(t)
+	for _, n := range []int{0, 1, 5} {
+		export := largeExport(n)
+		export.Watchlist.Name = `"items":null`
+
+		var buf bytes.Buffer
+		require.NoError(t, exporter.ExportToJSON(&buf, export))
+		decoded, err := decodeJSONExport(buf.Bytes())
+		require.NoError(t, err, buf.String())
+		assert.Equal(t, `"items":null`, decoded.Watchlist.Name)
+		assert.Len(t, decoded.Watchlist.Items, n)
+	}
+}
+
+func BenchmarkExport(b *testing.B) {
+	exporter := newTestExportService(b)
+	export := largeExport(50000)
+
+	for _, format := range exporter.Formats() {
+		e, err := exporter.Exporter(format)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(format, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := e.Export(io.Discard, export, ExportOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
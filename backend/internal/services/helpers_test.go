@@ -0,0 +1,34 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+	"r.a.w/backend/pkg/logger"
+)
+
+// newTestService stores items in the default list of user "user" in a fresh
+// data directory. It has no movie service, so nothing is fetched.
+func newTestService(t *testing.T, items []models.WatchlistItem) *WatchlistService {
+	dir := t.TempDir()
+	appLogger, err := logger.NewLogger(filepath.Join(dir, "test.log"))
+	require.NoError(t, err)
+	t.Cleanup(appLogger.Close)
+
+	s := NewWatchlistService(dir, nil, appLogger)
+	rank := ""
+	for i := range items {
+		rank = rankAfter(rank)
+		items[i].Rank = rank
+	}
+	require.NoError(t, s.saveWatchlist(&models.Watchlist{
+		SchemaVersion: models.CurrentWatchlistSchemaVersion,
+		ID:            models.DefaultListID,
+		UserID:        "user",
+		IsDefault:     true,
+		Items:         items,
+	}))
+	return s
+}
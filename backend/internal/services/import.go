@@ -0,0 +1,490 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// importExpiry is how long an import report can still be confirmed
+const importExpiry = 24 * time.Hour
+
+// maxImportCandidates bounds the candidates offered for an ambiguous row
+const maxImportCandidates = 5
+
+// ImportWatchlist reads an import file, matches every row to a TMDB title and,
+// unless dryRun is set, adds the matched titles to the list. Ambiguous rows
+// are kept in the returned report and can be resolved with ConfirmImport.
+// Our own JSON and NDJSON exports skip matching and are restored exactly.
+func (s *WatchlistService) ImportWatchlist(userID, listID, format string, data []byte, dryRun bool) (*models.ImportReport, error) {
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+	if _, err := s.GetList(userID, listID); err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		format = detectImportFormat(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	}
+	if format == models.ImportFormatJSON || format == models.ImportFormatNDJSON {
+		return s.importExport(userID, listID, format, data, dryRun)
+	}
+
+	if s.movieService == nil {
+		return nil, fmt.Errorf("metadata provider is unavailable")
+	}
+	format, rows, err := parseImport(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &models.ImportReport{
+		ID:        s.generateID(),
+		UserID:    userID,
+		ListID:    listID,
+		Format:    format,
+		Mode:      models.ImportModeMerge,
+		DryRun:    dryRun,
+		CreatedAt: now,
+		ExpiresAt: now.Add(importExpiry),
+	}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, s.matchImportRow(row))
+	}
+
+	if !dryRun {
+		s.importMatchedRows(report)
+	}
+	countImport(report)
+
+	s.importMu.Lock()
+	s.removeExpiredImports(userID)
+	err = s.saveImport(report)
+	s.importMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Success("Imported %d of %d %s row(s) for user %s", report.Imported, len(report.Rows), format, userID)
+	return report, nil
+}
+
+// GetImport returns an earlier import report
+func (s *WatchlistService) GetImport(userID, importID string) (*models.ImportReport, error) {
+	return s.loadImport(userID, importID)
+}
+
+// ConfirmImport resolves ambiguous rows of an earlier import to the chosen
+// titles and imports every matched row that was not imported yet, including
+// the matched rows of a dry run. Confirmations run one at a time.
+func (s *WatchlistService) ConfirmImport(userID, importID string, confirmation models.ImportConfirmation) (*models.ImportReport, error) {
+	// Held from loading the report to saving it, so that concurrent
+	// confirmations can't import the same rows twice
+	s.importMu.Lock()
+	defer s.importMu.Unlock()
+
+	report, err := s.loadImport(userID, importID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, choice := range confirmation.Choices {
+		result := importRowByLine(report, choice.Line)
+		if result == nil {
+			return nil, invalidf("line %d not found in import", choice.Line)
+		}
+		if result.Imported {
+			return nil, invalidf("line %d was already imported", choice.Line)
+		}
+		if choice.ID == 0 {
+			result.Status = models.ImportStatusSkipped
+			result.Match = nil
+			continue
+		}
+
+		mediaType := choice.MediaType
+		if mediaType == "" {
+			mediaType = result.Row.MediaType
+		}
+		result.Status = models.ImportStatusMatched
+		result.Match = &models.ImportCandidate{MediaType: mediaType, ID: choice.ID}
+		for _, candidate := range result.Candidates {
+			if candidate.ID == choice.ID && candidate.MediaType == mediaType {
+				candidate := candidate
+				result.Match = &candidate
+			}
+		}
+		result.Error = ""
+	}
+
+	report.DryRun = false
+	s.importMatchedRows(report)
+	countImport(report)
+
+	if err := s.saveImport(report); err != nil {
+		return nil, err
+	}
+
+	s.logger.Success("Confirmed import %s for user %s, %d row(s) imported", importID, userID, report.Imported)
+	return report, nil
+}
+
+// matchImportRow resolves a row to a TMDB title by TMDB id, IMDb id, or a
+// title search narrowed down by year
+func (s *WatchlistService) matchImportRow(row models.ImportRow) models.ImportRowResult {
+	result := models.ImportRowResult{Row: row}
+	if row.MediaType == "" {
+		row.MediaType = models.MediaTypeMovie
+		result.Row.MediaType = row.MediaType
+	}
+
+	if row.TMDBID > 0 {
+		result.Status = models.ImportStatusMatched
+		result.Match = &models.ImportCandidate{MediaType: row.MediaType, ID: row.TMDBID, Title: row.Title, Year: row.Year}
+		return result
+	}
+
+	if row.IMDbID != "" {
+		if found, err := s.movieService.FindByIMDbID(row.IMDbID); err == nil {
+			candidates := importCandidates(found["movie_results"], models.MediaTypeMovie)
+			candidates = append(candidates, importCandidates(found["tv_results"], models.MediaTypeTV)...)
+			if len(candidates) == 1 {
+				result.Status = models.ImportStatusMatched
+				result.Match = &candidates[0]
+				result.Row.MediaType = candidates[0].MediaType
+				return result
+			}
+		}
+	}
+
+	found, err := s.movieService.SearchContent(row.Title, row.MediaType, 1)
+	if err != nil {
+		result.Status = models.ImportStatusUnmatched
+		result.Error = err.Error()
+		return result
+	}
+	candidates := importCandidates(found["results"], row.MediaType)
+
+	match, narrowed := pickImportMatch(row, candidates)
+	switch {
+	case match != nil:
+		result.Status = models.ImportStatusMatched
+		result.Match = match
+	case len(narrowed) == 0:
+		result.Status = models.ImportStatusUnmatched
+	default:
+		result.Status = models.ImportStatusAmbiguous
+		if len(narrowed) > maxImportCandidates {
+			narrowed = narrowed[:maxImportCandidates]
+		}
+		result.Candidates = narrowed
+	}
+	return result
+}
+
+// pickImportMatch chooses a single candidate for a row if the title and year
+// leave no doubt. Otherwise it returns the candidates worth offering, best first.
+func pickImportMatch(row models.ImportRow, candidates []models.ImportCandidate) (*models.ImportCandidate, []models.ImportCandidate) {
+	title := normalizeImportTitle(row.Title)
+
+	var exact, sameYear, nearYear []models.ImportCandidate
+	for _, candidate := range candidates {
+		yearDiff := candidate.Year - row.Year
+		if yearDiff < 0 {
+			yearDiff = -yearDiff
+		}
+		if row.Year == 0 || yearDiff == 0 {
+			sameYear = append(sameYear, candidate)
+			if normalizeImportTitle(candidate.Title) == title {
+				exact = append(exact, candidate)
+			}
+		} else if yearDiff == 1 {
+			// Release years differ by region, so allow one year either way
+			nearYear = append(nearYear, candidate)
+		}
+	}
+
+	switch {
+	case len(exact) == 1:
+		return &exact[0], exact
+	case len(exact) > 1:
+		return nil, exact
+	case len(sameYear) == 1 && row.Year != 0:
+		return &sameYear[0], sameYear
+	case len(sameYear) > 0:
+		return nil, sameYear
+	case len(nearYear) == 1:
+		return &nearYear[0], nearYear
+	default:
+		return nil, nearYear
+	}
+}
+
+// importMatchedRows adds every matched row that was not imported yet to the
+// report's list, with its notes, tags, rating and watch. Metadata is fetched
+// before the list is locked.
+func (s *WatchlistService) importMatchedRows(report *models.ImportReport) {
+	prepared := make(map[int]*models.WatchlistItem)
+	for i := range report.Rows {
+		result := &report.Rows[i]
+		if result.Status != models.ImportStatusMatched || result.Imported {
+			continue
+		}
+		item, err := s.prepareItem(result.Match.MediaType, result.Match.ID)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		if result.Match.Title == "" {
+			result.Match.Title = item.Title
+			result.Match.Year = yearOf(item.ReleaseDate)
+		}
+		if err := s.applyImportedData(item, result.Row); err != nil {
+			result.Warning = err.Error()
+		}
+		prepared[i] = item
+	}
+	if len(prepared) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.GetList(report.UserID, report.ListID)
+	if err == nil {
+		var history *models.WatchHistory
+		history, err = s.loadWatchHistory(report.UserID)
+		if err == nil {
+			err = s.insertImportedItems(report, list, history, prepared)
+		}
+	}
+	if err != nil {
+		for i := range prepared {
+			report.Rows[i].Imported = false
+			report.Rows[i].ItemID = ""
+			report.Rows[i].Error = err.Error()
+		}
+	}
+}
+
+// insertImportedItems adds prepared items to a list and their watches to the
+// history, then saves both. The history is saved first and put back if the
+// list can't be saved, so an error means nothing was imported. The caller
+// must hold the service lock.
+func (s *WatchlistService) insertImportedItems(report *models.ImportReport, list *models.Watchlist, history *models.WatchHistory, prepared map[int]*models.WatchlistItem) error {
+	originalEvents := len(history.Events)
+	for i := range report.Rows {
+		item, ok := prepared[i]
+		if !ok {
+			continue
+		}
+		result := &report.Rows[i]
+
+		if err := s.insertItem(list, item); err != nil {
+			result.Status = models.ImportStatusDuplicate
+			continue
+		}
+		result.Imported = true
+		result.ItemID = item.ID
+
+		if result.Row.Watched {
+			input := models.WatchEventInput{WatchedAt: result.Row.WatchedAt}
+			event, err := s.newWatchEvent(item, input)
+			if err != nil {
+				// An unusable date still records the watch
+				event, err = s.newWatchEvent(item, models.WatchEventInput{})
+			}
+			if err == nil {
+				history.Events = append(history.Events, event)
+			}
+		}
+	}
+
+	now := time.Now()
+	history.UpdatedAt = now
+	if err := s.saveWatchHistory(history); err != nil {
+		return err
+	}
+	list.UpdatedAt = now
+	if err := s.saveWatchlist(list); err != nil {
+		history.Events = history.Events[:originalEvents]
+		if restoreErr := s.saveWatchHistory(history); restoreErr != nil {
+			s.logger.Error("Failed to roll back imported watches for user %s: %v", report.UserID, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// applyImportedData copies a row's personal data onto a new item. Ratings
+// that don't fit their scale are dropped. Tags that can't be kept are
+// reported in the returned error; the item is still imported.
+func (s *WatchlistService) applyImportedData(item *models.WatchlistItem, row models.ImportRow) error {
+	item.UserNotes = row.Notes
+	var tagErr error
+	if len(row.Tags) > 0 {
+		tags, err := normalizeTags(row.Tags)
+		if err == nil {
+			if err = addItemTags(item, tags); err != nil {
+				item.Tags = item.Tags[:maxTagsPerItem]
+			}
+		}
+		if err != nil {
+			tagErr = fmt.Errorf("not every tag was imported: %w", err)
+		}
+	}
+	if row.Rating != nil && validateRating(*row.Rating, row.RatingScale) == nil {
+		ratedAt := time.Now()
+		if row.WatchedAt != nil {
+			ratedAt = *row.WatchedAt
+		}
+		item.PersonalRating = &models.PersonalRating{
+			Value:   *row.Rating,
+			Scale:   row.RatingScale,
+			RatedAt: ratedAt,
+		}
+	}
+	return tagErr
+}
+
+// importCandidates converts TMDB search or find results to candidates
+func importCandidates(results interface{}, mediaType string) []models.ImportCandidate {
+	list, _ := results.([]interface{})
+	candidates := make([]models.ImportCandidate, 0, len(list))
+	for _, r := range list {
+		result, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		candidate := models.ImportCandidate{
+			MediaType:  mediaType,
+			ID:         int(numberField(result, "id")),
+			PosterPath: stringField(result, "poster_path"),
+		}
+		if mediaType == models.MediaTypeTV {
+			candidate.Title = stringField(result, "name")
+			candidate.Year = yearOf(stringField(result, "first_air_date"))
+		} else {
+			candidate.Title = stringField(result, "title")
+			candidate.Year = yearOf(stringField(result, "release_date"))
+		}
+		if candidate.ID > 0 {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// normalizeImportTitle lowercases a title and drops ASCII punctuation and extra
+// spaces, so "Se7en:" and "se7en" compare equal
+func normalizeImportTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if r == ' ' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r > 127 {
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// importRowByLine finds a row of a report by its line in the import file
+func importRowByLine(report *models.ImportReport, line int) *models.ImportRowResult {
+	for i := range report.Rows {
+		if report.Rows[i].Row.Line == line {
+			return &report.Rows[i]
+		}
+	}
+	return nil
+}
+
+// countImport recomputes a report's totals from its rows
+func countImport(report *models.ImportReport) {
+	report.Matched, report.Ambiguous, report.Unmatched, report.Duplicates, report.Imported = 0, 0, 0, 0, 0
+	for _, result := range report.Rows {
+		switch result.Status {
+		case models.ImportStatusMatched:
+			report.Matched++
+		case models.ImportStatusAmbiguous:
+			report.Ambiguous++
+		case models.ImportStatusUnmatched:
+			report.Unmatched++
+		case models.ImportStatusDuplicate:
+			report.Duplicates++
+		}
+		if result.Imported {
+			report.Imported++
+		}
+	}
+}
+
+// importFilePath returns where an import report is stored
+func (s *WatchlistService) importFilePath(userID, importID string) string {
+	return filepath.Join(s.dataDir, fmt.Sprintf("import_%s_%s.json", userID, importID))
+}
+
+// loadImport reads an import report, rejecting expired ones
+func (s *WatchlistService) loadImport(userID, importID string) (*models.ImportReport, error) {
+	data, err := ioutil.ReadFile(s.importFilePath(userID, importID))
+	if os.IsNotExist(err) {
+		return nil, ErrImportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import: %w", err)
+	}
+
+	var report models.ImportReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal import: %w", err)
+	}
+	if report.UserID != userID {
+		return nil, ErrImportNotFound
+	}
+	if time.Now().After(report.ExpiresAt) {
+		os.Remove(s.importFilePath(userID, importID))
+		return nil, fmt.Errorf("import has expired: %w", ErrImportNotFound)
+	}
+
+	return &report, nil
+}
+
+// removeExpiredImports deletes a user's import reports that can no longer be confirmed
+func (s *WatchlistService) removeExpiredImports(userID string) {
+	files, err := ioutil.ReadDir(s.dataDir)
+	if err != nil {
+		return
+	}
+
+	prefix := fmt.Sprintf("import_%s_", userID)
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		// loadImport removes the file when the report has expired
+		s.loadImport(userID, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".json"))
+	}
+}
+
+// saveImport saves an import report so it can be confirmed later
+func (s *WatchlistService) saveImport(report *models.ImportReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal import: %w", err)
+	}
+
+	if err := ioutil.WriteFile(s.importFilePath(report.UserID, report.ID), data, 0644); err != nil {
+		return fmt.Errorf("failed to save import: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// maxImportRows bounds the size of one import; every row may need a TMDB lookup
+const maxImportRows = 2000
+
+// parseImport reads the rows of an import file. An empty format is detected
+// from the content: JSON other than our own exports is taken as a Trakt
+// export and CSV files are told apart by their header.
+func parseImport(data []byte, format string) (string, []models.ImportRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if format == "" {
+		format = detectImportFormat(data)
+	}
+
+	var rows []models.ImportRow
+	var err error
+	switch format {
+	case models.ImportFormatTrakt:
+		rows, err = parseTrakt(data)
+	case models.ImportFormatCSV, models.ImportFormatIMDb, models.ImportFormatLetterboxd:
+		rows, err = parseImportCSV(data, format)
+	default:
+		return "", nil, invalidf("unsupported import format %q", format)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(rows) == 0 {
+		return "", nil, invalidf("no titles found in %s import", format)
+	}
+	if len(rows) > maxImportRows {
+		return "", nil, invalidf("an import can have at most %d rows", maxImportRows)
+	}
+	return format, rows, nil
+}
+
+// detectImportFormat guesses the format of an import file
+func detectImportFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	header := string(trimmed)
+	if i := strings.IndexByte(header, '\n'); i != -1 {
+		header = header[:i]
+	}
+
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		if strings.Contains(header, `"type":"`+models.NDJSONRecordHeader+`"`) {
+			return models.ImportFormatNDJSON
+		}
+		if bytes.Contains(trimmed, []byte(`"export_schema_version"`)) {
+			return models.ImportFormatJSON
+		}
+	}
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return models.ImportFormatTrakt
+	}
+
+	header = strings.ToLower(header)
+	switch {
+	case strings.Contains(header, "letterboxd uri"):
+		return models.ImportFormatLetterboxd
+	case strings.Contains(header, "const") && strings.Contains(header, "title type"):
+		return models.ImportFormatIMDb
+	default:
+		return models.ImportFormatCSV
+	}
+}
+
+// parseImportCSV reads a CSV import, mapping columns by header name
+func parseImportCSV(data []byte, format string) ([]models.ImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, invalidf("failed to read CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, invalidf("the file is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	titleColumn := "title"
+	if format == models.ImportFormatLetterboxd {
+		titleColumn = "name"
+	}
+	if _, ok := columns[titleColumn]; !ok {
+		return nil, invalidf("%s CSV has no %q column", format, titleColumn)
+	}
+
+	var rows []models.ImportRow
+	for i, record := range records[1:] {
+		row := models.ImportRow{
+			Line:  i + 2,
+			Title: field(record, titleColumn),
+		}
+		if row.Title == "" {
+			continue
+		}
+
+		switch format {
+		case models.ImportFormatCSV:
+			row.Year = yearOf(field(record, "release date"))
+			row.MediaType = models.MediaTypeMovie
+			if field(record, "type") == mediaTypeLabel(models.MediaTypeTV) {
+				row.MediaType = models.MediaTypeTV
+			}
+			row.Watched = strings.EqualFold(field(record, "status"), "watched")
+			row.WatchedAt = parseImportDate(field(record, "watched date"))
+			row.Notes = field(record, "notes")
+
+		case models.ImportFormatIMDb:
+			row.IMDbID = field(record, "const")
+			row.Year = yearOf(field(record, "year"))
+			row.MediaType = imdbMediaType(field(record, "title type"))
+			row.Notes = field(record, "description")
+			// A personal rating means the title was seen
+			if rating, err := strconv.ParseFloat(field(record, "your rating"), 64); err == nil && rating > 0 {
+				row.Rating = &rating
+				row.RatingScale = models.RatingScaleTen
+				row.Watched = true
+				row.WatchedAt = parseImportDate(field(record, "date rated"))
+			}
+
+		case models.ImportFormatLetterboxd:
+			// Letterboxd only tracks films. Diary and ratings exports have
+			// watched dates or ratings; a plain watchlist export has neither.
+			row.Year = yearOf(field(record, "year"))
+			row.MediaType = models.MediaTypeMovie
+			row.Notes = field(record, "review")
+			if watched := parseImportDate(field(record, "watched date")); watched != nil {
+				row.Watched = true
+				row.WatchedAt = watched
+			}
+			if rating, err := strconv.ParseFloat(field(record, "rating"), 64); err == nil && rating > 0 {
+				row.Rating = &rating
+				row.RatingScale = models.RatingScaleFive
+				row.Watched = true
+				if row.WatchedAt == nil {
+					row.WatchedAt = parseImportDate(field(record, "date"))
+				}
+			}
+			for _, tag := range strings.Split(field(record, "tags"), ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					row.Tags = append(row.Tags, tag)
+				}
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// traktEntry is one entry of a Trakt watchlist, history or ratings export
+type traktEntry struct {
+	Type      string      `json:"type"`
+	WatchedAt string      `json:"watched_at"`
+	RatedAt   string      `json:"rated_at"`
+	Rating    float64     `json:"rating"`
+	Notes     string      `json:"notes"`
+	Movie     *traktTitle `json:"movie"`
+	Show      *traktTitle `json:"show"`
+}
+
+type traktTitle struct {
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+	IDs   struct {
+		TMDB int    `json:"tmdb"`
+		IMDb string `json:"imdb"`
+	} `json:"ids"`
+}
+
+// parseTrakt reads a Trakt JSON export. Episode and season entries are
+// imported as their show, without a watch.
+func parseTrakt(data []byte) ([]models.ImportRow, error) {
+	var entries []traktEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, invalidf("failed to read Trakt export, expected a JSON array: %v", err)
+	}
+
+	var rows []models.ImportRow
+	for i, entry := range entries {
+		title := entry.Movie
+		row := models.ImportRow{Line: i + 1, MediaType: models.MediaTypeMovie}
+		if title == nil {
+			title = entry.Show
+			row.MediaType = models.MediaTypeTV
+		}
+		if title == nil {
+			continue
+		}
+
+		row.Title = title.Title
+		row.Year = title.Year
+		row.TMDBID = title.IDs.TMDB
+		row.IMDbID = title.IDs.IMDb
+		row.Notes = entry.Notes
+
+		whole := entry.Type == "" || entry.Type == "movie" || entry.Type == "show"
+		if whole && entry.WatchedAt != "" {
+			row.Watched = true
+			row.WatchedAt = parseImportDate(entry.WatchedAt)
+		}
+		if whole && entry.Rating > 0 {
+			rating := entry.Rating
+			row.Rating = &rating
+			row.RatingScale = models.RatingScaleTen
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// imdbMediaType maps an IMDb title type to a media type
+func imdbMediaType(titleType string) string {
+	switch strings.ToLower(strings.ReplaceAll(titleType, " ", "")) {
+	case "tvseries", "tvminiseries", "tvmini-series":
+		return models.MediaTypeTV
+	}
+	return models.MediaTypeMovie
+}
+
+// yearOf returns the year at the start of a date or year string, or 0
+func yearOf(value string) int {
+	if len(value) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(value[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
+
+// parseImportDate parses the date formats found in import files
+func parseImportDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestParseImportDetectsFormats(t *testing.T) {
+	imdb := "Position,Const,Created,Modified,Description,Title,URL,Title Type,IMDb Rating,Runtime (mins),Year,Genres,Num Votes,Release Date,Directors,Your Rating,Date Rated\n" +
+		"1,tt0078748,2024-01-01,2024-01-01,,Alien,https://www.imdb.com/title/tt0078748/,Movie,8.5,117,1979,Horror,900000,1979-05-25,Ridley Scott,9,2024-02-03\n" +
+		"2,tt5753856,2024-01-01,2024-01-01,,Dark,https://www.imdb.com/title/tt5753856/,TV Series,8.7,60,2017,Drama,400000,2017-12-01,,,\n"
+	format, rows, err := parseImport([]byte(imdb), "")
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportFormatIMDb, format)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "tt0078748", rows[0].IMDbID)
+	assert.True(t, rows[0].Watched)
+	assert.Equal(t, 9.0, *rows[0].Rating)
+	assert.Equal(t, models.MediaTypeTV, rows[1].MediaType)
+	assert.False(t, rows[1].Watched)
+
+	letterboxd := "\xef\xbb\xbfDate,Name,Year,Letterboxd URI,Rating,Rewatch,Tags,Watched Date\n" +
+		"2024-03-01,Heat,1995,https://boxd.it/abc,4.5,,\"crime, rewatch\",2024-02-28\n"
+	format, rows, err = parseImport([]byte(letterboxd), "")
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportFormatLetterboxd, format)
+	require.Len(t, rows, 1)
+	assert.Equal(t, 1995, rows[0].Year)
+	assert.Equal(t, []string{"crime", "rewatch"}, rows[0].Tags)
+	assert.Equal(t, models.RatingScaleFive, rows[0].RatingScale)
+	assert.Equal(t, "2024-02-28", rows[0].WatchedAt.Format("2006-01-02"))
+
+	trakt := `[{"type":"movie","watched_at":"2024-01-02T20:00:00.000Z","movie":{"title":"Alien","year":1979,"ids":{"tmdb":348,"imdb":"tt0078748"}}},
+		{"type":"episode","watched_at":"2024-01-03T20:00:00.000Z","show":{"title":"Dark","year":2017,"ids":{"tmdb":70523}}}]`
+	format, rows, err = parseImport([]byte(trakt), "")
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportFormatTrakt, format)
+	require.Len(t, rows, 2)
+	assert.Equal(t, 348, rows[0].TMDBID)
+	assert.True(t, rows[0].Watched)
+	assert.Equal(t, models.MediaTypeTV, rows[1].MediaType)
+	assert.False(t, rows[1].Watched, "an episode watch does not mark the whole show watched")
+
+	own := "Title,Type,Release Date,Genre,Rating,Status,Added Date,Watched Date,Notes,Overview,Seasons,Episodes\n" +
+		"Dark,TV Show,2017-12-01,Drama,8.4,Watched,2024-01-01,2024-01-05,great,,3,26\n"
+	format, rows, err = parseImport([]byte(own), "")
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportFormatCSV, format)
+	require.Len(t, rows, 1)
+	assert.Equal(t, models.MediaTypeTV, rows[0].MediaType)
+	assert.Equal(t, 2017, rows[0].Year)
+	assert.Equal(t, "great", rows[0].Notes)
+}
+
+func TestPickImportMatch(t *testing.T) {
+	candidates := []models.ImportCandidate{
+		{ID: 1, Title: "Heat", Year: 1995},
+		{ID: 2, Title: "Heat", Year: 1986},
+		{ID: 3, Title: "Heat Wave", Year: 1995},
+	}
+
+	match, _ := pickImportMatch(models.ImportRow{Title: "heat", Year: 1995}, candidates)
+	require.NotNil(t, match)
+	assert.Equal(t, 1, match.ID)
+
+	match, offered := pickImportMatch(models.ImportRow{Title: "Heat"}, candidates)
+	assert.Nil(t, match, "two exact titles without a year are ambiguous")
+	assert.Len(t, offered, 2)
+
+	match, _ = pickImportMatch(models.ImportRow{Title: "Heat", Year: 1987}, candidates)
+	require.NotNil(t, match, "a year off by one still matches")
+	assert.Equal(t, 2, match.ID)
+
+	match, offered = pickImportMatch(models.ImportRow{Title: "Heat", Year: 2020}, candidates)
+	assert.Nil(t, match)
+	assert.Empty(t, offered)
+}
+
+func TestConfirmImportResolvesAmbiguousRows(t *testing.T) {
+	responses := fakeProvider{
+		"/3/search/movie": `{"results": [
+			{"id": 1, "title": "Heat", "release_date": "1995-12-15"},
+			{"id": 2, "title": "Heat", "release_date": "1986-03-14"}]}`,
+	}
+	for path, body := range heatResponses {
+		responses[path] = body
+	}
+	s := withProvider(newTestService(t, nil), responses)
+
+	letterboxd := "Date,Name,Year,Letterboxd URI,Rating,Rewatch,Tags,Watched Date\n" +
+		"2024-03-01,Heat,,https://boxd.it/abc,4.5,,crime,2024-02-28\n"
+	report, err := s.ImportWatchlist("user", "", "", []byte(letterboxd), false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Ambiguous)
+	assert.Equal(t, 0, report.Imported)
+	require.Len(t, report.Rows[0].Candidates, 2)
+
+	// Two confirmations at once import the row only once
+	line := report.Rows[0].Row.Line
+	confirmation := models.ImportConfirmation{Choices: []models.ImportChoice{{Line: line, ID: 1}}}
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := s.ConfirmImport("user", report.ID, confirmation)
+			errs <- err
+		}()
+	}
+	failed := 0
+	for i := 0; i < 2; i++ {
+		if <-errs != nil {
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed, "the second confirmation finds the row imported")
+
+	report, err = s.GetImport("user", report.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 0, report.Ambiguous)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	require.Len(t, watchlist.Items, 1)
+	assert.Equal(t, "Heat", watchlist.Items[0].Title)
+	assert.Equal(t, []string{"crime"}, watchlist.Items[0].Tags)
+	assert.True(t, watchlist.Items[0].IsWatched)
+}
+
+func TestImportReportsDroppedTagsAndFailedSaves(t *testing.T) {
+	responses := fakeProvider{
+		"/3/search/movie": `{"results": [{"id": 1, "title": "Heat", "release_date": "1995-12-15"}]}`,
+	}
+	for path, body := range heatResponses {
+		responses[path] = body
+	}
+	s := withProvider(newTestService(t, nil), responses)
+
+	var tags []string
+	for i := 0; i <= maxTagsPerItem; i++ {
+		tags = append(tags, fmt.Sprintf("tag%d", i))
+	}
+	letterboxd := "Date,Name,Year,Letterboxd URI,Rating,Rewatch,Tags,Watched Date\n" +
+		"2024-03-01,Heat,1995,https://boxd.it/abc,4.5,,\"" + strings.Join(tags, ",") + "\",2024-02-28\n"
+
+	// A directory in the way of the list's temporary file makes its save fail
+	tmp := s.listFilePath("user", "") + ".tmp"
+	require.NoError(t, os.Mkdir(tmp, 0755))
+	report, err := s.ImportWatchlist("user", "", "", []byte(letterboxd), false)
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 1)
+	assert.False(t, report.Rows[0].Imported)
+	assert.NotEmpty(t, report.Rows[0].Error)
+	history, err := s.GetWatchHistory("user", "")
+	require.NoError(t, err)
+	assert.Empty(t, history, "the watches of a failed import are rolled back")
+
+	require.NoError(t, os.RemoveAll(tmp))
+	report, err = s.ConfirmImport("user", report.ID, models.ImportConfirmation{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported, "a failed import can be retried")
+	assert.Contains(t, report.Rows[0].Warning, "at most")
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	require.Len(t, watchlist.Items, 1)
+	assert.Len(t, watchlist.Items[0].Tags, maxTagsPerItem)
+	assert.Equal(t, 1, watchlist.Items[0].WatchCount)
+
+	_, err = s.ConfirmImport("user", "missing", models.ImportConfirmation{})
+	assert.ErrorIs(t, err, ErrImportNotFound)
+	_, err = s.ConfirmImport("user", report.ID, models.ImportConfirmation{Choices: []models.ImportChoice{{Line: 99, ID: 1}}})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.ImportWatchlist("user", "missing", "", []byte(letterboxd), false)
+	assert.ErrorIs(t, err, ErrListNotFound)
+	_, err = s.ImportWatchlist("user", "", "csv", []byte(""), false)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
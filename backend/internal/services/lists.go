@@ -0,0 +1,403 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// defaultListName is the name given to a user's default list
+const defaultListName = "Watchlist"
+
+// listRef identifies a stored list
+type listRef struct {
+	userID string
+	listID string
+}
+
+// GetLists returns summaries of all of a user's lists, in display order.
+// The default list is always included.
+func (s *WatchlistService) GetLists(userID string) ([]models.ListSummary, error) {
+	lists, err := s.userLists(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]models.ListSummary, 0, len(lists))
+	for _, list := range lists {
+		summaries = append(summaries, models.ListSummary{
+			ID:          list.ID,
+			Name:        list.Name,
+			Description: list.Description,
+			Icon:        list.Icon,
+			Position:    list.Position,
+			IsDefault:   list.IsDefault,
+			ItemCount:   len(list.Items),
+			CreatedAt:   list.CreatedAt,
+			UpdatedAt:   list.UpdatedAt,
+		})
+	}
+
+	return summaries, nil
+}
+
+// CreateList creates a new named list for a user, placed after the existing ones
+func (s *WatchlistService) CreateList(userID string, input models.ListInput) (*models.Watchlist, error) {
+	if input.Name == nil || strings.TrimSpace(*input.Name) == "" {
+		return nil, invalidf("list name is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lists, err := s.userLists(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	list := &models.Watchlist{
+		SchemaVersion: models.CurrentWatchlistSchemaVersion,
+		ID:            s.generateID(),
+		UserID:        userID,
+		Name:          strings.TrimSpace(*input.Name),
+		Position:      len(lists),
+		Items:         []models.WatchlistItem{},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if input.Description != nil {
+		list.Description = *input.Description
+	}
+	if input.Icon != nil {
+		list.Icon = *input.Icon
+	}
+
+	if err := s.saveWatchlist(list); err != nil {
+		return nil, err
+	}
+
+	if input.Position != nil {
+		if err := s.reorderLists(userID, list.ID, *input.Position); err != nil {
+			return nil, err
+		}
+		return s.GetList(userID, list.ID)
+	}
+
+	return list, nil
+}
+
+// UpdateList changes a list's name, description, icon or position
+func (s *WatchlistService) UpdateList(userID, listID string, input models.ListInput) (*models.Watchlist, error) {
+	if input.Name != nil && strings.TrimSpace(*input.Name) == "" {
+		return nil, invalidf("list name cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Name != nil {
+		list.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		list.Description = *input.Description
+	}
+	if input.Icon != nil {
+		list.Icon = *input.Icon
+	}
+	list.UpdatedAt = time.Now()
+
+	if err := s.saveWatchlist(list); err != nil {
+		return nil, err
+	}
+
+	if input.Position != nil {
+		if err := s.reorderLists(userID, list.ID, *input.Position); err != nil {
+			return nil, err
+		}
+		return s.GetList(userID, list.ID)
+	}
+
+	return list, nil
+}
+
+// DeleteList deletes a named list, moving its items to the trash.
+// The default list cannot be deleted.
+func (s *WatchlistService) DeleteList(userID, listID string) error {
+	if listID == "" || listID == models.DefaultListID {
+		return invalidf("the default list cannot be deleted")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.GetList(userID, listID)
+	if err != nil {
+		return err
+	}
+
+	// The list's items can still be restored from the trash, into the default list
+	previous, err := s.moveToTrash(userID, list, list.Items...)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.listFilePath(userID, listID)); err != nil {
+		s.restoreTrash(previous)
+		return fmt.Errorf("failed to delete list: %w", err)
+	}
+	if err := s.deleteVersions(userID, listID); err != nil {
+		s.logger.Warning("Failed to delete versions of list %s for user %s: %v", listID, userID, err)
+	}
+	if err := s.deleteCollaboration(userID, listID); err != nil {
+		s.logger.Warning("Failed to delete collaboration on list %s for user %s: %v", listID, userID, err)
+	}
+
+	s.logger.Success("List %s deleted for user %s", listID, userID)
+	return nil
+}
+
+// MoveItem moves an item from one of the user's lists to another
+func (s *WatchlistService) MoveItem(userID, fromListID, itemID, toListID string) (*models.WatchlistItem, error) {
+	return s.transferItem(userID, fromListID, itemID, toListID, true)
+}
+
+// CopyItem copies an item from one of the user's lists to another
+func (s *WatchlistService) CopyItem(userID, fromListID, itemID, toListID string) (*models.WatchlistItem, error) {
+	return s.transferItem(userID, fromListID, itemID, toListID, false)
+}
+
+// transferItem copies an item between lists, removing it from the source if move is set.
+// Both lists are updated under the same lock, and a failed save rolls the move back.
+func (s *WatchlistService) transferItem(userID, fromListID, itemID, toListID string, move bool) (*models.WatchlistItem, error) {
+	if fromListID == "" {
+		fromListID = models.DefaultListID
+	}
+	if toListID == "" {
+		toListID = models.DefaultListID
+	}
+	if fromListID == toListID {
+		return nil, invalidf("source and target lists are the same")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, err := s.GetList(userID, fromListID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.GetList(userID, toListID)
+	if err != nil {
+		return nil, err
+	}
+
+	original := *source
+	original.Items = append([]models.WatchlistItem(nil), source.Items...)
+
+	copied, err := s.transferBetween(source, target, itemID, move)
+	if err != nil {
+		return nil, err
+	}
+
+	// The source is saved first so that a failed save can never leave the item
+	// in both lists; if the target then fails, the source is put back. Versions
+	// are only recorded once both are written.
+	now := time.Now()
+	if move {
+		source.UpdatedAt = now
+		if err := s.writeWatchlist(source); err != nil {
+			return nil, err
+		}
+	}
+
+	target.UpdatedAt = now
+	if err := s.saveWatchlist(target); err != nil {
+		if move {
+			if restoreErr := s.writeWatchlist(&original); restoreErr != nil {
+				s.logger.Error("Failed to restore list %s for user %s: %v", original.ID, userID, restoreErr)
+			}
+		}
+		return nil, err
+	}
+	if move {
+		s.saveVersion(source)
+	}
+
+	return copied, nil
+}
+
+// transferBetween copies an item from source to the end of target in memory,
+// removing it from source if move is set. Moving keeps the item's id and added date.
+func (s *WatchlistService) transferBetween(source, target *models.Watchlist, itemID string, move bool) (*models.WatchlistItem, error) {
+	item := findItem(source, itemID)
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+
+	copied := *item
+	copied.Tags = append([]string(nil), item.Tags...)
+	if err := s.insertItem(target, &copied); err != nil {
+		return nil, err
+	}
+	if !move {
+		return &copied, nil
+	}
+
+	last := &target.Items[len(target.Items)-1]
+	last.ID = item.ID
+	last.AddedAt = item.AddedAt
+	copied = *last
+
+	removeItem(source, itemID)
+	return &copied, nil
+}
+
+// reorderLists moves a list to the given position and renumbers the rest.
+// The caller must hold the service lock.
+func (s *WatchlistService) reorderLists(userID, listID string, position int) error {
+	lists, err := s.userLists(userID)
+	if err != nil {
+		return err
+	}
+
+	var moved *models.Watchlist
+	rest := make([]*models.Watchlist, 0, len(lists))
+	for _, list := range lists {
+		if list.ID == listID {
+			moved = list
+		} else {
+			rest = append(rest, list)
+		}
+	}
+	if moved == nil {
+		return ErrListNotFound
+	}
+
+	if position < 0 {
+		position = 0
+	}
+	if position > len(rest) {
+		position = len(rest)
+	}
+	ordered := append(rest[:position:position], append([]*models.Watchlist{moved}, rest[position:]...)...)
+
+	for i, list := range ordered {
+		if list.Position == i {
+			continue
+		}
+		list.Position = i
+		if err := s.writeWatchlist(list); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// userLists loads all of a user's lists, sorted by position
+func (s *WatchlistService) userLists(userID string) ([]*models.Watchlist, error) {
+	defaultList, err := s.GetWatchlist(userID)
+	if err != nil {
+		return nil, err
+	}
+	lists := []*models.Watchlist{defaultList}
+
+	files, err := ioutil.ReadDir(s.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %w", err)
+	}
+
+	prefix := fmt.Sprintf("list_%s_", userID)
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), prefix) || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		// The prefix can also match users whose id extends this one, so check the owner
+		data, err := ioutil.ReadFile(filepath.Join(s.dataDir, file.Name()))
+		if err != nil {
+			continue
+		}
+		var header struct {
+			ID     string `json:"id"`
+			UserID string `json:"user_id"`
+		}
+		if err := json.Unmarshal(data, &header); err != nil || header.UserID != userID {
+			continue
+		}
+
+		list, err := s.GetList(userID, header.ID)
+		if err != nil {
+			s.logger.Warning("Skipping list %s for user %s: %v", header.ID, userID, err)
+			continue
+		}
+		lists = append(lists, list)
+	}
+
+	sort.SliceStable(lists, func(i, j int) bool {
+		if lists[i].Position != lists[j].Position {
+			return lists[i].Position < lists[j].Position
+		}
+		return lists[i].CreatedAt.Before(lists[j].CreatedAt)
+	})
+
+	return lists, nil
+}
+
+// findUserItem finds an item in any of the user's lists
+func (s *WatchlistService) findUserItem(userID, itemID string) (*models.WatchlistItem, error) {
+	_, item, err := s.findUserListItem(userID, itemID)
+	return item, err
+}
+
+// findUserListItem finds an item in any of the user's lists, along with the
+// list that holds it
+func (s *WatchlistService) findUserListItem(userID, itemID string) (*models.Watchlist, *models.WatchlistItem, error) {
+	lists, err := s.userLists(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, list := range lists {
+		if item := findItem(list, itemID); item != nil {
+			return list, item, nil
+		}
+	}
+	return nil, nil, ErrItemNotFound
+}
+
+// updateUserItem applies fn to an item in whichever of the user's lists holds
+// it and saves that list, all under the service lock. Nothing is saved if fn fails.
+func (s *WatchlistService) updateUserItem(userID, itemID string, fn func(*models.WatchlistItem) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lists, err := s.userLists(userID)
+	if err != nil {
+		return err
+	}
+	for _, list := range lists {
+		item := findItem(list, itemID)
+		if item == nil {
+			continue
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+		list.UpdatedAt = time.Now()
+		return s.saveWatchlist(list)
+	}
+	return ErrItemNotFound
+}
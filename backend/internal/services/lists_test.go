@@ -0,0 +1,111 @@
+package services
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestListCRUD(t *testing.T) {
+	s := newTestService(t, nil)
+	name := func(v string) *string { return &v }
+
+	_, err := s.CreateList("user", models.ListInput{Name: name("  ")})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	halloween, err := s.CreateList("user", models.ListInput{Name: name(" Halloween "), Icon: name("🎃")})
+	require.NoError(t, err)
+	assert.Equal(t, "Halloween", halloween.Name)
+	first := 0
+	dateNight, err := s.CreateList("user", models.ListInput{Name: name("Date night"), Position: &first})
+	require.NoError(t, err)
+
+	lists, err := s.GetLists("user")
+	require.NoError(t, err)
+	require.Len(t, lists, 3)
+	assert.Equal(t, []string{dateNight.ID, models.DefaultListID, halloween.ID}, []string{lists[0].ID, lists[1].ID, lists[2].ID})
+
+	description := "Scary movies"
+	updated, err := s.UpdateList("user", halloween.ID, models.ListInput{Description: &description})
+	require.NoError(t, err)
+	assert.Equal(t, "Halloween", updated.Name, "nil fields are left as they are")
+	assert.Equal(t, "Scary movies", updated.Description)
+	_, err = s.UpdateList("user", halloween.ID, models.ListInput{Name: name("")})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.UpdateList("user", "missing", models.ListInput{Description: &description})
+	assert.ErrorIs(t, err, ErrListNotFound)
+
+	assert.ErrorIs(t, s.DeleteList("user", models.DefaultListID), ErrInvalidInput)
+	require.NoError(t, s.DeleteList("user", dateNight.ID))
+	_, err = s.GetList("user", dateNight.ID)
+	assert.ErrorIs(t, err, ErrListNotFound)
+}
+
+func TestMoveAndCopyItems(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", Tags: []string{"crime"}},
+		{ID: "movie_2", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Ronin"},
+	})
+	listName := "Heists"
+	heists, err := s.CreateList("user", models.ListInput{Name: &listName})
+	require.NoError(t, err)
+
+	copied, err := s.CopyItem("user", "", "movie_1", heists.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, "movie_1", copied.ID, "copies get a new id")
+	assert.Equal(t, []string{"crime"}, copied.Tags)
+
+	_, err = s.CopyItem("user", "", "movie_1", heists.ID)
+	assert.ErrorIs(t, err, ErrItemExists)
+	_, err = s.CopyItem("user", "", "movie_1", models.DefaultListID)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.CopyItem("user", "", "missing", heists.ID)
+	assert.ErrorIs(t, err, ErrItemNotFound)
+	_, err = s.MoveItem("user", "", "movie_2", "missing")
+	assert.ErrorIs(t, err, ErrListNotFound)
+
+	moved, err := s.MoveItem("user", "", "movie_2", heists.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "movie_2", moved.ID, "moving keeps the id")
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	require.Len(t, watchlist.Items, 1)
+	assert.Equal(t, "movie_1", watchlist.Items[0].ID)
+
+	list, err := s.GetList("user", heists.ID)
+	require.NoError(t, err)
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, "Heat", list.Items[0].Title)
+	assert.Equal(t, "Ronin", list.Items[1].Title)
+
+	// Deleting a list sends its items to the trash
+	require.NoError(t, s.DeleteList("user", heists.ID))
+	trash, err := s.GetTrash("user")
+	require.NoError(t, err)
+	assert.Len(t, trash, 2)
+}
+
+func TestFailedMoveRecordsNoVersion(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+	listName := "Heists"
+	heists, err := s.CreateList("user", models.ListInput{Name: &listName})
+	require.NoError(t, err)
+
+	// A directory in the way of the target's temporary file makes its save fail
+	require.NoError(t, os.Mkdir(s.listFilePath("user", heists.ID)+".tmp", 0755))
+	_, err = s.MoveItem("user", "", "movie_1", heists.ID)
+	require.Error(t, err)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Len(t, watchlist.Items, 1, "the source is put back")
+	versions, err := s.GetVersions("user", "")
+	require.NoError(t, err)
+	assert.Len(t, versions, 1, "the rolled back source is not a version")
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/api"
+	"r.a.w/backend/internal/models"
+)
+
+// maxCastMembers is how many top-billed actors are kept per item
+const maxCastMembers = 5
+
+// fetchMetadata fills an item's provider metadata from TMDB and OMDB, based on
+// its MediaType and MovieID. Personal fields (notes, watched state) are untouched.
+func (s *WatchlistService) fetchMetadata(item *models.WatchlistItem) error {
+	if s.movieService == nil {
+		return fmt.Errorf("metadata provider is unavailable")
+	}
+
+	var data *api.CombinedMovieData
+	var err error
+	if item.IsTV() {
+		data, err = s.movieService.GetTVDetails(item.MovieID, "")
+	} else {
+		data, err = s.movieService.GetMovieDetails(item.MovieID, "")
+	}
+	if errors.Is(err, api.ErrNotFound) || (err == nil && data.TMDBData == nil) {
+		return fmt.Errorf("%s %d: %w", item.MediaType, item.MovieID, ErrTitleNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s %d: %w", item.MediaType, item.MovieID, err)
+	}
+
+	tmdb := data.TMDBData
+	if item.IsTV() {
+		item.Title = stringField(tmdb, "name")
+		item.ReleaseDate = stringField(tmdb, "first_air_date")
+		item.NumberOfSeasons = int(numberField(tmdb, "number_of_seasons"))
+		item.NumberOfEpisodes = int(numberField(tmdb, "number_of_episodes"))
+		if runtimes, ok := tmdb["episode_run_time"].([]interface{}); ok && len(runtimes) > 0 {
+			if runtime, ok := runtimes[0].(float64); ok {
+				item.Runtime = int(runtime)
+			}
+		}
+		if externalIDs, ok := tmdb["external_ids"].(map[string]interface{}); ok {
+			item.IMDbID = stringField(externalIDs, "imdb_id")
+		}
+		item.Releases = tvReleases(tmdb)
+		item.Creators = namesOf(tmdb["created_by"], maxCastMembers)
+		// Credits are a separate request; keep the old ones if it fails
+		if credits, err := s.movieService.GetTVCredits(item.MovieID); err == nil {
+			item.Directors, item.Cast = creditNames(credits)
+		} else {
+			s.logger.Warning("Could not fetch credits for TV show %d: %v", item.MovieID, err)
+		}
+	} else {
+		item.Title = stringField(tmdb, "title")
+		item.ReleaseDate = stringField(tmdb, "release_date")
+		item.Runtime = int(numberField(tmdb, "runtime"))
+		item.IMDbID = stringField(tmdb, "imdb_id")
+		// Release dates are a separate request; keep the old ones if it fails
+		if dates, err := s.movieService.GetMovieReleaseDates(item.MovieID); err == nil {
+			item.Releases = movieReleases(dates)
+		} else {
+			s.logger.Warning("Could not fetch release dates for movie %d: %v", item.MovieID, err)
+		}
+		if credits, err := s.movieService.GetMovieCredits(item.MovieID); err == nil {
+			item.Directors, item.Cast = creditNames(credits)
+		} else {
+			s.logger.Warning("Could not fetch credits for movie %d: %v", item.MovieID, err)
+		}
+	}
+
+	item.PosterPath = stringField(tmdb, "poster_path")
+	item.Overview = stringField(tmdb, "overview")
+	item.Rating = numberField(tmdb, "vote_average")
+
+	var genres []string
+	if genreList, ok := tmdb["genres"].([]interface{}); ok {
+		for _, g := range genreList {
+			if genre, ok := g.(map[string]interface{}); ok {
+				if name := stringField(genre, "name"); name != "" {
+					genres = append(genres, name)
+				}
+			}
+		}
+	}
+	item.Genre = strings.Join(genres, ", ")
+
+	// OMDB fills the gaps TMDB leaves, mostly for TV shows
+	if omdb := data.OMDBData; omdb != nil {
+		if item.IMDbID == "" {
+			item.IMDbID = stringField(omdb, "imdbID")
+		}
+		if rating, err := strconv.ParseFloat(stringField(omdb, "imdbRating"), 64); err == nil {
+			item.IMDbRating = rating
+		}
+		if item.Runtime == 0 {
+			fmt.Sscanf(stringField(omdb, "Runtime"), "%d", &item.Runtime)
+		}
+	}
+
+	now := time.Now()
+	item.MetadataUpdatedAt = &now
+	return nil
+}
+
+// RefreshStaleMetadata re-fetches provider metadata for every item whose
+// metadata is older than maxAge. It returns the number of items refreshed.
+func (s *WatchlistService) RefreshStaleMetadata(maxAge time.Duration) (int, error) {
+	refs, err := s.storedLists()
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	now := time.Now()
+	cutoff := now.Add(-maxAge)
+	upcomingCutoff := cutoff
+	if maxAge > upcomingMetadataMaxAge {
+		upcomingCutoff = now.Add(-upcomingMetadataMaxAge)
+	}
+	for _, ref := range refs {
+		watchlist, err := s.GetList(ref.userID, ref.listID)
+		if err != nil {
+			s.logger.Warning("Skipping metadata refresh for list %s of user %s: %v", ref.listID, ref.userID, err)
+			continue
+		}
+
+		// Fetch outside the lock, then apply onto whatever is current
+		updates := make(map[string]models.WatchlistItem)
+		for _, item := range watchlist.Items {
+			itemCutoff := cutoff
+			if hasUpcomingRelease(item, now) {
+				itemCutoff = upcomingCutoff
+			}
+			if item.MetadataUpdatedAt != nil && item.MetadataUpdatedAt.After(itemCutoff) {
+				continue
+			}
+			if err := s.fetchMetadata(&item); err != nil {
+				s.logger.Warning("Could not refresh metadata for %s %d: %v", item.MediaType, item.MovieID, err)
+				continue
+			}
+			if item.IsTV() && item.Progress != nil && (item.NumberOfSeasons > len(item.Progress.Seasons) || item.NumberOfEpisodes != countEpisodes(item.Progress)) {
+				// New seasons or episodes aired; watched state is merged in applyMetadata
+				if progress, err := s.fetchTVProgress(&item); err == nil {
+					item.Progress = progress
+				}
+			}
+			updates[item.ID] = item
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		// Shows that gained episodes are no longer complete
+		var reopened []titleKey
+		err = s.refreshList(ref.userID, ref.listID, func(watchlist *models.Watchlist) error {
+			for i := range watchlist.Items {
+				item := &watchlist.Items[i]
+				if updated, ok := updates[item.ID]; ok {
+					wasCompleted := item.Progress != nil && summarizeTVProgress(item).IsCompleted
+					applyMetadata(item, updated)
+					if wasCompleted && !summarizeTVProgress(item).IsCompleted {
+						reopened = append(reopened, titleKey{item.MediaType, item.MovieID})
+					}
+					refreshed++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			s.logger.Error("Failed to save refreshed metadata for list %s of user %s: %v", ref.listID, ref.userID, err)
+			continue
+		}
+		if len(reopened) > 0 {
+			err := s.updateWatchHistory(ref.userID, func(history *models.WatchHistory) error {
+				for _, key := range reopened {
+					removeLatestCompletion(history, key)
+				}
+				return nil
+			})
+			if err != nil {
+				s.logger.Error("Failed to update watch history of user %s: %v", ref.userID, err)
+			}
+		}
+	}
+
+	return refreshed, nil
+}
+
+// StartMetadataRefresher runs RefreshStaleMetadata every interval until the
+// returned stop function is called.
+func (s *WatchlistService) StartMetadataRefresher(interval, maxAge time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				refreshed, err := s.RefreshStaleMetadata(maxAge)
+				if err != nil {
+					s.logger.Error("Metadata refresh failed: %v", err)
+				} else if refreshed > 0 {
+					s.logger.Success("Refreshed metadata for %d watchlist item(s)", refreshed)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// applyMetadata copies provider fields from src onto dst, keeping dst's personal data
+func applyMetadata(dst *models.WatchlistItem, src models.WatchlistItem) {
+	dst.Title = src.Title
+	dst.PosterPath = src.PosterPath
+	dst.ReleaseDate = src.ReleaseDate
+	dst.Genre = src.Genre
+	dst.Rating = src.Rating
+	dst.Overview = src.Overview
+	dst.IMDbID = src.IMDbID
+	dst.IMDbRating = src.IMDbRating
+	dst.Runtime = src.Runtime
+	dst.NumberOfSeasons = src.NumberOfSeasons
+	dst.NumberOfEpisodes = src.NumberOfEpisodes
+	dst.Releases = src.Releases
+	dst.Directors = src.Directors
+	dst.Cast = src.Cast
+	dst.Creators = src.Creators
+	dst.MetadataUpdatedAt = src.MetadataUpdatedAt
+	if src.Progress != nil && dst.Progress != nil && !sameEpisodes(src.Progress, dst.Progress) {
+		mergeTVProgress(src.Progress, dst.Progress)
+		dst.Progress = src.Progress
+	}
+}
+
+// sameEpisodes reports whether two progress records list the same episodes
+func sameEpisodes(a, b *models.TVProgress) bool {
+	if len(a.Seasons) != len(b.Seasons) {
+		return false
+	}
+	for i := range a.Seasons {
+		if a.Seasons[i].SeasonNumber != b.Seasons[i].SeasonNumber || len(a.Seasons[i].Episodes) != len(b.Seasons[i].Episodes) {
+			return false
+		}
+		for j := range a.Seasons[i].Episodes {
+			if a.Seasons[i].Episodes[j].EpisodeNumber != b.Seasons[i].Episodes[j].EpisodeNumber {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// countEpisodes returns how many episodes a progress record tracks
+func countEpisodes(progress *models.TVProgress) int {
+	count := 0
+	for _, sp := range progress.Seasons {
+		count += len(sp.Episodes)
+	}
+	return count
+}
+
+// mergeTVProgress copies watched state from old onto a freshly fetched
+// progress record, matching episodes by season and episode number
+func mergeTVProgress(fresh, old *models.TVProgress) {
+	for _, sp := range old.Seasons {
+		for _, ep := range sp.Episodes {
+			if !ep.Watched {
+				continue
+			}
+			if target := findEpisode(fresh, sp.SeasonNumber, ep.EpisodeNumber); target != nil {
+				target.Watched = true
+				target.WatchedAt = ep.WatchedAt
+			}
+		}
+	}
+}
+
+// creditNames returns the directors and top-billed cast of a TMDB credits
+// response
+func creditNames(credits map[string]interface{}) (directors, cast []string) {
+	if crew, ok := credits["crew"].([]interface{}); ok {
+		for _, c := range crew {
+			if member, ok := c.(map[string]interface{}); ok && stringField(member, "job") == "Director" {
+				directors = append(directors, stringField(member, "name"))
+			}
+		}
+	}
+	// Cast comes in billing order
+	return directors, namesOf(credits["cast"], maxCastMembers)
+}
+
+// namesOf returns the names of up to limit people in a decoded JSON array
+func namesOf(people interface{}, limit int) []string {
+	list, _ := people.([]interface{})
+	var names []string
+	for _, p := range list {
+		if len(names) == limit {
+			break
+		}
+		if person, ok := p.(map[string]interface{}); ok {
+			if name := stringField(person, "name"); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// stringField returns a string value from decoded JSON, or ""
+func stringField(data map[string]interface{}, key string) string {
+	if v, ok := data[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// numberField returns a numeric value from decoded JSON, or 0
+func numberField(data map[string]interface{}, key string) float64 {
+	if v, ok := data[key].(float64); ok {
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/api"
+	"r.a.w/backend/internal/models"
+)
+
+// fakeProvider answers TMDB and OMDB requests with canned JSON by URL path;
+// other paths are not found
+type fakeProvider map[string]string
+
+func (f fakeProvider) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, ok := f[req.URL.Path]
+	status := http.StatusOK
+	if !ok {
+		body, status = "{}", http.StatusNotFound
+	}
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}, Request: req}, nil
+}
+
+// withProvider makes s fetch metadata from responses
+func withProvider(s *WatchlistService, responses fakeProvider) *WatchlistService {
+	movieService := api.NewMovieService("tmdb-key", "omdb-key", s.logger)
+	movieService.TMDBClient.HTTPClient = &http.Client{Transport: responses}
+	movieService.OMDBClient.HTTPClient = &http.Client{Transport: responses}
+	s.movieService = movieService
+	return s
+}
+
+var heatResponses = fakeProvider{
+	"/3/movie/1": `{"title": "Heat", "release_date": "1995-12-15", "runtime": 170, "imdb_id": "tt0113277",
+		"poster_path": "/heat.jpg", "overview": "A heist", "vote_average": 7.9,
+		"genres": [{"name": "Crime"}, {"name": "Drama"}]}`,
+	"/": `{"imdbRating": "8.3"}`,
+}
+
+func TestAddToWatchlistFillsMetadata(t *testing.T) {
+	s := withProvider(newTestService(t, nil), heatResponses)
+
+	item, err := s.AddToWatchlist("user", models.MediaTypeMovie, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Heat", item.Title)
+	assert.Equal(t, "Crime, Drama", item.Genre)
+	assert.Equal(t, 170, item.Runtime)
+	assert.Equal(t, "tt0113277", item.IMDbID)
+	assert.InDelta(t, 8.3, item.IMDbRating, 0.001)
+	assert.NotNil(t, item.MetadataUpdatedAt)
+
+	_, err = s.AddToWatchlist("user", models.MediaTypeMovie, 1)
+	assert.ErrorIs(t, err, ErrItemExists)
+	_, err = s.AddToWatchlist("user", models.MediaTypeMovie, 2)
+	assert.ErrorIs(t, err, ErrTitleNotFound)
+	_, err = s.AddToWatchlist("user", "book", 1)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestRefreshStaleMetadata(t *testing.T) {
+	fresh := time.Now()
+	stale := fresh.Add(-48 * time.Hour)
+	s := withProvider(newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Old title", UserNotes: "with popcorn", MetadataUpdatedAt: &stale},
+		{ID: "movie_2", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Fresh", MetadataUpdatedAt: &fresh},
+	}), heatResponses)
+
+	refreshed, err := s.RefreshStaleMetadata(24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, refreshed, "fresh items are not fetched")
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Equal(t, "Heat", watchlist.Items[0].Title)
+	assert.Equal(t, "with popcorn", watchlist.Items[0].UserNotes)
+	assert.True(t, watchlist.Items[0].MetadataUpdatedAt.After(stale))
+	assert.Equal(t, "Fresh", watchlist.Items[1].Title)
+}
+
+func TestApplyMetadataKeepsPersonalData(t *testing.T) {
+	rating := &models.PersonalRating{Value: 4, Scale: 5}
+	dst := models.WatchlistItem{
+		ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Old title",
+		UserNotes: "with popcorn", PersonalRating: rating, Tags: []string{"crime"},
+	}
+	updatedAt := time.Now()
+	src := dst
+	src.Title = "Heat"
+	src.Runtime = 170
+	src.Directors = []string{"Michael Mann"}
+	src.UserNotes = ""
+	src.PersonalRating = nil
+	src.MetadataUpdatedAt = &updatedAt
+
+	applyMetadata(&dst, src)
+	assert.Equal(t, "Heat", dst.Title)
+	assert.Equal(t, 170, dst.Runtime)
+	assert.Equal(t, []string{"Michael Mann"}, dst.Directors)
+	assert.Equal(t, &updatedAt, dst.MetadataUpdatedAt)
+	assert.Equal(t, "with popcorn", dst.UserNotes)
+	assert.Equal(t, rating, dst.PersonalRating)
+	assert.Equal(t, []string{"crime"}, dst.Tags)
+}
+
+func TestApplyMetadataMergesNewEpisodes(t *testing.T) {
+	watchedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	dst := models.WatchlistItem{MediaType: models.MediaTypeTV, MovieID: 1, Progress: &models.TVProgress{
+		Seasons: []models.SeasonProgress{{SeasonNumber: 1, Episodes: []models.EpisodeProgress{
+			{EpisodeNumber: 1, Watched: true, WatchedAt: &watchedAt},
+			{EpisodeNumber: 2, Watched: true, WatchedAt: &watchedAt},
+		}}},
+	}}
+	require.True(t, summarizeTVProgress(&dst).IsCompleted)
+
+	// An episode aired in the running season
+	src := dst
+	src.Progress = &models.TVProgress{Seasons: []models.SeasonProgress{{SeasonNumber: 1, Episodes: []models.EpisodeProgress{
+		{EpisodeNumber: 1}, {EpisodeNumber: 2}, {EpisodeNumber: 3},
+	}}}}
+	applyMetadata(&dst, src)
+
+	summary := summarizeTVProgress(&dst)
+	assert.Equal(t, 3, summary.TotalEpisodes)
+	assert.Equal(t, 2, summary.WatchedEpisodes, "watched state is kept by season and episode")
+	assert.False(t, summary.IsCompleted)
+	assert.Equal(t, &models.EpisodeRef{Season: 1, Episode: 3}, summary.NextEpisode)
+	assert.Equal(t, &watchedAt, dst.Progress.Seasons[0].Episodes[1].WatchedAt)
+
+	// A refresh without new episodes leaves progress alone
+	unchanged := dst
+	unchanged.Progress = &models.TVProgress{Seasons: []models.SeasonProgress{{SeasonNumber: 1, Episodes: []models.EpisodeProgress{
+		{EpisodeNumber: 1}, {EpisodeNumber: 2}, {EpisodeNumber: 3},
+	}}}}
+	applyMetadata(&dst, unchanged)
+	assert.Equal(t, 2, summarizeTVProgress(&dst).WatchedEpisodes)
+}
+
+func TestCreditNames(t *testing.T) {
+	credits := map[string]interface{}{
+		"crew": []interface{}{
+			map[string]interface{}{"name": "Michael Mann", "job": "Director"},
+			map[string]interface{}{"name": "Dante Spinotti", "job": "Director of Photography"},
+		},
+		"cast": []interface{}{
+			map[string]interface{}{"name": "Al Pacino"}, map[string]interface{}{"name": "Robert De Niro"},
+			map[string]interface{}{"name": "Val Kilmer"}, map[string]interface{}{"name": "Jon Voight"},
+			map[string]interface{}{"name": "Tom Sizemore"}, map[string]interface{}{"name": "Diane Venora"},
+		},
+	}
+	directors, cast := creditNames(credits)
+	assert.Equal(t, []string{"Michael Mann"}, directors)
+	assert.Len(t, cast, maxCastMembers)
+	assert.Equal(t, "Al Pacino", cast[0])
+}
+
+func TestMigrateWatchlistBackfillsCredits(t *testing.T) {
+	fetchedAt := time.Now()
+	watchlist := &models.Watchlist{SchemaVersion: 3, Items: []models.WatchlistItem{
+		{ID: "tv_1", MediaType: models.MediaTypeTV, MovieID: 1, Directors: []string{"Craig Mazin"}, Cast: []string{"Jared Harris"}, MetadataUpdatedAt: &fetchedAt},
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, MetadataUpdatedAt: &fetchedAt},
+		{ID: "movie_2", MediaType: models.MediaTypeMovie, MovieID: 2, Directors: []string{"Michael Mann"}, MetadataUpdatedAt: &fetchedAt},
+	}}
+
+	changed, _ := migrateWatchlist(watchlist)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"Craig Mazin"}, watchlist.Items[0].Creators, "creators were stored as directors")
+	assert.Empty(t, watchlist.Items[0].Directors)
+	assert.Nil(t, watchlist.Items[1].MetadataUpdatedAt, "items without credits are refreshed")
+	assert.NotNil(t, watchlist.Items[2].MetadataUpdatedAt)
+	assert.Equal(t, []string{"Michael Mann"}, watchlist.Items[2].Directors)
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"r.a.w/backend/internal/models"
+)
+
+// migrateWatchlist upgrades a watchlist loaded from disk to the current schema.
+// It returns true if anything changed and the watchlist should be saved, along
+// with any watch events that must be added to the user's history.
+func migrateWatchlist(watchlist *models.Watchlist) (bool, []models.WatchEvent) {
+	if watchlist.SchemaVersion >= models.CurrentWatchlistSchemaVersion {
+		return false, nil
+	}
+
+	// Version 0 -> 1: items predate TV support, so everything stored was a movie
+	if watchlist.SchemaVersion < 1 {
+		for i := range watchlist.Items {
+			if watchlist.Items[i].MediaType == "" {
+				watchlist.Items[i].MediaType = models.MediaTypeMovie
+			}
+		}
+	}
+
+	// Version 1 -> 2: watched state moves into the watch history
+	var seed []models.WatchEvent
+	if watchlist.SchemaVersion < 2 {
+		for _, item := range watchlist.Items {
+			if !item.IsWatched {
+				continue
+			}
+			watchedAt := item.AddedAt
+			if item.WatchedAt != nil {
+				watchedAt = *item.WatchedAt
+			}
+			seed = append(seed, models.WatchEvent{
+				ID:        item.ID,
+				ItemID:    item.ID,
+				MediaType: item.MediaType,
+				MovieID:   item.MovieID,
+				Title:     item.Title,
+				WatchedAt: watchedAt,
+				Notes:     item.UserNotes,
+				CreatedAt: watchedAt,
+				UpdatedAt: watchedAt,
+			})
+		}
+	}
+
+	// Version 2 -> 3: items get ranks in their stored order
+	if watchlist.SchemaVersion < 3 {
+		rank := ""
+		for i := range watchlist.Items {
+			rank = rankAfter(rank)
+			watchlist.Items[i].Rank = rank
+		}
+	}
+
+	// Version 3 -> 4: TV creators were stored as directors, and items whose
+	// metadata was fetched before credits were stored are refreshed to get them
+	if watchlist.SchemaVersion < 4 {
+		for i := range watchlist.Items {
+			item := &watchlist.Items[i]
+			if item.IsTV() && len(item.Creators) == 0 {
+				item.Creators, item.Directors = item.Directors, nil
+			}
+			if len(item.Directors) == 0 && len(item.Cast) == 0 && len(item.Creators) == 0 {
+				item.MetadataUpdatedAt = nil
+			}
+		}
+	}
+
+	watchlist.SchemaVersion = models.CurrentWatchlistSchemaVersion
+	return true, seed
+}
+
+// seedWatchHistory adds migrated watch events to a user's history, skipping
+// events that were already seeded by an earlier, interrupted migration
+func (s *WatchlistService) seedWatchHistory(userID string, seed []models.WatchEvent) error {
+	if len(seed) == 0 {
+		return nil
+	}
+
+	history, err := s.loadWatchHistory(userID)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for _, event := range history.Events {
+		existing[event.ID] = true
+	}
+	for _, event := range seed {
+		if !existing[event.ID] {
+			history.Events = append(history.Events, event)
+		}
+	}
+
+	return s.saveWatchHistory(history)
+}
+
+// MigrateDataDir upgrades every watchlist file in the data directory to the
+// current schema. It is safe to run on every startup.
+func (s *WatchlistService) MigrateDataDir() error {
+	files, err := ioutil.ReadDir(s.dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to read data directory: %w", err)
+	}
+
+	migrated := 0
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "watchlist_") || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		filePath := filepath.Join(s.dataDir, file.Name())
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			s.logger.Warning("Skipping unreadable watchlist file %s: %v", file.Name(), err)
+			continue
+		}
+
+		var watchlist models.Watchlist
+		if err := json.Unmarshal(data, &watchlist); err != nil {
+			s.logger.Warning("Skipping invalid watchlist file %s: %v", file.Name(), err)
+			continue
+		}
+
+		changed, seed := migrateWatchlist(&watchlist)
+		if !changed {
+			continue
+		}
+
+		if err := s.seedWatchHistory(watchlist.UserID, seed); err != nil {
+			return fmt.Errorf("failed to migrate watch history for %s: %w", file.Name(), err)
+		}
+
+		if err := s.writeWatchlist(&watchlist); err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", file.Name(), err)
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		s.logger.Success("Migrated %d watchlist file(s) to schema version %d", migrated, models.CurrentWatchlistSchemaVersion)
+	}
+	return nil
+}
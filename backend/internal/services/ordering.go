@@ -0,0 +1,240 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"r.a.w/backend/internal/models"
+)
+
+// rankDigits are the digits used in item ranks, in ascending order
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// rankWidth is the length of ranks handed out when appending items
+const rankWidth = 4
+
+// priorityOrder maps priorities to their sort order; items without a priority sort last
+var priorityOrder = map[string]int{
+	models.PriorityHigh:   0,
+	models.PriorityMedium: 1,
+	models.PriorityLow:    2,
+	"":                    3,
+}
+
+// ReorderItem moves an item within a list. Only the moved item's rank changes.
+func (s *WatchlistService) ReorderItem(userID, listID, itemID string, req models.ReorderRequest) (*models.WatchlistItem, error) {
+	set := 0
+	if req.Index != nil {
+		set++
+	}
+	if req.Before != "" {
+		set++
+	}
+	if req.After != "" {
+		set++
+	}
+	if set != 1 {
+		return nil, invalidf("exactly one of index, before or after is required")
+	}
+
+	var updated models.WatchlistItem
+	err := s.updateList(userID, listID, func(watchlist *models.Watchlist) error {
+		item := findItem(watchlist, itemID)
+		if item == nil {
+			return ErrItemNotFound
+		}
+
+		// Neighbours are looked up among the other items, in rank order
+		others := make([]models.WatchlistItem, 0, len(watchlist.Items))
+		for _, other := range watchlist.Items {
+			if other.ID != itemID {
+				others = append(others, other)
+			}
+		}
+		sortByRank(others)
+
+		index := -1
+		switch {
+		case req.Index != nil:
+			index = *req.Index
+			if index < 0 {
+				index = 0
+			}
+			if index > len(others) {
+				index = len(others)
+			}
+		default:
+			anchor := req.Before
+			if anchor == "" {
+				anchor = req.After
+			}
+			for i, other := range others {
+				if other.ID == anchor {
+					index = i
+					break
+				}
+			}
+			if index == -1 {
+				return invalidf("anchor item %s is not in the list", anchor)
+			}
+			if req.After != "" {
+				index++
+			}
+		}
+
+		lo, hi := "", ""
+		if index > 0 {
+			lo = others[index-1].Rank
+		}
+		if index < len(others) {
+			hi = others[index].Rank
+		}
+		item.Rank = rankBetween(lo, hi)
+
+		updated = *item
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// SetPriority sets an item's priority; an empty priority clears it
+func (s *WatchlistService) SetPriority(userID, listID, itemID, priority string) (*models.WatchlistItem, error) {
+	priority = strings.ToLower(priority)
+	if _, ok := priorityOrder[priority]; !ok {
+		return nil, invalidf("invalid priority %q, use high, medium or low", priority)
+	}
+
+	var updated models.WatchlistItem
+	err := s.updateList(userID, listID, func(watchlist *models.Watchlist) error {
+		item := findItem(watchlist, itemID)
+		if item == nil {
+			return ErrItemNotFound
+		}
+		item.Priority = priority
+		updated = *item
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// sortByRank orders items by their manual position
+func sortByRank(items []models.WatchlistItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Rank < items[j].Rank
+	})
+}
+
+// ranksUsable reports whether the ranks of items, sorted by rank, can have
+// new ranks placed between them: each is made of rankDigits, is not only the
+// lowest digit, and differs from the others
+func ranksUsable(items []models.WatchlistItem) bool {
+	for i, item := range items {
+		if strings.Trim(item.Rank, rankDigits[:1]) == "" {
+			return false
+		}
+		for j := 0; j < len(item.Rank); j++ {
+			if strings.IndexByte(rankDigits, item.Rank[j]) < 0 {
+				return false
+			}
+		}
+		if i > 0 && items[i-1].Rank == item.Rank {
+			return false
+		}
+	}
+	return true
+}
+
+// rerank gives items fresh ranks in their current order
+func rerank(items []models.WatchlistItem) {
+	rank := ""
+	for i := range items {
+		rank = rankAfter(rank)
+		items[i].Rank = rank
+	}
+}
+
+// nextRank returns a rank that sorts after every item in the list
+func nextRank(items []models.WatchlistItem) string {
+	last := ""
+	for _, item := range items {
+		if item.Rank > last {
+			last = item.Rank
+		}
+	}
+	return rankAfter(last)
+}
+
+// rankAfter returns a short rank greater than lo, for appending.
+// It increments the first rankWidth digits of lo as a base-36 number.
+func rankAfter(lo string) string {
+	digits := []byte(lo)
+	if len(digits) > rankWidth {
+		digits = digits[:rankWidth]
+	}
+	for len(digits) < rankWidth {
+		digits = append(digits, rankDigits[0])
+	}
+
+	for i := rankWidth - 1; i >= 0; i-- {
+		d := rankDigit(digits[i], 0)
+		if d < len(rankDigits)-1 {
+			digits[i] = rankDigits[d+1]
+			return string(digits)
+		}
+		digits[i] = rankDigits[0]
+	}
+
+	// Every fixed-width rank is taken; fall back to a longer one
+	return rankBetween(lo, "")
+}
+
+// rankDigit returns the value of a rank digit. Ranks are validated when they
+// come from outside, but a byte that is not in rankDigits takes the value
+// unknown rather than indexing out of range.
+func rankDigit(c byte, unknown int) int {
+	if d := strings.IndexByte(rankDigits, c); d >= 0 {
+		return d
+	}
+	return unknown
+}
+
+// rankBetween returns a rank strictly between lo and hi. An empty lo means
+// "before everything" and an empty hi "after everything". A rank made only of
+// the lowest digit is never handed out, so there is always room at the front.
+func rankBetween(lo, hi string) string {
+	base := len(rankDigits)
+	var rank []byte
+	loBound, hiBound := true, hi != ""
+
+	for i := 0; ; i++ {
+		l := 0
+		if loBound && i < len(lo) {
+			l = rankDigit(lo[i], 0)
+		}
+		h := base
+		if hiBound && i < len(hi) {
+			h = rankDigit(hi[i], base)
+		}
+
+		if h-l > 1 {
+			return string(append(rank, rankDigits[(l+h)/2]))
+		}
+
+		// No room at this digit; keep lo's digit and look further right
+		rank = append(rank, rankDigits[l])
+		if l < h {
+			hiBound = false
+		}
+		if i >= len(lo) {
+			loBound = false
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestRankAfterIncreases(t *testing.T) {
+	rank := ""
+	for i := 0; i < 100; i++ {
+		next := rankAfter(rank)
+		assert.Greater(t, next, rank)
+		assert.Len(t, next, rankWidth)
+		rank = next
+	}
+
+	assert.Greater(t, rankAfter("zzzz"), "zzzz")
+}
+
+func TestRankBetweenStaysBetween(t *testing.T) {
+	lo, hi := "", rankAfter("")
+	for i := 0; i < 200; i++ {
+		mid := rankBetween(lo, hi)
+		assert.Greater(t, mid, lo)
+		assert.Less(t, mid, hi)
+
+		// Alternate sides so both bounds keep moving
+		if i%2 == 0 {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	assert.Greater(t, rankBetween("0001", ""), "0001")
+	assert.Less(t, rankBetween("", "0001"), "0001")
+	mid := rankBetween("000z", "0010")
+	assert.Greater(t, mid, "000z")
+	assert.Less(t, mid, "0010")
+
+	// Digits outside rankDigits must not panic
+	assert.NotPanics(t, func() { rankBetween("A", "B") })
+	assert.NotPanics(t, func() { rankBetween("0A", "") })
+	assert.NotPanics(t, func() { rankAfter("A~") })
+}
+
+func TestReorderItemWithForeignRanks(t *testing.T) {
+	s := newTestService(t, nil)
+	require.NoError(t, s.updateWatchlist("user", func(w *models.Watchlist) error {
+		w.Items = []models.WatchlistItem{
+			{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", Rank: "A"},
+			{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Ronin", Rank: "B"},
+			{ID: "c", MediaType: models.MediaTypeMovie, MovieID: 3, Title: "Thief", Rank: "C"},
+		}
+		return nil
+	}))
+
+	assert.NotPanics(t, func() {
+		_, err := s.ReorderItem("user", "", "c", models.ReorderRequest{After: "a"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestReorderItemRejectsBadInput(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+		{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Ronin"},
+	})
+
+	index := 0
+	_, err := s.ReorderItem("user", "", "a", models.ReorderRequest{Index: &index, Before: "b"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.ReorderItem("user", "", "a", models.ReorderRequest{After: "missing"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.ReorderItem("user", "", "missing", models.ReorderRequest{After: "b"})
+	assert.ErrorIs(t, err, ErrItemNotFound)
+	_, err = s.SetPriority("user", "", "a", "urgent")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	moved, err := s.ReorderItem("user", "", "a", models.ReorderRequest{After: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, "a", moved.ID)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// SetRatingScale sets the default scale used for personal ratings
+// when a request doesn't specify one.
+func (s *WatchlistService) SetRatingScale(scale int) error {
+	if scale != models.RatingScaleFive && scale != models.RatingScaleTen {
+		return fmt.Errorf("unsupported rating scale %d, use %d or %d", scale, models.RatingScaleFive, models.RatingScaleTen)
+	}
+	s.ratingScale = scale
+	return nil
+}
+
+// UpdatePersonalData updates the personal rating, review and notes of an item
+// in any of the user's lists without touching its watched state.
+func (s *WatchlistService) UpdatePersonalData(userID, itemID string, update models.PersonalDataUpdate) (*models.WatchlistItem, error) {
+	scale := update.Scale
+	if scale == 0 {
+		scale = s.ratingScale
+	}
+	if update.Rating != nil && *update.Rating != 0 {
+		if err := validateRating(*update.Rating, scale); err != nil {
+			return nil, err
+		}
+	}
+
+	var updated models.WatchlistItem
+	err := s.updateUserItem(userID, itemID, func(item *models.WatchlistItem) error {
+		now := time.Now()
+		if update.Rating != nil {
+			if *update.Rating == 0 {
+				item.PersonalRating = nil
+			} else {
+				item.PersonalRating = &models.PersonalRating{
+					Value:   *update.Rating,
+					Scale:   scale,
+					RatedAt: now,
+				}
+			}
+		}
+
+		if update.Review != nil {
+			setReview(item, *update.Review, now)
+		}
+
+		if update.Notes != nil {
+			item.UserNotes = *update.Notes
+		}
+
+		updated = *item
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// setReview writes a review, keeping the previous text in the edit history
+func setReview(item *models.WatchlistItem, text string, now time.Time) {
+	if item.Review == nil {
+		if text == "" {
+			return
+		}
+		item.Review = &models.Review{
+			Text:      text,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return
+	}
+
+	if item.Review.Text == text {
+		return
+	}
+	item.Review.History = append(item.Review.History, models.ReviewRevision{
+		Text:     item.Review.Text,
+		EditedAt: item.Review.UpdatedAt,
+	})
+	item.Review.Text = text
+	item.Review.UpdatedAt = now
+}
+
+// validateRating checks a rating against its scale: half stars out of 5,
+// or whole points out of 10
+func validateRating(rating float64, scale int) error {
+	switch scale {
+	case models.RatingScaleFive:
+		if rating < 0.5 || rating > 5 || math.Mod(rating*2, 1) != 0 {
+			return invalidf("rating must be between 0.5 and 5 in half-star steps")
+		}
+	case models.RatingScaleTen:
+		if rating < 1 || rating > 10 || math.Mod(rating, 1) != 0 {
+			return invalidf("rating must be a whole number between 1 and 10")
+		}
+	default:
+		return invalidf("unsupported rating scale %d", scale)
+	}
+	return nil
+}
+
+// addRatingStats fills the personal vs. provider rating comparison in stats
+func addRatingStats(stats *models.WatchlistStats, items []models.WatchlistItem) {
+	var personalTotal, providerTotal float64
+	var providerCount int
+
+	for _, item := range items {
+		if item.PersonalRating == nil {
+			continue
+		}
+		personal := item.PersonalRating.OutOfTen()
+		stats.RatedItems++
+		personalTotal += personal
+
+		if item.Rating > 0 {
+			providerTotal += item.Rating
+			providerCount++
+			stats.RatingDisagreements = append(stats.RatingDisagreements, models.RatingDisagreement{
+				ItemID:         item.ID,
+				Title:          item.Title,
+				PersonalRating: personal,
+				ProviderRating: item.Rating,
+				Difference:     personal - item.Rating,
+			})
+		}
+	}
+
+	if stats.RatedItems > 0 {
+		stats.PersonalAverageRating = personalTotal / float64(stats.RatedItems)
+	}
+	if providerCount > 0 {
+		stats.ProviderAverageRating = providerTotal / float64(providerCount)
+	}
+
+	// Keep the 5 biggest disagreements in either direction
+	sort.Slice(stats.RatingDisagreements, func(i, j int) bool {
+		return math.Abs(stats.RatingDisagreements[i].Difference) > math.Abs(stats.RatingDisagreements[j].Difference)
+	})
+	if len(stats.RatingDisagreements) > 5 {
+		stats.RatingDisagreements = stats.RatingDisagreements[:5]
+	}
+}
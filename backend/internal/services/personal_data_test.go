@@ -0,0 +1,105 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestUpdatePersonalDataValidatesRating(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+	rate := func(rating float64, scale int) error {
+		_, err := s.UpdatePersonalData("user", "movie_1", models.PersonalDataUpdate{Rating: &rating, Scale: scale})
+		return err
+	}
+
+	assert.NoError(t, rate(4.5, 0), "half stars on the default scale of 5")
+	assert.ErrorIs(t, rate(4.25, 0), ErrInvalidInput)
+	assert.ErrorIs(t, rate(6, models.RatingScaleFive), ErrInvalidInput)
+	assert.NoError(t, rate(7, models.RatingScaleTen))
+	assert.ErrorIs(t, rate(7.5, models.RatingScaleTen), ErrInvalidInput)
+	assert.ErrorIs(t, rate(3, 7), ErrInvalidInput)
+
+	_, err := s.UpdatePersonalData("user", "missing", models.PersonalDataUpdate{})
+	assert.ErrorIs(t, err, ErrItemNotFound)
+
+	require.Error(t, s.SetRatingScale(7))
+	require.NoError(t, s.SetRatingScale(models.RatingScaleTen))
+	assert.NoError(t, rate(8, 0), "the configured scale is the default")
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Equal(t, models.RatingScaleTen, watchlist.Items[0].PersonalRating.Scale)
+
+	require.NoError(t, rate(0, 0), "a rating of 0 clears the rating")
+	watchlist, err = s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Nil(t, watchlist.Items[0].PersonalRating)
+}
+
+func TestUpdatePersonalDataKeepsReviewHistory(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+	_, err := s.MarkAsWatched("user", "movie_1", models.WatchEventInput{})
+	require.NoError(t, err)
+
+	review := func(text string) *models.WatchlistItem {
+		notes := "seen at the cinema"
+		item, err := s.UpdatePersonalData("user", "movie_1", models.PersonalDataUpdate{Review: &text, Notes: &notes})
+		require.NoError(t, err)
+		return item
+	}
+	review("Great")
+	review("Great")
+	item := review("Great heist movie")
+	require.NotNil(t, item.Review)
+	assert.Equal(t, "Great heist movie", item.Review.Text)
+	require.Len(t, item.Review.History, 1, "saving the same text is not an edit")
+	assert.Equal(t, "Great", item.Review.History[0].Text)
+	assert.Equal(t, "seen at the cinema", item.UserNotes)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.True(t, watchlist.Items[0].IsWatched, "personal data does not touch watched state")
+}
+
+func TestUpdatePersonalDataInNamedList(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+	name := "Heists"
+	list, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+	_, err = s.MoveItem("user", "", "movie_1", list.ID)
+	require.NoError(t, err)
+
+	rating := 4.0
+	item, err := s.UpdatePersonalData("user", "movie_1", models.PersonalDataUpdate{Rating: &rating})
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, item.PersonalRating.Value)
+
+	named, err := s.GetList("user", list.ID)
+	require.NoError(t, err)
+	require.NotNil(t, named.Items[0].PersonalRating)
+}
+
+func TestStatsCompareRatings(t *testing.T) {
+	stats := computeStats(&models.Watchlist{Items: []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, Title: "Loved", Rating: 6, PersonalRating: &models.PersonalRating{Value: 5, Scale: models.RatingScaleFive}},
+		{ID: "b", MediaType: models.MediaTypeMovie, Title: "Agreed", Rating: 8, PersonalRating: &models.PersonalRating{Value: 8, Scale: models.RatingScaleTen}},
+		{ID: "c", MediaType: models.MediaTypeTV, Title: "Unrated", Rating: 7},
+	}})
+
+	assert.Equal(t, 2, stats.RatedItems)
+	assert.InDelta(t, 9, stats.PersonalAverageRating, 0.001, "ratings are compared out of 10")
+	assert.InDelta(t, 7, stats.ProviderAverageRating, 0.001)
+	require.Len(t, stats.RatingDisagreements, 2)
+	assert.Equal(t, "Loved", stats.RatingDisagreements[0].Title, "the biggest disagreement comes first")
+	assert.InDelta(t, 4, stats.RatingDisagreements[0].Difference, 0.001)
+	assert.Equal(t, 1, stats.TVShows.TotalItems)
+}
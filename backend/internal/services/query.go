@@ -0,0 +1,289 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// maxPageSize caps the number of items returned in one page
+const maxPageSize = 200
+
+// queryCursor marks the last item of a page. It holds the item's sort values
+// so the next page starts after it even if the list changed in between.
+type queryCursor struct {
+	Sort  string               `json:"s"`
+	Order string               `json:"o"`
+	Last  models.WatchlistItem `json:"l"`
+}
+
+// QueryWatchlist filters, sorts and paginates a user's default list
+func (s *WatchlistService) QueryWatchlist(userID string, query models.WatchlistQuery) (*models.WatchlistPage, error) {
+	return s.QueryList(userID, models.DefaultListID, query)
+}
+
+// QueryList filters, sorts and paginates one of a user's lists. Items are
+// ordered by the sort field, then by manual position, then by id, so pages
+// never overlap or skip items.
+func (s *WatchlistService) QueryList(userID, listID string, query models.WatchlistQuery) (*models.WatchlistPage, error) {
+	compare, err := itemComparator(query.Sort, query.Order)
+	if err != nil {
+		return nil, err
+	}
+	match, err := itemFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	if query.Limit < 0 {
+		return nil, invalidf("limit cannot be negative")
+	}
+	if query.Limit > maxPageSize {
+		query.Limit = maxPageSize
+	}
+
+	watchlist, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.WatchlistItem, 0, len(watchlist.Items))
+	for _, item := range watchlist.Items {
+		if match(&item) {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return compare(&items[i], &items[j]) < 0
+	})
+	total := len(items)
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != query.Sort || cursor.Order != query.Order {
+			return nil, invalidf("cursor does not match the requested sort order")
+		}
+		start := sort.Search(len(items), func(i int) bool {
+			return compare(&items[i], &cursor.Last) > 0
+		})
+		items = items[start:]
+	}
+
+	page := &models.WatchlistPage{Watchlist: watchlist, Total: total}
+	if query.Limit > 0 && len(items) > query.Limit {
+		items = items[:query.Limit]
+		next, err := encodeCursor(queryCursor{Sort: query.Sort, Order: query.Order, Last: items[len(items)-1]})
+		if err != nil {
+			return nil, err
+		}
+		page.Next = next
+	}
+	watchlist.Items = items
+
+	return page, nil
+}
+
+// itemFilter returns a predicate matching the items selected by a query
+func itemFilter(query models.WatchlistQuery) (func(*models.WatchlistItem) bool, error) {
+	switch query.Status {
+	case "", "watched", "unwatched":
+	default:
+		return nil, invalidf("invalid status %q, use watched or unwatched", query.Status)
+	}
+	switch query.MediaType {
+	case "", models.MediaTypeMovie, models.MediaTypeTV:
+	default:
+		return nil, invalidf("invalid media type %q", query.MediaType)
+	}
+
+	genre := strings.ToLower(strings.TrimSpace(query.Genre))
+	text := strings.ToLower(strings.TrimSpace(query.Text))
+
+	return func(item *models.WatchlistItem) bool {
+		if query.Status == "watched" && !item.IsWatched {
+			return false
+		}
+		if query.Status == "unwatched" && item.IsWatched {
+			return false
+		}
+		if query.MediaType != "" && item.MediaType != query.MediaType {
+			return false
+		}
+		if query.MinRating > 0 && item.Rating < query.MinRating {
+			return false
+		}
+		if query.AddedAfter != nil && !item.AddedAt.After(*query.AddedAfter) {
+			return false
+		}
+		if genre != "" && !hasGenre(item.Genre, genre) {
+			return false
+		}
+		for _, tag := range query.Tags {
+			if indexTag(item.Tags, strings.TrimSpace(tag)) == -1 {
+				return false
+			}
+		}
+		if text != "" && !matchesText(item, text) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// hasGenre reports whether a comma-separated genre list contains genre (lowercase)
+func hasGenre(genres, genre string) bool {
+	for _, g := range strings.Split(genres, ",") {
+		if strings.ToLower(strings.TrimSpace(g)) == genre {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesText reports whether text (lowercase) appears in an item's title,
+// overview, notes or tags
+func matchesText(item *models.WatchlistItem, text string) bool {
+	fields := append([]string{item.Title, item.Overview, item.UserNotes}, item.Tags...)
+	if item.Review != nil {
+		fields = append(fields, item.Review.Text)
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// itemComparator returns a total order over items for the given sort field and
+// order. Only the sort field is reversed for descending order; ties fall back to
+// manual position and then id.
+func itemComparator(sortBy, order string) (func(a, b *models.WatchlistItem) int, error) {
+	var primary func(a, b *models.WatchlistItem) int
+	switch sortBy {
+	case "", "position":
+		primary = func(a, b *models.WatchlistItem) int { return 0 }
+	case "priority":
+		primary = func(a, b *models.WatchlistItem) int {
+			return compareInts(priorityOrder[a.Priority], priorityOrder[b.Priority])
+		}
+	case "added_at":
+		primary = func(a, b *models.WatchlistItem) int { return compareTimes(a.AddedAt, b.AddedAt) }
+	case "title":
+		primary = func(a, b *models.WatchlistItem) int {
+			return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		}
+	case "rating":
+		primary = func(a, b *models.WatchlistItem) int { return compareFloats(a.Rating, b.Rating) }
+	case "release_date":
+		primary = func(a, b *models.WatchlistItem) int { return strings.Compare(a.ReleaseDate, b.ReleaseDate) }
+	case "watched_at":
+		primary = func(a, b *models.WatchlistItem) int {
+			var at, bt time.Time
+			if a.WatchedAt != nil {
+				at = *a.WatchedAt
+			}
+			if b.WatchedAt != nil {
+				bt = *b.WatchedAt
+			}
+			return compareTimes(at, bt)
+		}
+	default:
+		return nil, invalidf("invalid sort field %q", sortBy)
+	}
+
+	desc := false
+	switch order {
+	case "", "asc":
+	case "desc":
+		desc = true
+	default:
+		return nil, invalidf("invalid order %q, use asc or desc", order)
+	}
+
+	return func(a, b *models.WatchlistItem) int {
+		c := primary(a, b)
+		if desc {
+			c = -c
+		}
+		if c == 0 {
+			c = strings.Compare(a.Rank, b.Rank)
+		}
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		return c
+	}, nil
+}
+
+// encodeCursor serializes a cursor into an opaque URL-safe token
+func encodeCursor(cursor queryCursor) (string, error) {
+	// Only the fields used for ordering are kept
+	last := cursor.Last
+	cursor.Last = models.WatchlistItem{
+		ID:          last.ID,
+		Rank:        last.Rank,
+		Priority:    last.Priority,
+		Title:       last.Title,
+		Rating:      last.Rating,
+		ReleaseDate: last.ReleaseDate,
+		AddedAt:     last.AddedAt,
+		WatchedAt:   last.WatchedAt,
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor parses a token produced by encodeCursor
+func decodeCursor(token string) (*queryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalidf("invalid cursor")
+	}
+	var cursor queryCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, invalidf("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
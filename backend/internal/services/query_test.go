@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestQueryWatchlistPagesCoverEveryItemOnce(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var items []models.WatchlistItem
+	for i := 0; i < 10; i++ {
+		items = append(items, models.WatchlistItem{
+			ID:        fmt.Sprintf("item-%d", i),
+			MediaType: models.MediaTypeMovie,
+			MovieID:   i + 1,
+			Title:     fmt.Sprintf("Title %d", i%3), // duplicate titles exercise tie-breaking
+			Rating:    float64(i % 4),
+			AddedAt:   base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	s := newTestService(t, items)
+
+	for _, sortBy := range []string{"", "title", "rating", "added_at"} {
+		for _, order := range []string{"asc", "desc"} {
+			seen := make(map[string]bool)
+			query := models.WatchlistQuery{Sort: sortBy, Order: order, Limit: 3}
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 10)
+				page, err := s.QueryWatchlist("user", query)
+				require.NoError(t, err)
+				assert.Equal(t, 10, page.Total)
+				for _, item := range page.Items {
+					assert.False(t, seen[item.ID], "item %s returned twice", item.ID)
+					seen[item.ID] = true
+				}
+				if page.Next == "" {
+					break
+				}
+				query.Cursor = page.Next
+			}
+			assert.Len(t, seen, 10, "sort=%s order=%s", sortBy, order)
+		}
+	}
+}
+
+func TestQueryWatchlistFilters(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien", Genre: "Horror, Science Fiction", Rating: 8.5},
+		{ID: "b", MediaType: models.MediaTypeTV, MovieID: 1, Title: "Dark", Genre: "Drama, Mystery", Rating: 8.4},
+		{ID: "c", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Heat", Genre: "Crime", Rating: 7.9, UserNotes: "rewatch the bank scene"},
+	})
+
+	ids := func(query models.WatchlistQuery) []string {
+		page, err := s.QueryWatchlist("user", query)
+		require.NoError(t, err)
+		var ids []string
+		for _, item := range page.Items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"a"}, ids(models.WatchlistQuery{Genre: "science fiction"}))
+	assert.Equal(t, []string{"b"}, ids(models.WatchlistQuery{MediaType: models.MediaTypeTV}))
+	assert.Equal(t, []string{"a", "b"}, ids(models.WatchlistQuery{MinRating: 8}))
+	assert.Equal(t, []string{"c"}, ids(models.WatchlistQuery{Text: "BANK"}))
+	assert.Empty(t, ids(models.WatchlistQuery{Status: "watched"}))
+
+	_, err := s.QueryWatchlist("user", models.WatchlistQuery{Sort: "popularity"})
+	assert.Error(t, err)
+}
+
+func TestQueryErrorsAreTyped(t *testing.T) {
+	s := newTestService(t, nil)
+
+	for _, query := range []models.WatchlistQuery{
+		{Status: "maybe"}, {MediaType: "book"}, {Sort: "popularity"}, {Order: "up"}, {Limit: -1}, {Cursor: "not-a-cursor"},
+	} {
+		_, err := s.QueryWatchlist("user", query)
+		assert.ErrorIs(t, err, ErrInvalidInput, "%+v", query)
+	}
+
+	_, err := s.QueryList("user", "missing", models.WatchlistQuery{})
+	assert.ErrorIs(t, err, ErrListNotFound)
+	assert.NotErrorIs(t, err, ErrInvalidInput)
+}
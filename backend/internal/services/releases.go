@@ -0,0 +1,129 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// releaseRegion is the country whose release dates are preferred; other
+// countries are only used when it has no date of a given type
+const releaseRegion = "US"
+
+// upcomingMetadataMaxAge is how stale metadata may get for items with an
+// upcoming release, whose dates tend to move around
+const upcomingMetadataMaxAge = 24 * time.Hour
+
+// TMDB release types, see https://developer.themoviedb.org/reference/movie-release-dates
+var tmdbReleaseTypes = map[int]string{
+	2: models.ReleaseTheatrical, // limited
+	3: models.ReleaseTheatrical,
+	4: models.ReleaseDigital,
+}
+
+// movieReleases picks the theatrical and digital release dates from a TMDB
+// release_dates response
+func movieReleases(data map[string]interface{}) []models.ReleaseDate {
+	regional := make(map[string]models.ReleaseDate)
+	earliest := make(map[string]models.ReleaseDate)
+
+	results, _ := data["results"].([]interface{})
+	for _, r := range results {
+		country, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		code := stringField(country, "iso_3166_1")
+		dates, _ := country["release_dates"].([]interface{})
+		for _, d := range dates {
+			date, ok := d.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			releaseType, ok := tmdbReleaseTypes[int(numberField(date, "type"))]
+			day := releaseDay(stringField(date, "release_date"))
+			if !ok || day == "" {
+				continue
+			}
+
+			release := models.ReleaseDate{Type: releaseType, Date: day, Country: code}
+			if current, ok := earliest[releaseType]; !ok || day < current.Date {
+				earliest[releaseType] = release
+			}
+			if code == releaseRegion {
+				if current, ok := regional[releaseType]; !ok || day < current.Date {
+					regional[releaseType] = release
+				}
+			}
+		}
+	}
+
+	var releases []models.ReleaseDate
+	for _, releaseType := range []string{models.ReleaseTheatrical, models.ReleaseDigital} {
+		if release, ok := regional[releaseType]; ok {
+			releases = append(releases, release)
+		} else if release, ok := earliest[releaseType]; ok {
+			releases = append(releases, release)
+		}
+	}
+	return releases
+}
+
+// tvReleases returns the next episode to air from TMDB TV details
+func tvReleases(tmdb map[string]interface{}) []models.ReleaseDate {
+	next, ok := tmdb["next_episode_to_air"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	day := releaseDay(stringField(next, "air_date"))
+	if day == "" {
+		return nil
+	}
+	return []models.ReleaseDate{{
+		Type:          models.ReleaseEpisode,
+		Date:          day,
+		SeasonNumber:  int(numberField(next, "season_number")),
+		EpisodeNumber: int(numberField(next, "episode_number")),
+		EpisodeName:   stringField(next, "name"),
+	}}
+}
+
+// releaseDay trims a TMDB date or timestamp to YYYY-MM-DD, or returns "" if it isn't one
+func releaseDay(value string) string {
+	if len(value) < 10 {
+		return ""
+	}
+	if _, err := time.Parse("2006-01-02", value[:10]); err != nil {
+		return ""
+	}
+	return value[:10]
+}
+
+// itemReleases returns an item's dated releases, sorted by date. Items that
+// were never refreshed fall back to their release date.
+func itemReleases(item models.WatchlistItem) []models.ReleaseDate {
+	releases := append([]models.ReleaseDate(nil), item.Releases...)
+	if len(releases) == 0 {
+		if day := releaseDay(item.ReleaseDate); day != "" {
+			releaseType := models.ReleaseTheatrical
+			if item.IsTV() {
+				releaseType = models.ReleaseEpisode
+			}
+			releases = append(releases, models.ReleaseDate{Type: releaseType, Date: day, SeasonNumber: 1, EpisodeNumber: 1})
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool { return releases[i].Date < releases[j].Date })
+	return releases
+}
+
+// hasUpcomingRelease reports whether an item has a release on or after now
+func hasUpcomingRelease(item models.WatchlistItem, now time.Time) bool {
+	today := now.Format("2006-01-02")
+	for _, release := range itemReleases(item) {
+		if release.Date >= today {
+			return true
+		}
+	}
+	return false
+}
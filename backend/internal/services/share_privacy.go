@@ -0,0 +1,263 @@
+package services
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// Errors returned for password protected shares, both mapped to 401
+var (
+	ErrSharePasswordRequired = errors.New("shared watchlist is password protected")
+	ErrShareWrongPassword    = errors.New("incorrect share password")
+)
+
+const (
+	// minSharePasswordLength is the shortest password a share accepts
+	minSharePasswordLength = 4
+	// sharePasswordIterations is the PBKDF2 work factor for share passwords
+	sharePasswordIterations = 100000
+	// shareAccessLogLimit is how many access attempts are kept per share
+	shareAccessLogLimit = 500
+)
+
+// sharedView builds what viewers of a share see from a resolved share
+func sharedView(share *models.ShareableWatchlist) *models.SharedWatchlistView {
+	view := &models.SharedWatchlistView{
+		Title:       share.Title,
+		Description: share.Description,
+		DisplayName: share.DisplayName,
+		Mode:        share.Mode,
+		Items:       make([]models.SharedItem, 0, len(share.Items)),
+		CreatedAt:   share.CreatedAt,
+		UpdatedAt:   share.UpdatedAt,
+		ExpiresAt:   share.ExpiresAt,
+	}
+	for _, item := range share.Items {
+		view.Items = append(view.Items, sharedItem(item, share.Visibility))
+	}
+	return view
+}
+
+// sharedItem copies the public fields of an item, and the personal ones the
+// visibility allows
+func sharedItem(item models.WatchlistItem, visibility models.ShareVisibility) models.SharedItem {
+	shared := models.SharedItem{
+		MediaType:        item.MediaType,
+		MovieID:          item.MovieID,
+		Title:            item.Title,
+		PosterPath:       item.PosterPath,
+		ReleaseDate:      item.ReleaseDate,
+		Genre:            item.Genre,
+		Rating:           item.Rating,
+		Overview:         item.Overview,
+		Runtime:          item.Runtime,
+		NumberOfSeasons:  item.NumberOfSeasons,
+		NumberOfEpisodes: item.NumberOfEpisodes,
+		IMDbID:           item.IMDbID,
+		IMDbRating:       item.IMDbRating,
+		Priority:         item.Priority,
+		Tags:             item.Tags,
+	}
+	if !visibility.HideWatched {
+		watched := item.IsWatched
+		shared.IsWatched = &watched
+		shared.WatchCount = item.WatchCount
+		// A rating gives away that the title was watched
+		if item.PersonalRating != nil {
+			shared.PersonalRating = item.PersonalRating.OutOfTen()
+		}
+	}
+	if !visibility.HideDates {
+		addedAt := item.AddedAt
+		shared.AddedAt = &addedAt
+		if !visibility.HideWatched {
+			shared.WatchedAt = item.WatchedAt
+		}
+	}
+	if !visibility.HideNotes {
+		shared.UserNotes = item.UserNotes
+		if item.Review != nil {
+			shared.Review = item.Review.Text
+		}
+	}
+	return shared
+}
+
+// ownerShare prepares a share for its creator: the password hash is replaced
+// by whether one is set, and the status, view and clone counts are filled in
+func (s *WatchlistService) ownerShare(share *models.ShareableWatchlist, now time.Time) *models.ShareableWatchlist {
+	share.Status = shareStatus(share, now)
+	share.HasPassword = share.PasswordHash != ""
+	share.PasswordHash = ""
+	if log, err := s.loadShareAccessLog(share.ID); err == nil {
+		share.ViewCount = log.ViewCount
+		share.CloneCount = log.CloneCount
+	}
+	return share
+}
+
+// setSharePassword protects a share with a password, or removes protection
+// when the password is empty
+func setSharePassword(share *models.ShareableWatchlist, password string) error {
+	if password == "" {
+		share.PasswordHash = ""
+		return nil
+	}
+	if len(password) < minSharePasswordLength {
+		return invalidf("share password must be at least %d characters", minSharePasswordLength)
+	}
+	hash, err := hashSharePassword(password)
+	if err != nil {
+		return err
+	}
+	share.PasswordHash = hash
+	return nil
+}
+
+// hashSharePassword derives a salted PBKDF2-SHA256 hash, stored as
+// pbkdf2-sha256$<iterations>$<salt>$<key>
+func hashSharePassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, sharePasswordIterations, 32)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash share password: %w", err)
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", sharePasswordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// checkSharePassword reports whether password matches a stored hash
+func checkSharePassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// GetShareAccessLog returns the view count and recent access attempts of
+// one of a user's shares
+func (s *WatchlistService) GetShareAccessLog(userID, shareID string) (*models.ShareAccessLog, error) {
+	share, err := s.loadShare(shareID)
+	if err != nil {
+		return nil, err
+	}
+	if share.CreatedBy != userID {
+		return nil, ErrShareNotFound
+	}
+
+	log, err := s.loadShareAccessLog(shareID)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]models.ShareAccess, 0, len(log.Entries))
+	for i := len(log.Entries) - 1; i >= 0; i-- {
+		entries = append(entries, log.Entries[i])
+	}
+	log.Entries = entries
+	return log, nil
+}
+
+// logShareAccess records a successful access to a share. The access has
+// already happened, so failures are only logged.
+func (s *WatchlistService) logShareAccess(shareID string, viewer models.ShareViewer, result string) {
+	access := models.ShareAccess{
+		At:        time.Now(),
+		ViewerID:  viewer.UserID,
+		IP:        viewer.IP,
+		UserAgent: viewer.UserAgent,
+		Result:    result,
+	}
+	if err := s.recordShareAccess(shareID, access); err != nil {
+		s.logger.Warning("Failed to log access to share %s: %v", shareID, err)
+	}
+}
+
+// recordShareAccess appends an access attempt to a share's log, counting
+// successful views and clones
+func (s *WatchlistService) recordShareAccess(shareID string, access models.ShareAccess) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log, err := s.loadShareAccessLog(shareID)
+	if err != nil {
+		return err
+	}
+	switch access.Result {
+	case models.ShareAccessViewed:
+		log.ViewCount++
+		at := access.At
+		log.LastViewedAt = &at
+	case models.ShareAccessCloned:
+		log.CloneCount++
+	}
+	log.Entries = append(log.Entries, access)
+	if len(log.Entries) > shareAccessLogLimit {
+		log.Entries = log.Entries[len(log.Entries)-shareAccessLogLimit:]
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal share access log: %w", err)
+	}
+	if err := ioutil.WriteFile(s.shareAccessLogPath(shareID), data, 0644); err != nil {
+		return fmt.Errorf("failed to save share access log: %w", err)
+	}
+	return nil
+}
+
+// loadShareAccessLog reads a share's access log, stored oldest first. Shares
+// that were never viewed have an empty log.
+func (s *WatchlistService) loadShareAccessLog(shareID string) (*models.ShareAccessLog, error) {
+	log := &models.ShareAccessLog{ShareID: shareID, Entries: []models.ShareAccess{}}
+
+	data, err := ioutil.ReadFile(s.shareAccessLogPath(shareID))
+	if os.IsNotExist(err) {
+		return log, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read share access log: %w", err)
+	}
+	if err := json.Unmarshal(data, log); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal share access log: %w", err)
+	}
+	return log, nil
+}
+
+// shareAccessLogPath returns where a share's access log is stored
+func (s *WatchlistService) shareAccessLogPath(shareID string) string {
+	return filepath.Join(s.dataDir, fmt.Sprintf("share_access_%s.json", shareID))
+}
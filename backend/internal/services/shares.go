@@ -0,0 +1,394 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// Errors returned for shares, so handlers can tell missing shares (404)
+// from ones that existed but can no longer be viewed (410)
+var (
+	ErrShareNotFound = errors.New("shared watchlist not found")
+	ErrShareGone     = errors.New("shared watchlist is no longer available")
+)
+
+// CreateShareableWatchlist shares one of a user's lists, either as a
+// snapshot of its current items or as a live view of the list
+func (s *WatchlistService) CreateShareableWatchlist(userID string, req models.ShareRequest) (*models.ShareableWatchlist, error) {
+	if req.Mode == "" {
+		req.Mode = models.ShareModeSnapshot
+	}
+	if req.Mode != models.ShareModeSnapshot && req.Mode != models.ShareModeLive {
+		return nil, invalidf("invalid share mode %q, use %s or %s", req.Mode, models.ShareModeSnapshot, models.ShareModeLive)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, invalidf("expiry must be in the future")
+	}
+	if req.ListID == "" {
+		req.ListID = models.DefaultListID
+	}
+
+	watchlist, err := s.GetList(userID, req.ListID)
+	if err != nil {
+		return nil, err
+	}
+	
+	now := time.Now()
+	shareableWatchlist := &models.ShareableWatchlist{
+		ID:          s.generateID(),
+		Title:       req.Title,
+		Description: req.Description,
+		CreatedBy:   userID,
+		CreatedAt:   now,
+		IsPublic:    req.IsPublic,
+		ShareToken:  s.generateShareToken(),
+		Mode:        req.Mode,
+		ListID:      req.ListID,
+		UpdatedAt:   now,
+		ExpiresAt:   req.ExpiresAt,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Visibility:  req.Visibility,
+	}
+	if err := setSharePassword(shareableWatchlist, req.Password); err != nil {
+		return nil, err
+	}
+	if req.Mode == models.ShareModeSnapshot {
+		shareableWatchlist.Items = watchlist.Items
+	}
+	
+	if err := s.saveShare(shareableWatchlist); err != nil {
+		return nil, err
+	}
+	if _, err := s.resolveShare(shareableWatchlist); err != nil {
+		return nil, err
+	}
+	return s.ownerShare(shareableWatchlist, now), nil
+}
+
+// GetSharedWatchlist retrieves a shared watchlist by token, as seen by
+// viewer. Unknown tokens and private shares of other users are not found;
+// revoked and expired shares, live shares of deleted lists, and shares
+// unlisted by a moderator, except to their creator, are gone.
+// Viewer ids are not secret, so every viewer, the creator included, must
+// give the password of protected shares and has their view counted and
+// logged. Creators see their shares without this through GetShares.
+func (s *WatchlistService) GetSharedWatchlist(shareToken string, viewer models.ShareViewer) (*models.SharedWatchlistView, error) {
+	share, err := s.openShare(shareToken, viewer, models.ShareAccessViewed)
+	if err != nil {
+		return nil, err
+	}
+	return sharedView(share), nil
+}
+
+// openShare finds a share by token and checks that viewer may see it, see
+// GetSharedWatchlist. Every access is logged with result; with an empty
+// result a successful access is left for the caller to log, see logShareAccess.
+func (s *WatchlistService) openShare(shareToken string, viewer models.ShareViewer, result string) (*models.ShareableWatchlist, error) {
+	shares, err := s.loadShares()
+	if err != nil {
+		return nil, err
+	}
+	
+	for i := range shares {
+		share := &shares[i]
+		if share.ShareToken != shareToken {
+			continue
+		}
+		
+		isOwner := viewer.UserID != "" && viewer.UserID == share.CreatedBy
+		if !share.IsPublic && !isOwner {
+			return nil, ErrShareNotFound
+		}
+		if shareStatus(share, time.Now()) != models.ShareActive {
+			return nil, ErrShareGone
+		}
+		// Moderators unlist shares that should not be seen
+		if share.UnlistedAt != nil && !isOwner {
+			return nil, ErrShareGone
+		}
+
+		access := models.ShareAccess{
+			At:        time.Now(),
+			ViewerID:  viewer.UserID,
+			IP:        viewer.IP,
+			UserAgent: viewer.UserAgent,
+		}
+		if share.PasswordHash != "" {
+			if viewer.Password == "" {
+				return nil, ErrSharePasswordRequired
+			}
+			if !checkSharePassword(share.PasswordHash, viewer.Password) {
+				access.Result = models.ShareAccessWrongPassword
+				if err := s.recordShareAccess(share.ID, access); err != nil {
+					s.logger.Warning("Failed to log access to share %s: %v", share.ID, err)
+				}
+				return nil, ErrShareWrongPassword
+			}
+		}
+		if _, err := s.resolveShare(share); err != nil {
+			return nil, err
+		}
+
+		if result != "" {
+			s.logShareAccess(share.ID, viewer, result)
+		}
+		return share, nil
+	}
+	
+	return nil, ErrShareNotFound
+}
+
+// GetShares returns every share a user created, newest first, with its status
+func (s *WatchlistService) GetShares(userID string) ([]models.ShareableWatchlist, error) {
+	shares, err := s.loadShares()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	userShares := []models.ShareableWatchlist{}
+	for _, share := range shares {
+		if share.CreatedBy != userID {
+			continue
+		}
+		if share.Mode == models.ShareModeLive {
+			share.Items = nil
+		}
+		userShares = append(userShares, *s.ownerShare(&share, now))
+	}
+	sort.Slice(userShares, func(i, j int) bool {
+		return userShares[i].CreatedAt.After(userShares[j].CreatedAt)
+	})
+	return userShares, nil
+}
+
+// UpdateShare changes the details, visibility, mode, expiry or privacy
+// settings of a share
+func (s *WatchlistService) UpdateShare(userID, shareID string, update models.ShareUpdate) (*models.ShareableWatchlist, error) {
+	return s.updateShare(userID, shareID, func(share *models.ShareableWatchlist) error {
+		if update.Mode != nil && *update.Mode != share.Mode {
+			switch *update.Mode {
+			case models.ShareModeSnapshot:
+				watchlist, err := s.GetList(userID, share.ListID)
+				if err != nil {
+					return err
+				}
+				share.Items = watchlist.Items
+			case models.ShareModeLive:
+				share.Items = nil
+			default:
+				return invalidf("invalid share mode %q, use %s or %s", *update.Mode, models.ShareModeSnapshot, models.ShareModeLive)
+			}
+			share.Mode = *update.Mode
+		}
+		if update.ExpiresAt != nil {
+			if !update.ExpiresAt.After(time.Now()) {
+				return invalidf("expiry must be in the future")
+			}
+			share.ExpiresAt = update.ExpiresAt
+		}
+		if update.ClearExpiry {
+			share.ExpiresAt = nil
+		}
+		if update.Title != nil {
+			share.Title = *update.Title
+		}
+		if update.Description != nil {
+			share.Description = *update.Description
+		}
+		if update.IsPublic != nil {
+			share.IsPublic = *update.IsPublic
+		}
+		if update.DisplayName != nil {
+			share.DisplayName = strings.TrimSpace(*update.DisplayName)
+		}
+		if update.Visibility != nil {
+			share.Visibility = *update.Visibility
+		}
+		if update.Password != nil {
+			return setSharePassword(share, *update.Password)
+		}
+		return nil
+	})
+}
+
+// RevokeShare stops a share from being viewed. The record is kept, so its
+// link reports the share as gone rather than unknown.
+func (s *WatchlistService) RevokeShare(userID, shareID string) (*models.ShareableWatchlist, error) {
+	return s.updateShare(userID, shareID, func(share *models.ShareableWatchlist) error {
+		if share.RevokedAt == nil {
+			now := time.Now()
+			share.RevokedAt = &now
+		}
+		return nil
+	})
+}
+
+// RegenerateShareToken gives a share a new token, so the old link stops working
+func (s *WatchlistService) RegenerateShareToken(userID, shareID string) (*models.ShareableWatchlist, error) {
+	return s.updateShare(userID, shareID, func(share *models.ShareableWatchlist) error {
+		share.ShareToken = s.generateShareToken()
+		return nil
+	})
+}
+
+// DeleteShare removes a share entirely, along with its access log
+func (s *WatchlistService) DeleteShare(userID, shareID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	share, err := s.loadShare(shareID)
+	if err != nil {
+		return err
+	}
+	if share.CreatedBy != userID {
+		return ErrShareNotFound
+	}
+
+	if err := os.Remove(s.shareFilePath(shareID)); err != nil {
+		return fmt.Errorf("failed to delete shared watchlist: %w", err)
+	}
+	if err := os.Remove(s.shareAccessLogPath(shareID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete share access log: %w", err)
+	}
+	return nil
+}
+
+// updateShare loads one of a user's shares, applies fn and saves the result
+// under the service lock
+func (s *WatchlistService) updateShare(userID, shareID string, fn func(*models.ShareableWatchlist) error) (*models.ShareableWatchlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	share, err := s.loadShare(shareID)
+	if err != nil {
+		return nil, err
+	}
+	if share.CreatedBy != userID {
+		return nil, ErrShareNotFound
+	}
+
+	if err := fn(share); err != nil {
+		return nil, err
+	}
+	share.UpdatedAt = time.Now()
+	if err := s.saveShare(share); err != nil {
+		return nil, err
+	}
+
+	return s.ownerShare(share, time.Now()), nil
+}
+
+// resolveShare fills in the items of a live share from its list
+func (s *WatchlistService) resolveShare(share *models.ShareableWatchlist) (*models.ShareableWatchlist, error) {
+	if share.Mode != models.ShareModeLive {
+		return share, nil
+	}
+
+	watchlist, err := s.GetList(share.CreatedBy, share.ListID)
+	if err != nil {
+		return nil, ErrShareGone
+	}
+	share.Items = watchlist.Items
+	return share, nil
+}
+
+// shareStatus returns whether a share is active, expired or revoked
+func shareStatus(share *models.ShareableWatchlist, now time.Time) string {
+	switch {
+	case share.RevokedAt != nil:
+		return models.ShareRevoked
+	case share.ExpiresAt != nil && !now.Before(*share.ExpiresAt):
+		return models.ShareExpired
+	default:
+		return models.ShareActive
+	}
+}
+
+// loadShares reads every stored share
+func (s *WatchlistService) loadShares() ([]models.ShareableWatchlist, error) {
+	files, err := ioutil.ReadDir(s.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %w", err)
+	}
+
+	var shares []models.ShareableWatchlist
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, "shared_watchlist_") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		share, err := s.loadShare(strings.TrimSuffix(strings.TrimPrefix(name, "shared_watchlist_"), ".json"))
+		if err != nil {
+			continue
+		}
+		shares = append(shares, *share)
+	}
+	return shares, nil
+}
+
+// loadShare reads a share by id. Shares saved before modes existed are snapshots
+// of the default list.
+func (s *WatchlistService) loadShare(shareID string) (*models.ShareableWatchlist, error) {
+	if shareID == "" || strings.ContainsAny(shareID, `/\.`) {
+		return nil, ErrShareNotFound
+	}
+
+	data, err := ioutil.ReadFile(s.shareFilePath(shareID))
+	if os.IsNotExist(err) {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared watchlist: %w", err)
+	}
+
+	var share models.ShareableWatchlist
+	if err := json.Unmarshal(data, &share); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal shared watchlist: %w", err)
+	}
+	if share.Mode == "" {
+		share.Mode = models.ShareModeSnapshot
+	}
+	if share.ListID == "" {
+		share.ListID = models.DefaultListID
+	}
+	if share.UpdatedAt.IsZero() {
+		share.UpdatedAt = share.CreatedAt
+	}
+	return &share, nil
+}
+
+// saveShare writes a share to file
+func (s *WatchlistService) saveShare(share *models.ShareableWatchlist) error {
+	stored := *share
+	stored.Status = ""
+	stored.HasPassword = false
+	stored.ViewCount = 0
+	stored.CloneCount = 0
+	if stored.Mode == models.ShareModeLive {
+		stored.Items = nil
+	}
+
+	data, err := json.MarshalIndent(&stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal shareable watchlist: %w", err)
+	}
+	if err := ioutil.WriteFile(s.shareFilePath(share.ID), data, 0644); err != nil {
+		return fmt.Errorf("failed to save shareable watchlist: %w", err)
+	}
+	return nil
+}
+
+// shareFilePath returns where a share is stored
+func (s *WatchlistService) shareFilePath(shareID string) string {
+	return filepath.Join(s.dataDir, fmt.Sprintf("shared_watchlist_%s.json", shareID))
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestShareModes(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+
+	snapshot, err := s.CreateShareableWatchlist("user", models.ShareRequest{Title: "Snap", IsPublic: true})
+	require.NoError(t, err)
+	assert.Equal(t, models.ShareModeSnapshot, snapshot.Mode)
+	live, err := s.CreateShareableWatchlist("user", models.ShareRequest{Title: "Live", IsPublic: true, Mode: models.ShareModeLive})
+	require.NoError(t, err)
+
+	_, err = s.CreateShareableWatchlist("user", models.ShareRequest{Mode: "mirror"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	past := time.Now().Add(-time.Hour)
+	_, err = s.CreateShareableWatchlist("user", models.ShareRequest{ExpiresAt: &past})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.CreateShareableWatchlist("user", models.ShareRequest{ListID: "missing"})
+	assert.ErrorIs(t, err, ErrListNotFound)
+
+	require.NoError(t, s.updateWatchlist("user", func(w *models.Watchlist) error {
+		w.Items = append(w.Items, models.WatchlistItem{ID: "movie_2", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Ronin"})
+		return nil
+	}))
+
+	shared, err := s.GetSharedWatchlist(snapshot.ShareToken, models.ShareViewer{})
+	require.NoError(t, err)
+	assert.Len(t, shared.Items, 1, "snapshots keep the items they were made with")
+	shared, err = s.GetSharedWatchlist(live.ShareToken, models.ShareViewer{})
+	require.NoError(t, err)
+	assert.Len(t, shared.Items, 2, "live shares follow the list")
+
+	// Switching to a snapshot freezes the current items
+	mode := models.ShareModeSnapshot
+	_, err = s.UpdateShare("user", live.ID, models.ShareUpdate{Mode: &mode})
+	require.NoError(t, err)
+	require.NoError(t, s.updateWatchlist("user", func(w *models.Watchlist) error {
+		w.Items = w.Items[:1]
+		return nil
+	}))
+	shared, err = s.GetSharedWatchlist(live.ShareToken, models.ShareViewer{})
+	require.NoError(t, err)
+	assert.Len(t, shared.Items, 2)
+}
+
+func TestShareAccess(t *testing.T) {
+	s := newTestService(t, nil)
+
+	private, err := s.CreateShareableWatchlist("user", models.ShareRequest{Title: "Private"})
+	require.NoError(t, err)
+	_, err = s.GetSharedWatchlist(private.ShareToken, models.ShareViewer{})
+	assert.ErrorIs(t, err, ErrShareNotFound)
+	_, err = s.GetSharedWatchlist(private.ShareToken, models.ShareViewer{UserID: "someone-else"})
+	assert.ErrorIs(t, err, ErrShareNotFound)
+	_, err = s.GetSharedWatchlist(private.ShareToken, models.ShareViewer{UserID: "user"})
+	assert.NoError(t, err, "owners can view their private shares")
+
+	public := true
+	_, err = s.UpdateShare("someone-else", private.ID, models.ShareUpdate{IsPublic: &public})
+	assert.ErrorIs(t, err, ErrShareNotFound)
+	_, err = s.UpdateShare("user", private.ID, models.ShareUpdate{IsPublic: &public})
+	require.NoError(t, err)
+	_, err = s.GetSharedWatchlist(private.ShareToken, models.ShareViewer{})
+	assert.NoError(t, err)
+
+	_, err = s.GetSharedWatchlist("unknown", models.ShareViewer{})
+	assert.ErrorIs(t, err, ErrShareNotFound)
+}
+
+func TestShareLifecycle(t *testing.T) {
+	s := newTestService(t, nil)
+
+	share, err := s.CreateShareableWatchlist("user", models.ShareRequest{Title: "Mine", IsPublic: true})
+	require.NoError(t, err)
+
+	regenerated, err := s.RegenerateShareToken("user", share.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, share.ShareToken, regenerated.ShareToken)
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{})
+	assert.ErrorIs(t, err, ErrShareNotFound)
+	_, err = s.GetSharedWatchlist(regenerated.ShareToken, models.ShareViewer{})
+	require.NoError(t, err)
+
+	// Expiry can be set and cleared; an expired share is gone
+	soon := time.Now().Add(50 * time.Millisecond)
+	_, err = s.UpdateShare("user", share.ID, models.ShareUpdate{ExpiresAt: &soon})
+	require.NoError(t, err)
+	time.Sleep(60 * time.Millisecond)
+	_, err = s.GetSharedWatchlist(regenerated.ShareToken, models.ShareViewer{})
+	assert.ErrorIs(t, err, ErrShareGone)
+	shares, err := s.GetShares("user")
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	assert.Equal(t, models.ShareExpired, shares[0].Status)
+
+	_, err = s.UpdateShare("user", share.ID, models.ShareUpdate{ClearExpiry: true})
+	require.NoError(t, err)
+	_, err = s.GetSharedWatchlist(regenerated.ShareToken, models.ShareViewer{})
+	require.NoError(t, err)
+
+	revoked, err := s.RevokeShare("user", share.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ShareRevoked, revoked.Status)
+	_, err = s.GetSharedWatchlist(regenerated.ShareToken, models.ShareViewer{})
+	assert.ErrorIs(t, err, ErrShareGone)
+
+	assert.ErrorIs(t, s.DeleteShare("someone-else", share.ID), ErrShareNotFound)
+	require.NoError(t, s.DeleteShare("user", share.ID))
+	_, err = s.GetSharedWatchlist(regenerated.ShareToken, models.ShareViewer{})
+	assert.ErrorIs(t, err, ErrShareNotFound)
+	shares, err = s.GetShares("user")
+	require.NoError(t, err)
+	assert.Empty(t, shares)
+}
+
+func TestLiveShareOfDeletedList(t *testing.T) {
+	s := newTestService(t, nil)
+	name := "Later"
+	list, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+
+	share, err := s.CreateShareableWatchlist("user", models.ShareRequest{IsPublic: true, Mode: models.ShareModeLive, ListID: list.ID})
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteList("user", list.ID))
+
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{})
+	assert.ErrorIs(t, err, ErrShareGone)
+}
+
+func TestSharePrivacy(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", AddedAt: time.Now(),
+			UserNotes: "watched with Sam", Review: &models.Review{Text: "Great"},
+			PersonalRating: &models.PersonalRating{Value: 8, Scale: models.RatingScaleTen}},
+	})
+	_, err := s.MarkAsWatched("user", "movie_1", models.WatchEventInput{})
+	require.NoError(t, err)
+
+	share, err := s.CreateShareableWatchlist("user", models.ShareRequest{IsPublic: true, DisplayName: " Movie Fan "})
+	require.NoError(t, err)
+	shared, err := s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{})
+	require.NoError(t, err)
+	assert.Equal(t, "Movie Fan", shared.DisplayName)
+	require.Len(t, shared.Items, 1)
+	item := shared.Items[0]
+	assert.Equal(t, "watched with Sam", item.UserNotes)
+	assert.Equal(t, "Great", item.Review)
+	require.NotNil(t, item.IsWatched)
+	assert.True(t, *item.IsWatched)
+	assert.NotNil(t, item.WatchedAt)
+	assert.NotNil(t, item.AddedAt)
+	assert.Equal(t, 8.0, item.PersonalRating)
+
+	data, err := json.Marshal(shared)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"user"`, "viewers never see the creator's user id")
+
+	visibility := models.ShareVisibility{HideNotes: true, HideWatched: true, HideDates: true}
+	_, err = s.UpdateShare("user", share.ID, models.ShareUpdate{Visibility: &visibility})
+	require.NoError(t, err)
+	shared, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{})
+	require.NoError(t, err)
+	item = shared.Items[0]
+	assert.Empty(t, item.UserNotes)
+	assert.Empty(t, item.Review)
+	assert.Nil(t, item.IsWatched)
+	assert.Zero(t, item.WatchCount)
+	assert.Zero(t, item.PersonalRating, "a rating would give the watch away")
+	assert.Nil(t, item.WatchedAt)
+	assert.Nil(t, item.AddedAt)
+	assert.Equal(t, "Heat", item.Title)
+}
+
+func TestSharePassword(t *testing.T) {
+	s := newTestService(t, nil)
+
+	_, err := s.CreateShareableWatchlist("user", models.ShareRequest{IsPublic: true, Password: "abc"})
+	assert.ErrorIs(t, err, ErrInvalidInput, "passwords must have a minimum length")
+
+	share, err := s.CreateShareableWatchlist("user", models.ShareRequest{IsPublic: true, Password: "secret"})
+	require.NoError(t, err)
+	assert.True(t, share.HasPassword)
+	assert.Empty(t, share.PasswordHash, "the hash is never returned")
+
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{})
+	assert.ErrorIs(t, err, ErrSharePasswordRequired)
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{Password: "guess", IP: "10.0.0.1"})
+	assert.ErrorIs(t, err, ErrShareWrongPassword)
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{Password: "secret", IP: "10.0.0.2", UserAgent: "test"})
+	require.NoError(t, err)
+	// Viewer ids are not secret, so the creator's id does not bypass the password
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{UserID: "user"})
+	assert.ErrorIs(t, err, ErrSharePasswordRequired)
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{UserID: "user", Password: "guess", IP: "10.0.0.3"})
+	assert.ErrorIs(t, err, ErrShareWrongPassword)
+
+	// Only successful views count; failed attempts are logged too
+	log, err := s.GetShareAccessLog("user", share.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, log.ViewCount)
+	require.Len(t, log.Entries, 3)
+	assert.Equal(t, models.ShareAccessWrongPassword, log.Entries[0].Result)
+	assert.Equal(t, "user", log.Entries[0].ViewerID)
+	assert.Equal(t, models.ShareAccessViewed, log.Entries[1].Result)
+	assert.Equal(t, "10.0.0.2", log.Entries[1].IP)
+	assert.Equal(t, models.ShareAccessWrongPassword, log.Entries[2].Result)
+	_, err = s.GetShareAccessLog("someone-else", share.ID)
+	assert.ErrorIs(t, err, ErrShareNotFound)
+
+	shares, err := s.GetShares("user")
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	assert.Equal(t, 1, shares[0].ViewCount)
+	assert.Empty(t, shares[0].PasswordHash)
+
+	// Clearing the password opens the share again
+	empty := ""
+	updated, err := s.UpdateShare("user", share.ID, models.ShareUpdate{Password: &empty})
+	require.NoError(t, err)
+	assert.False(t, updated.HasPassword)
+	_, err = s.GetSharedWatchlist(share.ShareToken, models.ShareViewer{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteShare("user", share.ID))
+	_, err = s.GetShareAccessLog("user", share.ID)
+	assert.ErrorIs(t, err, ErrShareNotFound)
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"r.a.w/backend/internal/models"
+)
+
+// maxTagLength and maxTagsPerItem bound user-supplied tags
+const (
+	maxTagLength   = 50
+	maxTagsPerItem = 30
+)
+
+// GetTags returns every tag used across a user's lists with the number of
+// items carrying it, most used first
+func (s *WatchlistService) GetTags(userID string) ([]models.TagCount, error) {
+	lists, err := s.userLists(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tags differing only in case are counted together under the first spelling seen
+	var tags []models.TagCount
+	index := make(map[string]int)
+	for _, list := range lists {
+		for _, item := range list.Items {
+			for _, tag := range item.Tags {
+				key := strings.ToLower(tag)
+				if i, ok := index[key]; ok {
+					tags[i].Count++
+					continue
+				}
+				index[key] = len(tags)
+				tags = append(tags, models.TagCount{Tag: tag, Count: 1})
+			}
+		}
+	}
+	if tags == nil {
+		tags = []models.TagCount{}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return strings.ToLower(tags[i].Tag) < strings.ToLower(tags[j].Tag)
+	})
+
+	return tags, nil
+}
+
+// AddTags adds tags to several items of a list in one save. Tags an item
+// already carries (compared case-insensitively) are skipped.
+func (s *WatchlistService) AddTags(userID, listID string, update models.TagUpdate) ([]models.WatchlistItem, error) {
+	tags, err := normalizeTags(update.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateItemTags(userID, listID, update.ItemIDs, func(item *models.WatchlistItem) error {
+		return addItemTags(item, tags)
+	})
+}
+
+// RemoveTags removes tags from several items of a list in one save
+func (s *WatchlistService) RemoveTags(userID, listID string, update models.TagUpdate) ([]models.WatchlistItem, error) {
+	tags, err := normalizeTags(update.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateItemTags(userID, listID, update.ItemIDs, func(item *models.WatchlistItem) error {
+		removeItemTags(item, tags)
+		return nil
+	})
+}
+
+// RenameTag renames a tag on every item in all of the user's lists, merging it
+// into the new name where an item already has both. The changed lists are
+// written under the service lock, and if one of them fails to save the ones
+// already written are put back, so the rename applies to all lists or none.
+// It returns the number of items changed.
+func (s *WatchlistService) RenameTag(userID string, rename models.TagRename) (int, error) {
+	from, err := normalizeTag(rename.From)
+	if err != nil {
+		return 0, err
+	}
+	to, err := normalizeTag(rename.To)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lists, err := s.userLists(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Apply every change in memory first so a bad list aborts before anything is written
+	changed := 0
+	var dirty []*models.Watchlist
+	for _, list := range lists {
+		listChanged := false
+		for i := range list.Items {
+			item := &list.Items[i]
+			at := indexTag(item.Tags, from)
+			if at == -1 {
+				continue
+			}
+			if existing := indexTag(item.Tags, to); existing != -1 && existing != at {
+				item.Tags = append(item.Tags[:at], item.Tags[at+1:]...)
+			} else {
+				item.Tags[at] = to
+			}
+			changed++
+			listChanged = true
+		}
+		if listChanged {
+			dirty = append(dirty, list)
+		}
+	}
+
+	if changed == 0 {
+		return 0, invalidf("tag %q not found", from)
+	}
+
+	// A second read gives untouched copies to restore from if a save fails
+	originals, err := s.userLists(userID)
+	if err != nil {
+		return 0, err
+	}
+	// Versions are only recorded once every list is written, so a rolled
+	// back rename never shows up as one
+	for i, list := range dirty {
+		if err := s.writeWatchlist(list); err != nil {
+			s.restoreLists(dirty[:i], originals)
+			return 0, fmt.Errorf("failed to rename tag %q: %w", from, err)
+		}
+	}
+	for _, list := range dirty {
+		s.saveVersion(list)
+	}
+	return changed, nil
+}
+
+// restoreLists writes back the original version of each saved list, without
+// recording a version. It is best effort: a list that cannot be restored is
+// only logged.
+func (s *WatchlistService) restoreLists(saved, originals []*models.Watchlist) {
+	for _, list := range saved {
+		for _, original := range originals {
+			if original.ID != list.ID {
+				continue
+			}
+			if err := s.writeWatchlist(original); err != nil {
+				s.logger.Error("Failed to restore list %s for user %s: %v", list.ID, list.UserID, err)
+			}
+		}
+	}
+}
+
+// updateItemTags applies fn to each of the given items of a list in one save.
+// Unknown item ids fail the whole update.
+func (s *WatchlistService) updateItemTags(userID, listID string, itemIDs []string, fn func(*models.WatchlistItem) error) ([]models.WatchlistItem, error) {
+	if len(itemIDs) == 0 {
+		return nil, invalidf("at least one item id is required")
+	}
+
+	var updated []models.WatchlistItem
+	err := s.updateList(userID, listID, func(watchlist *models.Watchlist) error {
+		updated = nil
+		for _, itemID := range itemIDs {
+			item := findItem(watchlist, itemID)
+			if item == nil {
+				return fmt.Errorf("item %s: %w", itemID, ErrItemNotFound)
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+			updated = append(updated, *item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// addItemTags adds normalized tags to an item, skipping ones it already carries
+func addItemTags(item *models.WatchlistItem, tags []string) error {
+	for _, tag := range tags {
+		if indexTag(item.Tags, tag) == -1 {
+			item.Tags = append(item.Tags, tag)
+		}
+	}
+	if len(item.Tags) > maxTagsPerItem {
+		return invalidf("an item can have at most %d tags", maxTagsPerItem)
+	}
+	return nil
+}
+
+// removeItemTags removes normalized tags from an item
+func removeItemTags(item *models.WatchlistItem, tags []string) {
+	for _, tag := range tags {
+		if i := indexTag(item.Tags, tag); i != -1 {
+			item.Tags = append(item.Tags[:i], item.Tags[i+1:]...)
+		}
+	}
+}
+
+// normalizeTags validates a set of tags, dropping case-insensitive duplicates
+func normalizeTags(raw []string) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, invalidf("at least one tag is required")
+	}
+
+	var tags []string
+	for _, r := range raw {
+		tag, err := normalizeTag(r)
+		if err != nil {
+			return nil, err
+		}
+		if indexTag(tags, tag) == -1 {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// normalizeTag trims a tag and collapses inner whitespace
+func normalizeTag(raw string) (string, error) {
+	tag := strings.Join(strings.Fields(raw), " ")
+	if tag == "" {
+		return "", invalidf("tags cannot be empty")
+	}
+	if len(tag) > maxTagLength {
+		return "", invalidf("tag %q is longer than %d characters", tag, maxTagLength)
+	}
+	return tag, nil
+}
+
+// indexTag returns the position of tag in tags, ignoring case, or -1
+func indexTag(tags []string, tag string) int {
+	for i, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return i
+		}
+	}
+	return -1
+}
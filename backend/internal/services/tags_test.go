@@ -0,0 +1,102 @@
+package services
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestTagsAddRemoveAndCount(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien"},
+		{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Heat"},
+	})
+
+	items, err := s.AddTags("user", "", models.TagUpdate{ItemIDs: []string{"a", "b"}, Tags: []string{" with  kids ", "Rewatch", "rewatch"}})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, []string{"with kids", "Rewatch"}, items[0].Tags)
+
+	_, err = s.RemoveTags("user", "", models.TagUpdate{ItemIDs: []string{"b"}, Tags: []string{"REWATCH"}})
+	require.NoError(t, err)
+
+	tags, err := s.GetTags("user")
+	require.NoError(t, err)
+	assert.Equal(t, []models.TagCount{{Tag: "with kids", Count: 2}, {Tag: "Rewatch", Count: 1}}, tags)
+
+	_, err = s.AddTags("user", "", models.TagUpdate{ItemIDs: []string{"a", "missing"}, Tags: []string{"x"}})
+	assert.ErrorIs(t, err, ErrItemNotFound)
+	_, err = s.AddTags("user", "", models.TagUpdate{ItemIDs: []string{"a"}, Tags: []string{" "}})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.AddTags("user", "missing", models.TagUpdate{ItemIDs: []string{"a"}, Tags: []string{"x"}})
+	assert.ErrorIs(t, err, ErrListNotFound)
+	page, err := s.QueryWatchlist("user", models.WatchlistQuery{Tags: []string{"x"}})
+	require.NoError(t, err)
+	assert.Empty(t, page.Items, "a failed bulk update must not be saved")
+}
+
+func TestRenameTagMerges(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Tags: []string{"kids", "family"}},
+		{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Tags: []string{"kids"}},
+	})
+
+	changed, err := s.RenameTag("user", models.TagRename{From: "kids", To: "Family"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"family"}, watchlist.Items[0].Tags)
+	assert.Equal(t, []string{"Family"}, watchlist.Items[1].Tags)
+
+	_, err = s.RenameTag("user", models.TagRename{From: "kids", To: "x"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestRenameTagAcrossLists(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Tags: []string{"kids"}},
+	})
+	name := "Weekend"
+	weekend, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+	_, err = s.CopyItem("user", "", "a", weekend.ID)
+	require.NoError(t, err)
+
+	changed, err := s.RenameTag("user", models.TagRename{From: "kids", To: "family"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+
+	tags, err := s.GetTags("user")
+	require.NoError(t, err)
+	assert.Equal(t, []models.TagCount{{Tag: "family", Count: 2}}, tags)
+}
+
+func TestFailedRenameTagRecordsNoVersion(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Tags: []string{"kids"}},
+	})
+	name := "Weekend"
+	weekend, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+	_, err = s.CopyItem("user", "", "a", weekend.ID)
+	require.NoError(t, err)
+	before, err := s.GetVersions("user", "")
+	require.NoError(t, err)
+
+	// A directory in the way of the second list's temporary file makes its save fail
+	require.NoError(t, os.Mkdir(s.listFilePath("user", weekend.ID)+".tmp", 0755))
+	_, err = s.RenameTag("user", models.TagRename{From: "kids", To: "family"})
+	require.Error(t, err)
+
+	after, err := s.GetVersions("user", "")
+	require.NoError(t, err)
+	assert.Len(t, after, len(before), "the rolled back rename is not a version")
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kids"}, watchlist.Items[0].Tags)
+}
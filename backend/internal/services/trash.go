@@ -0,0 +1,345 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// defaultTrashRetention is how long deleted items are kept before they expire
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// SetTrashRetention sets how long deleted items stay in the trash
+func (s *WatchlistService) SetTrashRetention(retention time.Duration) error {
+	if retention <= 0 {
+		return fmt.Errorf("trash retention must be positive")
+	}
+	s.trashRetention = retention
+	return nil
+}
+
+// GetTrash returns a user's deleted items, most recently deleted first.
+// Expired entries are left out even if the sweeper has not removed them yet.
+func (s *WatchlistService) GetTrash(userID string) ([]models.TrashEntry, error) {
+	trash, err := s.loadTrash(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries := make([]models.TrashEntry, 0, len(trash.Entries))
+	for _, entry := range trash.Entries {
+		if entry.ExpiresAt.After(now) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// RestoreItem puts a deleted item back into the list it was deleted from, at
+// its old position. If that list no longer exists it goes to the default list.
+// Expired entries can no longer be restored.
+func (s *WatchlistService) RestoreItem(userID, itemID string) (*models.WatchlistItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trash, err := s.loadTrash(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := trashIndex(trash, itemID)
+	if index == -1 || !trash.Entries[index].ExpiresAt.After(time.Now()) {
+		return nil, ErrTrashNotFound
+	}
+	entry := trash.Entries[index]
+
+	list, err := s.GetList(userID, entry.ListID)
+	if errors.Is(err, ErrListNotFound) {
+		list, err = s.GetWatchlist(userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	item := entry.Item
+	rankTaken := item.Rank == ""
+	for _, existing := range list.Items {
+		if existing.MediaType == item.MediaType && existing.MovieID == item.MovieID {
+			return nil, fmt.Errorf("%s %d: %w", item.MediaType, item.MovieID, ErrItemExists)
+		}
+		if existing.ID == item.ID {
+			item.ID = s.generateID()
+		}
+		if existing.Rank == item.Rank {
+			rankTaken = true
+		}
+	}
+	// Items added since the delete may have taken the old rank, and two equal
+	// ranks can't be ordered between, so the item then goes to the end
+	if rankTaken {
+		item.Rank = nextRank(list.Items)
+	}
+	list.Items = append(list.Items, item)
+	sortByRank(list.Items)
+
+	// The entry leaves the trash first, and comes back if the list can't be
+	// saved, so a failure never leaves the item in both places
+	trash.Entries = append(trash.Entries[:index], trash.Entries[index+1:]...)
+	if err := s.saveTrash(trash); err != nil {
+		return nil, err
+	}
+
+	list.UpdatedAt = time.Now()
+	if err := s.saveWatchlist(list); err != nil {
+		trash.Entries = append(trash.Entries, entry)
+		if restoreErr := s.saveTrash(trash); restoreErr != nil {
+			s.logger.Error("Failed to put item %s back in the trash of user %s: %v", entry.Item.ID, userID, restoreErr)
+		}
+		return nil, err
+	}
+
+	s.logger.Success("Restored item %s to list %s for user %s", item.ID, list.ID, userID)
+	return &item, nil
+}
+
+// PurgeItem permanently deletes an item from the trash
+func (s *WatchlistService) PurgeItem(userID, itemID string) error {
+	return s.updateTrash(userID, func(trash *models.Trash) error {
+		index := trashIndex(trash, itemID)
+		if index == -1 {
+			return ErrTrashNotFound
+		}
+		trash.Entries = append(trash.Entries[:index], trash.Entries[index+1:]...)
+		return nil
+	})
+}
+
+// EmptyTrash permanently deletes every item in a user's trash
+func (s *WatchlistService) EmptyTrash(userID string) error {
+	return s.updateTrash(userID, func(trash *models.Trash) error {
+		trash.Entries = []models.TrashEntry{}
+		return nil
+	})
+}
+
+// PurgeExpiredTrash permanently deletes expired trash entries for every user.
+// It returns the number of entries removed.
+func (s *WatchlistService) PurgeExpiredTrash() (int, error) {
+	files, err := ioutil.ReadDir(s.dataDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read data directory: %w", err)
+	}
+
+	purged := 0
+	now := time.Now()
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, "trash_") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		userID := strings.TrimSuffix(strings.TrimPrefix(name, "trash_"), ".json")
+
+		err := s.updateTrash(userID, func(trash *models.Trash) error {
+			kept := trash.Entries[:0]
+			for _, entry := range trash.Entries {
+				if entry.ExpiresAt.After(now) {
+					kept = append(kept, entry)
+				} else {
+					purged++
+				}
+			}
+			trash.Entries = kept
+			return nil
+		})
+		if err != nil {
+			s.logger.Warning("Failed to sweep trash for user %s: %v", userID, err)
+		}
+	}
+
+	return purged, nil
+}
+
+// StartTrashSweeper runs PurgeExpiredTrash every interval until the returned
+// stop function is called.
+func (s *WatchlistService) StartTrashSweeper(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				purged, err := s.PurgeExpiredTrash()
+				if err != nil {
+					s.logger.Error("Trash sweep failed: %v", err)
+				} else if purged > 0 {
+					s.logger.Success("Purged %d expired item(s) from trash", purged)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// moveToTrash records a deleted item in the user's trash and returns the
+// trash as it was before, for putting back if the delete then fails.
+// The caller must hold the service lock.
+func (s *WatchlistService) moveToTrash(userID string, list *models.Watchlist, items ...models.WatchlistItem) (*models.Trash, error) {
+	trash, err := s.loadTrash(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return trash, nil
+	}
+
+	previous := *trash
+	previous.Entries = append([]models.TrashEntry(nil), trash.Entries...)
+	addToTrash(trash, list, items, time.Now(), s.trashRetention)
+	if err := s.saveTrash(trash); err != nil {
+		return nil, err
+	}
+	return &previous, nil
+}
+
+// restoreTrash puts back the trash returned by moveToTrash when the delete
+// that followed failed. The caller must hold the service lock.
+func (s *WatchlistService) restoreTrash(previous *models.Trash) {
+	if err := s.saveTrash(previous); err != nil {
+		s.logger.Error("Failed to take deleted items back out of the trash of user %s: %v", previous.UserID, err)
+	}
+}
+
+// removeToTrash removes an item from one of a user's lists into their trash
+// and returns it. The entry goes in the trash first, and comes back out if the
+// list can't be saved, so a failure never leaves the item in both places.
+func (s *WatchlistService) removeToTrash(userID, listID, itemID string) (*models.WatchlistItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	found := findItem(list, itemID)
+	if found == nil {
+		return nil, ErrItemNotFound
+	}
+	item := *found
+
+	previous, err := s.moveToTrash(userID, list, item)
+	if err != nil {
+		return nil, err
+	}
+	removeItem(list, itemID)
+	list.UpdatedAt = time.Now()
+	if err := s.saveWatchlist(list); err != nil {
+		s.restoreTrash(previous)
+		return nil, err
+	}
+	return &item, nil
+}
+
+// addToTrash appends trash entries for items deleted from list
+func addToTrash(trash *models.Trash, list *models.Watchlist, items []models.WatchlistItem, now time.Time, retention time.Duration) {
+	for _, item := range items {
+		// Watched state is derived from the history and would be stale on restore
+		clearWatchState(&item)
+		trash.Entries = append(trash.Entries, models.TrashEntry{
+			Item:      item,
+			ListID:    list.ID,
+			ListName:  list.Name,
+			DeletedAt: now,
+			ExpiresAt: now.Add(retention),
+		})
+	}
+}
+
+// trashIndex returns the position of an item's entry in the trash, or -1
+func trashIndex(trash *models.Trash, itemID string) int {
+	for i, entry := range trash.Entries {
+		if entry.Item.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateTrash loads a user's trash, applies fn and saves it while holding the service lock
+func (s *WatchlistService) updateTrash(userID string, fn func(*models.Trash) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trash, err := s.loadTrash(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(trash); err != nil {
+		return err
+	}
+
+	return s.saveTrash(trash)
+}
+
+// loadTrash reads a user's trash, returning an empty one if none is stored
+func (s *WatchlistService) loadTrash(userID string) (*models.Trash, error) {
+	filePath := filepath.Join(s.dataDir, fmt.Sprintf("trash_%s.json", userID))
+
+	data, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return &models.Trash{
+			UserID:  userID,
+			Entries: []models.TrashEntry{},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash file: %w", err)
+	}
+
+	var trash models.Trash
+	if err := json.Unmarshal(data, &trash); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trash: %w", err)
+	}
+
+	return &trash, nil
+}
+
+// saveTrash saves a user's trash to file
+func (s *WatchlistService) saveTrash(trash *models.Trash) error {
+	filePath := filepath.Join(s.dataDir, fmt.Sprintf("trash_%s.json", trash.UserID))
+
+	trash.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(trash, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal trash: %w", err)
+	}
+
+	// Trashed items exist nowhere else, so the trash is written to a
+	// temporary file first, like lists, and never left half-written
+	if err := ioutil.WriteFile(filePath+".tmp", data, 0644); err != nil {
+		os.Remove(filePath + ".tmp")
+		return fmt.Errorf("failed to save trash: %w", err)
+	}
+	if err := os.Rename(filePath+".tmp", filePath); err != nil {
+		return fmt.Errorf("failed to save trash: %w", err)
+	}
+
+	return nil
+}
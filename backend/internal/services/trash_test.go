@@ -0,0 +1,117 @@
+package services
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestRemovedItemCanBeRestored(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien", UserNotes: "director's cut"},
+		{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Heat"},
+	})
+
+	require.NoError(t, s.RemoveFromWatchlist("user", "a"))
+	trash, err := s.GetTrash("user")
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, models.DefaultListID, trash[0].ListID)
+
+	item, err := s.RestoreItem("user", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "director's cut", item.UserNotes)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	require.Len(t, watchlist.Items, 2)
+	assert.Equal(t, "a", watchlist.Items[0].ID, "restored items keep their position")
+
+	trash, err = s.GetTrash("user")
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func TestFailedRemoveLeavesTrashAlone(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien"},
+	})
+
+	// A directory in the way of the list's temporary file makes its save fail
+	tmp := s.listFilePath("user", "") + ".tmp"
+	require.NoError(t, os.Mkdir(tmp, 0755))
+	assert.Error(t, s.RemoveFromWatchlist("user", "a"))
+
+	trash, err := s.GetTrash("user")
+	require.NoError(t, err)
+	assert.Empty(t, trash, "the entry leaves the trash again")
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Len(t, watchlist.Items, 1)
+
+	require.NoError(t, os.RemoveAll(tmp))
+	require.NoError(t, s.RemoveFromWatchlist("user", "a"))
+	_, err = s.RestoreItem("user", "a")
+	assert.NoError(t, err, "a retried remove can be restored")
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1},
+		{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2},
+	})
+
+	require.NoError(t, s.SetTrashRetention(time.Millisecond))
+	require.NoError(t, s.RemoveFromWatchlist("user", "a"))
+	require.NoError(t, s.SetTrashRetention(time.Hour))
+	require.NoError(t, s.RemoveFromWatchlist("user", "b"))
+	time.Sleep(5 * time.Millisecond)
+
+	purged, err := s.PurgeExpiredTrash()
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = s.RestoreItem("user", "a")
+	assert.ErrorIs(t, err, ErrTrashNotFound)
+	_, err = s.RestoreItem("user", "b")
+	assert.NoError(t, err)
+}
+
+func TestRestoreItemChecksExpiryAndRank(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien"},
+		{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Heat"},
+		{ID: "c", MediaType: models.MediaTypeMovie, MovieID: 3, Title: "Ronin"},
+	})
+	name := "Later"
+	later, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+	_, err = s.MoveItem("user", "", "c", later.ID)
+	require.NoError(t, err)
+
+	// Ronin comes back to the end of the list and takes Heat's old rank
+	require.NoError(t, s.RemoveFromWatchlist("user", "b"))
+	_, err = s.MoveItem("user", later.ID, "c", "")
+	require.NoError(t, err)
+	_, err = s.RestoreItem("user", "b")
+	require.NoError(t, err)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	require.Len(t, watchlist.Items, 3)
+	ranks := map[string]bool{}
+	for _, item := range watchlist.Items {
+		assert.False(t, ranks[item.Rank], "rank %s is used twice", item.Rank)
+		ranks[item.Rank] = true
+	}
+
+	require.NoError(t, s.SetTrashRetention(time.Millisecond))
+	require.NoError(t, s.RemoveFromWatchlist("user", "a"))
+	time.Sleep(5 * time.Millisecond)
+	_, err = s.RestoreItem("user", "a")
+	assert.ErrorIs(t, err, ErrTrashNotFound, "expired entries can't be restored")
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// GetTVProgress returns the episode progress of a TV show in the user's watchlist.
+// The episode list is fetched from TMDB the first time progress is requested.
+func (s *WatchlistService) GetTVProgress(userID, itemID string) (*models.TVProgressSummary, error) {
+	return s.updateTVProgress(userID, itemID, nil)
+}
+
+// MarkEpisodeWatched sets the watched state of a single episode
+func (s *WatchlistService) MarkEpisodeWatched(userID, itemID string, season, episode int, watched bool) (*models.TVProgressSummary, error) {
+	return s.updateTVProgress(userID, itemID, func(progress *models.TVProgress, now time.Time) error {
+		ep := findEpisode(progress, season, episode)
+		if ep == nil {
+			return fmt.Errorf("S%02dE%02d: %w", season, episode, ErrEpisodeNotFound)
+		}
+		setEpisodeWatched(ep, watched, now)
+		return nil
+	})
+}
+
+// MarkSeasonWatched sets the watched state of every episode in a season
+func (s *WatchlistService) MarkSeasonWatched(userID, itemID string, season int, watched bool) (*models.TVProgressSummary, error) {
+	return s.updateTVProgress(userID, itemID, func(progress *models.TVProgress, now time.Time) error {
+		for i := range progress.Seasons {
+			if progress.Seasons[i].SeasonNumber != season {
+				continue
+			}
+			for j := range progress.Seasons[i].Episodes {
+				setEpisodeWatched(&progress.Seasons[i].Episodes[j], watched, now)
+			}
+			return nil
+		}
+		return fmt.Errorf("season %d: %w", season, ErrEpisodeNotFound)
+	})
+}
+
+// MarkWatchedUpTo marks every episode up to and including the given one as watched
+func (s *WatchlistService) MarkWatchedUpTo(userID, itemID string, season, episode int) (*models.TVProgressSummary, error) {
+	return s.updateTVProgress(userID, itemID, func(progress *models.TVProgress, now time.Time) error {
+		if findEpisode(progress, season, episode) == nil {
+			return fmt.Errorf("S%02dE%02d: %w", season, episode, ErrEpisodeNotFound)
+		}
+		for i := range progress.Seasons {
+			sp := &progress.Seasons[i]
+			for j := range sp.Episodes {
+				ep := &sp.Episodes[j]
+				if sp.SeasonNumber < season || (sp.SeasonNumber == season && ep.EpisodeNumber <= episode) {
+					setEpisodeWatched(ep, true, now)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// updateTVProgress loads a TV item from any of the user's lists, makes sure
+// its episode list is populated, applies update (if any) and saves the result.
+// Once every episode is watched a completion event is recorded for the show,
+// which marks it watched, and it is removed again when the show is no longer
+// complete.
+func (s *WatchlistService) updateTVProgress(userID, itemID string, update func(*models.TVProgress, time.Time) error) (*models.TVProgressSummary, error) {
+	item, err := s.findUserItem(userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if !item.IsTV() {
+		return nil, invalidf("episode progress is only available for TV shows")
+	}
+
+	// Fetch the episode list before taking the lock; TMDB calls can be slow
+	var fetched *models.TVProgress
+	if item.Progress == nil {
+		fetched, err = s.fetchTVProgress(item)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fetched == nil && update == nil {
+		return summarizeTVProgress(item), nil
+	}
+
+	// Completion is judged and recorded under the same lock as the progress
+	// is saved, so concurrent updates that finish a show record it once
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, item, err := s.findUserListItem(userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	// The list is put back as it was if the completion can't be recorded
+	original, err := s.GetList(userID, list.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.Progress == nil {
+		item.Progress = fetched
+	}
+	if item.Progress == nil {
+		return nil, fmt.Errorf("episode list of TV show %d is unavailable", item.MovieID)
+	}
+	wasCompleted := summarizeTVProgress(item).IsCompleted
+
+	now := time.Now()
+	if update != nil {
+		if err := update(item.Progress, now); err != nil {
+			return nil, err
+		}
+		item.Progress.UpdatedAt = now
+	}
+	summary := summarizeTVProgress(item)
+
+	var history *models.WatchHistory
+	if summary.IsCompleted != wasCompleted {
+		history, err = s.loadWatchHistory(userID)
+		if err != nil {
+			return nil, err
+		}
+		if summary.IsCompleted {
+			event, err := s.newWatchEvent(item, models.WatchEventInput{})
+			if err != nil {
+				return nil, err
+			}
+			event.Completion = true
+			history.Events = append(history.Events, event)
+		} else {
+			removeLatestCompletion(history, titleKey{item.MediaType, item.MovieID})
+		}
+	}
+
+	// Watching episodes is not a change to the list, so no version is recorded
+	list.UpdatedAt = now
+	if err := s.writeWatchlist(list); err != nil {
+		return nil, err
+	}
+	if history != nil {
+		history.UpdatedAt = now
+		if err := s.saveWatchHistory(history); err != nil {
+			if restoreErr := s.writeWatchlist(original); restoreErr != nil {
+				s.logger.Error("Failed to roll back progress of item %s for user %s: %v", itemID, userID, restoreErr)
+			}
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+// fetchTVProgress builds an empty progress record from TMDB season details.
+// Specials (season 0) are not tracked.
+func (s *WatchlistService) fetchTVProgress(item *models.WatchlistItem) (*models.TVProgress, error) {
+	if s.movieService == nil {
+		return nil, fmt.Errorf("TV details are unavailable")
+	}
+
+	if item.NumberOfSeasons == 0 {
+		return nil, fmt.Errorf("could not determine seasons for TV show %d", item.MovieID)
+	}
+
+	progress := &models.TVProgress{UpdatedAt: time.Now()}
+	for season := 1; season <= item.NumberOfSeasons; season++ {
+		data, err := s.movieService.GetTVSeasonDetails(item.MovieID, season)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch season %d: %w", season, err)
+		}
+
+		sp := models.SeasonProgress{SeasonNumber: season}
+		if name, ok := data["name"].(string); ok {
+			sp.Name = name
+		}
+
+		episodes, _ := data["episodes"].([]interface{})
+		for _, e := range episodes {
+			episode, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			number, ok := episode["episode_number"].(float64)
+			if !ok {
+				continue
+			}
+			ep := models.EpisodeProgress{EpisodeNumber: int(number)}
+			if name, ok := episode["name"].(string); ok {
+				ep.Name = name
+			}
+			if airDate, ok := episode["air_date"].(string); ok {
+				ep.AirDate = airDate
+			}
+			sp.Episodes = append(sp.Episodes, ep)
+		}
+
+		progress.Seasons = append(progress.Seasons, sp)
+	}
+
+	return progress, nil
+}
+
+// summarizeTVProgress computes counts, percentage and the next episode to watch
+func summarizeTVProgress(item *models.WatchlistItem) *models.TVProgressSummary {
+	summary := &models.TVProgressSummary{
+		ItemID:   item.ID,
+		Progress: item.Progress,
+	}
+
+	for _, sp := range item.Progress.Seasons {
+		for _, ep := range sp.Episodes {
+			summary.TotalEpisodes++
+			if ep.Watched {
+				summary.WatchedEpisodes++
+			} else if summary.NextEpisode == nil {
+				summary.NextEpisode = &models.EpisodeRef{
+					Season:  sp.SeasonNumber,
+					Episode: ep.EpisodeNumber,
+					Name:    ep.Name,
+					AirDate: ep.AirDate,
+				}
+			}
+		}
+	}
+
+	if summary.TotalEpisodes > 0 {
+		summary.ProgressPercent = float64(summary.WatchedEpisodes) / float64(summary.TotalEpisodes) * 100
+		summary.IsCompleted = summary.WatchedEpisodes == summary.TotalEpisodes
+	}
+
+	return summary
+}
+
+// findItem returns the watchlist item with the given id, or nil
+func findItem(watchlist *models.Watchlist, itemID string) *models.WatchlistItem {
+	for i := range watchlist.Items {
+		if watchlist.Items[i].ID == itemID {
+			return &watchlist.Items[i]
+		}
+	}
+	return nil
+}
+
+// findEpisode returns the episode with the given numbers, or nil
+func findEpisode(progress *models.TVProgress, season, episode int) *models.EpisodeProgress {
+	for i := range progress.Seasons {
+		if progress.Seasons[i].SeasonNumber != season {
+			continue
+		}
+		for j := range progress.Seasons[i].Episodes {
+			if progress.Seasons[i].Episodes[j].EpisodeNumber == episode {
+				return &progress.Seasons[i].Episodes[j]
+			}
+		}
+	}
+	return nil
+}
+
+// setEpisodeWatched updates an episode, keeping the original watch date on repeat marks
+func setEpisodeWatched(ep *models.EpisodeProgress, watched bool, now time.Time) {
+	if watched {
+		if !ep.Watched {
+			ep.Watched = true
+			ep.WatchedAt = &now
+		}
+		return
+	}
+	ep.Watched = false
+	ep.WatchedAt = nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+// newTVProgressService stores a show with two seasons of two episodes, so
+// no episode list has to be fetched
+func newTVProgressService(t *testing.T) *WatchlistService {
+	return newTestService(t, []models.WatchlistItem{{
+		ID: "tv_1", MediaType: models.MediaTypeTV, MovieID: 1, Title: "Chernobyl",
+		Progress: &models.TVProgress{Seasons: []models.SeasonProgress{
+			{SeasonNumber: 1, Episodes: []models.EpisodeProgress{{EpisodeNumber: 1}, {EpisodeNumber: 2}}},
+			{SeasonNumber: 2, Episodes: []models.EpisodeProgress{{EpisodeNumber: 1}, {EpisodeNumber: 2}}},
+		}},
+	}})
+}
+
+func TestTVProgressSummary(t *testing.T) {
+	s := newTVProgressService(t)
+
+	summary, err := s.MarkWatchedUpTo("user", "tv_1", 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.WatchedEpisodes)
+	assert.Equal(t, 4, summary.TotalEpisodes)
+	assert.InDelta(t, 75, summary.ProgressPercent, 0.001)
+	assert.Equal(t, &models.EpisodeRef{Season: 2, Episode: 2}, summary.NextEpisode)
+	assert.False(t, summary.IsCompleted)
+
+	_, err = s.MarkEpisodeWatched("user", "tv_1", 3, 1, true)
+	assert.ErrorIs(t, err, ErrEpisodeNotFound, "unknown episodes are rejected")
+	_, err = s.MarkSeasonWatched("user", "tv_1", 3, true)
+	assert.ErrorIs(t, err, ErrEpisodeNotFound)
+	_, err = s.GetTVProgress("user", "missing")
+	assert.ErrorIs(t, err, ErrItemNotFound)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.False(t, watchlist.Items[0].IsWatched, "a partly watched show is not watched")
+}
+
+func TestTVProgressNotSavedWhenCompletionFails(t *testing.T) {
+	s := newTVProgressService(t)
+	_, err := s.MarkWatchedUpTo("user", "tv_1", 2, 1)
+	require.NoError(t, err)
+
+	// A directory in the way of the history's temporary file makes its save fail
+	tmp := filepath.Join(s.dataDir, "watch_history_user.json.tmp")
+	require.NoError(t, os.Mkdir(tmp, 0755))
+	_, err = s.MarkEpisodeWatched("user", "tv_1", 2, 2, true)
+	require.Error(t, err)
+
+	summary, err := s.GetTVProgress("user", "tv_1")
+	require.NoError(t, err)
+	assert.False(t, summary.IsCompleted, "the progress is rolled back with the completion")
+
+	require.NoError(t, os.RemoveAll(tmp))
+	summary, err = s.MarkEpisodeWatched("user", "tv_1", 2, 2, true)
+	require.NoError(t, err)
+	assert.True(t, summary.IsCompleted)
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.True(t, watchlist.Items[0].IsWatched, "a retry records the completion")
+}
+
+func TestTVProgressCompletion(t *testing.T) {
+	s := newTVProgressService(t)
+	watchCount := func() int {
+		watchlist, err := s.GetWatchlist("user")
+		require.NoError(t, err)
+		return watchlist.Items[0].WatchCount
+	}
+
+	_, err := s.MarkSeasonWatched("user", "tv_1", 1, true)
+	require.NoError(t, err)
+	summary, err := s.MarkSeasonWatched("user", "tv_1", 2, true)
+	require.NoError(t, err)
+	assert.True(t, summary.IsCompleted)
+	assert.Equal(t, 1, watchCount(), "completing a show marks it watched")
+
+	// Unwatching an episode takes the show out of completion
+	summary, err = s.MarkEpisodeWatched("user", "tv_1", 2, 2, false)
+	require.NoError(t, err)
+	assert.False(t, summary.IsCompleted)
+	assert.Equal(t, 0, watchCount())
+
+	// Completing it again is not a rewatch
+	_, err = s.MarkEpisodeWatched("user", "tv_1", 2, 2, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, watchCount())
+
+	// Watches recorded by hand stay when a season is unwatched
+	_, err = s.MarkAsWatched("user", "tv_1", models.WatchEventInput{})
+	require.NoError(t, err)
+	_, err = s.MarkSeasonWatched("user", "tv_1", 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, watchCount())
+	events, err := s.GetWatchHistory("user", "tv_1")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.False(t, events[0].Completion)
+}
+
+func TestTVProgressInNamedList(t *testing.T) {
+	s := newTVProgressService(t)
+	name := "Miniseries"
+	list, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+	_, err = s.MoveItem("user", "", "tv_1", list.ID)
+	require.NoError(t, err)
+
+	summary, err := s.MarkSeasonWatched("user", "tv_1", 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.WatchedEpisodes)
+
+	// Finishing the show from two requests at once completes it once
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := s.MarkWatchedUpTo("user", "tv_1", 2, 2)
+			done <- err
+		}()
+	}
+	require.NoError(t, <-done)
+	require.NoError(t, <-done)
+
+	events, err := s.GetWatchHistory("user", "tv_1")
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestMigrateWatchlistSeedsHistory(t *testing.T) {
+	s := newTestService(t, nil)
+
+	watchedAt := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	legacy := models.Watchlist{
+		SchemaVersion: 1,
+		UserID:        "user",
+		Items: []models.WatchlistItem{
+			{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat", IsWatched: true, WatchedAt: &watchedAt},
+			{ID: "movie_2", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Ronin"},
+		},
+	}
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(s.dataDir, "watchlist_user.json"), data, 0644))
+
+	require.NoError(t, s.MigrateDataDir())
+	// A second run, as on the next startup, does not seed again
+	require.NoError(t, s.MigrateDataDir())
+
+	events, err := s.GetWatchHistory("user", "")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "movie_1", events[0].ItemID)
+	assert.True(t, events[0].WatchedAt.Equal(watchedAt))
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Equal(t, models.CurrentWatchlistSchemaVersion, watchlist.SchemaVersion)
+	assert.True(t, watchlist.Items[0].IsWatched)
+	assert.False(t, watchlist.Items[1].IsWatched)
+	assert.NotEmpty(t, watchlist.Items[0].Rank)
+
+	// An interrupted migration seeds events only once
+	changed, seed := migrateWatchlist(&legacy)
+	assert.True(t, changed)
+	require.NoError(t, s.seedWatchHistory("user", seed))
+	events, err = s.GetWatchHistory("user", "")
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
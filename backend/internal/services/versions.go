@@ -0,0 +1,410 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// Default version retention: the newest versions are always kept up to
+// defaultMaxVersions, and older ones only while younger than defaultVersionMaxAge
+const (
+	defaultMaxVersions   = 50
+	defaultVersionMaxAge = 90 * 24 * time.Hour
+	minVersionsKept      = 5
+)
+
+// versionFields are the item fields compared when diffing versions
+var versionFields = []struct {
+	name  string
+	value func(*models.WatchlistItem) interface{}
+}{
+	{"title", func(i *models.WatchlistItem) interface{} { return i.Title }},
+	{"rank", func(i *models.WatchlistItem) interface{} { return i.Rank }},
+	{"priority", func(i *models.WatchlistItem) interface{} { return i.Priority }},
+	{"tags", func(i *models.WatchlistItem) interface{} { return i.Tags }},
+	{"user_notes", func(i *models.WatchlistItem) interface{} { return i.UserNotes }},
+	{"personal_rating", func(i *models.WatchlistItem) interface{} { return i.PersonalRating }},
+	{"review", func(i *models.WatchlistItem) interface{} {
+		if i.Review == nil {
+			return nil
+		}
+		return i.Review.Text
+	}},
+	{"rating", func(i *models.WatchlistItem) interface{} { return i.Rating }},
+	{"poster_path", func(i *models.WatchlistItem) interface{} { return i.PosterPath }},
+}
+
+// SetVersionRetention sets how many versions of each list are kept and for
+// how long. The newest few versions are kept regardless of age.
+func (s *WatchlistService) SetVersionRetention(maxVersions int, maxAge time.Duration) error {
+	if maxVersions < minVersionsKept {
+		return fmt.Errorf("at least %d versions must be kept", minVersionsKept)
+	}
+	if maxAge <= 0 {
+		return fmt.Errorf("version retention age must be positive")
+	}
+	s.maxVersions = maxVersions
+	s.versionMaxAge = maxAge
+	return nil
+}
+
+// GetVersions lists the stored versions of a list, newest first, without their contents
+func (s *WatchlistService) GetVersions(userID, listID string) ([]models.WatchlistVersion, error) {
+	if _, err := s.GetList(userID, listID); err != nil {
+		return nil, err
+	}
+	numbers, err := s.versionNumbers(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]models.WatchlistVersion, 0, len(numbers))
+	for i := len(numbers) - 1; i >= 0; i-- {
+		version, err := s.loadVersion(userID, listID, numbers[i])
+		if err != nil {
+			s.logger.Warning("Skipping unreadable version %d of list %s for user %s: %v", numbers[i], listID, userID, err)
+			continue
+		}
+		version.Watchlist = nil
+		versions = append(versions, *version)
+	}
+	return versions, nil
+}
+
+// GetVersion returns one stored version of a list
+func (s *WatchlistService) GetVersion(userID, listID string, number int) (*models.WatchlistVersion, error) {
+	return s.loadVersion(userID, listID, number)
+}
+
+// DiffVersions compares two versions of a list. A to of 0 compares against
+// the current list.
+func (s *WatchlistService) DiffVersions(userID, listID string, from, to int) (*models.WatchlistDiff, error) {
+	old, err := s.loadVersion(userID, listID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var current *models.Watchlist
+	if to == 0 {
+		current, err = s.GetList(userID, listID)
+	} else {
+		var version *models.WatchlistVersion
+		version, err = s.loadVersion(userID, listID, to)
+		if version != nil {
+			current = version.Watchlist
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffWatchlists(withoutWatchState(old.Watchlist), withoutWatchState(current))
+	diff.From = from
+	diff.To = to
+	return diff, nil
+}
+
+// RestoreVersion replaces a list's items and details with those of an earlier
+// version. The watch history is not touched, so watched state stays current;
+// versions written before it was left out of them may still carry it, so it
+// is dropped here too. Shows still in the list keep their current episode
+// progress, which matches the completions in the history. Items whose id is
+// now taken by another of the user's lists get a new one.
+// The restore is itself saved as a new version and can be undone.
+func (s *WatchlistService) RestoreVersion(userID, listID string, number int) (*models.Watchlist, error) {
+	version, err := s.loadVersion(userID, listID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.updateList(userID, listID, func(watchlist *models.Watchlist) error {
+		watchlist.Name = version.Watchlist.Name
+		watchlist.Description = version.Watchlist.Description
+		watchlist.Icon = version.Watchlist.Icon
+		progress := make(map[titleKey]*models.TVProgress)
+		for _, item := range watchlist.Items {
+			if item.Progress != nil {
+				progress[titleKey{item.MediaType, item.MovieID}] = item.Progress
+			}
+		}
+		watchlist.Items = withoutWatchState(version.Watchlist).Items
+		if watchlist.Items == nil {
+			watchlist.Items = []models.WatchlistItem{}
+		}
+		for i := range watchlist.Items {
+			item := &watchlist.Items[i]
+			if current, ok := progress[titleKey{item.MediaType, item.MovieID}]; ok {
+				item.Progress = current
+			}
+		}
+		// Items moved or copied to another list since keep their id there
+		return s.renewTakenItemIDs(userID, watchlist)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Success("Restored list %s to version %d for user %s", listID, number, userID)
+	return s.GetList(userID, listID)
+}
+
+// saveVersion stores a snapshot of a list that was just saved and prunes old
+// versions. A save that leaves the list as the newest version has it is not
+// recorded again. Failures are logged; they never fail the save itself.
+func (s *WatchlistService) saveVersion(watchlist *models.Watchlist) {
+	listID := watchlist.ID
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+
+	if s.sameAsLatestVersion(watchlist, listID) {
+		return
+	}
+	if err := s.writeVersion(watchlist, listID); err != nil {
+		s.logger.Warning("Failed to save version of list %s for user %s: %v", listID, watchlist.UserID, err)
+		return
+	}
+	if err := s.pruneVersions(watchlist.UserID, listID); err != nil {
+		s.logger.Warning("Failed to prune versions of list %s for user %s: %v", listID, watchlist.UserID, err)
+	}
+}
+
+// sameAsLatestVersion reports whether the details and items of a list equal
+// those of its newest stored version
+func (s *WatchlistService) sameAsLatestVersion(watchlist *models.Watchlist, listID string) bool {
+	numbers, err := s.versionNumbers(watchlist.UserID, listID)
+	if err != nil || len(numbers) == 0 {
+		return false
+	}
+	latest, err := s.loadVersion(watchlist.UserID, listID, numbers[len(numbers)-1])
+	if err != nil {
+		return false
+	}
+
+	// Compare as JSON, the form both were stored in
+	a, errA := json.Marshal(versionContent(latest.Watchlist))
+	b, errB := json.Marshal(versionContent(watchlist))
+	return errA == nil && errB == nil && string(a) == string(b)
+}
+
+// versionContent is the part of a list a version is kept for
+func versionContent(watchlist *models.Watchlist) interface{} {
+	watchlist = withoutWatchState(watchlist)
+	return struct {
+		Name        string
+		Description string
+		Icon        string
+		Items       []models.WatchlistItem
+	}{watchlist.Name, watchlist.Description, watchlist.Icon, watchlist.Items}
+}
+
+// withoutWatchState returns a copy of a list without the watched state derived
+// from the watch history or kept per episode. Watching a title is not a change
+// to the list, so versions neither store nor compare it.
+func withoutWatchState(watchlist *models.Watchlist) *models.Watchlist {
+	stripped := *watchlist
+	if watchlist.Items != nil {
+		stripped.Items = make([]models.WatchlistItem, len(watchlist.Items))
+		for i, item := range watchlist.Items {
+			clearWatchState(&item)
+			item.Progress = withoutEpisodeState(item.Progress)
+			stripped.Items[i] = item
+		}
+	}
+	return &stripped
+}
+
+// withoutEpisodeState returns a copy of a show's progress with every episode
+// unwatched, keeping only the episode list
+func withoutEpisodeState(progress *models.TVProgress) *models.TVProgress {
+	if progress == nil {
+		return nil
+	}
+	stripped := &models.TVProgress{Seasons: make([]models.SeasonProgress, len(progress.Seasons))}
+	for i, season := range progress.Seasons {
+		season.Episodes = append([]models.EpisodeProgress(nil), season.Episodes...)
+		for j := range season.Episodes {
+			season.Episodes[j].Watched = false
+			season.Episodes[j].WatchedAt = nil
+		}
+		stripped.Seasons[i] = season
+	}
+	return stripped
+}
+
+// writeVersion writes a list snapshot as the next version number
+func (s *WatchlistService) writeVersion(watchlist *models.Watchlist, listID string) error {
+	dir := s.versionDir(watchlist.UserID, listID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create version directory: %w", err)
+	}
+
+	numbers, err := s.versionNumbers(watchlist.UserID, listID)
+	if err != nil {
+		return err
+	}
+	next := 1
+	if len(numbers) > 0 {
+		next = numbers[len(numbers)-1] + 1
+	}
+
+	version := models.WatchlistVersion{
+		Version:   next,
+		CreatedAt: time.Now(),
+		ItemCount: len(watchlist.Items),
+		Watchlist: withoutWatchState(watchlist),
+	}
+	data, err := json.MarshalIndent(version, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal version: %w", err)
+	}
+
+	return ioutil.WriteFile(s.versionPath(watchlist.UserID, listID, next), data, 0644)
+}
+
+// pruneVersions removes versions beyond the retention limits, always keeping
+// the newest minVersionsKept
+func (s *WatchlistService) pruneVersions(userID, listID string) error {
+	numbers, err := s.versionNumbers(userID, listID)
+	if err != nil {
+		return err
+	}
+
+	// Versions are numbered in the order they were created, so only the
+	// oldest ones need to be read to find those past the age limit
+	cutoff := time.Now().Add(-s.versionMaxAge)
+	expired := true
+	for i, number := range numbers {
+		newer := len(numbers) - 1 - i
+		if newer < minVersionsKept {
+			break
+		}
+
+		remove := newer >= s.maxVersions
+		if !remove && expired {
+			version, err := s.loadVersion(userID, listID, number)
+			expired = err == nil && version.CreatedAt.Before(cutoff)
+			remove = expired
+		}
+		if !remove {
+			continue
+		}
+		if err := os.Remove(s.versionPath(userID, listID, number)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteVersions removes every stored version of a list
+func (s *WatchlistService) deleteVersions(userID, listID string) error {
+	return os.RemoveAll(s.versionDir(userID, listID))
+}
+
+// versionNumbers returns the stored version numbers of a list in ascending order
+func (s *WatchlistService) versionNumbers(userID, listID string) ([]int, error) {
+	files, err := ioutil.ReadDir(s.versionDir(userID, listID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version directory: %w", err)
+	}
+
+	var numbers []int
+	for _, file := range files {
+		number, err := strconv.Atoi(strings.TrimSuffix(file.Name(), ".json"))
+		if err == nil && strings.HasSuffix(file.Name(), ".json") {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+// loadVersion reads one stored version of a list
+func (s *WatchlistService) loadVersion(userID, listID string, number int) (*models.WatchlistVersion, error) {
+	data, err := ioutil.ReadFile(s.versionPath(userID, listID, number))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("version %d: %w", number, ErrVersionNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version: %w", err)
+	}
+
+	var version models.WatchlistVersion
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal version: %w", err)
+	}
+	if version.Watchlist == nil {
+		return nil, fmt.Errorf("version %d is empty", number)
+	}
+	return &version, nil
+}
+
+// versionDir returns where the versions of a list are stored
+func (s *WatchlistService) versionDir(userID, listID string) string {
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+	return filepath.Join(s.dataDir, "versions", userID, listID)
+}
+
+// versionPath returns where one version of a list is stored
+func (s *WatchlistService) versionPath(userID, listID string, number int) string {
+	return filepath.Join(s.versionDir(userID, listID), fmt.Sprintf("%06d.json", number))
+}
+
+// diffWatchlists compares the items of two lists by item id
+func diffWatchlists(old, current *models.Watchlist) *models.WatchlistDiff {
+	diff := &models.WatchlistDiff{
+		Added:   []models.WatchlistItem{},
+		Removed: []models.WatchlistItem{},
+		Changed: []models.ItemChange{},
+	}
+
+	oldItems := make(map[string]*models.WatchlistItem, len(old.Items))
+	for i := range old.Items {
+		oldItems[old.Items[i].ID] = &old.Items[i]
+	}
+	seen := make(map[string]bool, len(current.Items))
+
+	for i := range current.Items {
+		item := &current.Items[i]
+		seen[item.ID] = true
+		before, ok := oldItems[item.ID]
+		if !ok {
+			diff.Added = append(diff.Added, *item)
+			continue
+		}
+
+		var fields []models.FieldChange
+		for _, field := range versionFields {
+			from, to := field.value(before), field.value(item)
+			if !reflect.DeepEqual(from, to) {
+				fields = append(fields, models.FieldChange{Field: field.name, From: from, To: to})
+			}
+		}
+		if len(fields) > 0 {
+			diff.Changed = append(diff.Changed, models.ItemChange{ItemID: item.ID, Title: item.Title, Fields: fields})
+		}
+	}
+
+	for _, item := range old.Items {
+		if !seen[item.ID] {
+			diff.Removed = append(diff.Removed, item)
+		}
+	}
+
+	return diff
+}
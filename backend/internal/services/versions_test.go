@@ -0,0 +1,194 @@
+package services
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestVersionsDiffAndRestore(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien"},
+		{ID: "b", MediaType: models.MediaTypeMovie, MovieID: 2, Title: "Heat"},
+	})
+
+	_, err := s.SetPriority("user", "", "a", models.PriorityHigh)
+	require.NoError(t, err)
+	require.NoError(t, s.RemoveFromWatchlist("user", "b"))
+
+	versions, err := s.GetVersions("user", "")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, 3, versions[0].Version)
+	assert.Nil(t, versions[0].Watchlist)
+
+	diff, err := s.DiffVersions("user", "", 1, 0)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Added)
+	require.Len(t, diff.Removed, 1)
+	assert.Equal(t, "b", diff.Removed[0].ID)
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, "priority", diff.Changed[0].Fields[0].Field)
+
+	watchlist, err := s.RestoreVersion("user", "", 1)
+	require.NoError(t, err)
+	require.Len(t, watchlist.Items, 2)
+	assert.Empty(t, watchlist.Items[0].Priority)
+
+	versions, err = s.GetVersions("user", "")
+	require.NoError(t, err)
+	assert.Len(t, versions, 4, "a restore is saved as a new version")
+}
+
+func TestVersionsLeaveOutWatchedState(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien"},
+	})
+
+	_, err := s.SetPriority("user", "", "a", models.PriorityHigh)
+	require.NoError(t, err)
+	_, err = s.MarkAsWatched("user", "a", models.WatchEventInput{})
+	require.NoError(t, err)
+	_, err = s.SetPriority("user", "", "a", models.PriorityHigh)
+	require.NoError(t, err)
+
+	versions, err := s.GetVersions("user", "")
+	require.NoError(t, err)
+	assert.Len(t, versions, 2, "watching a title is not a list change")
+	version, err := s.GetVersion("user", "", 2)
+	require.NoError(t, err)
+	assert.False(t, version.Watchlist.Items[0].IsWatched)
+
+	diff, err := s.DiffVersions("user", "", 1, 0)
+	require.NoError(t, err)
+	require.Len(t, diff.Changed, 1)
+	require.Len(t, diff.Changed[0].Fields, 1)
+	assert.Equal(t, "priority", diff.Changed[0].Fields[0].Field)
+
+	watchlist, err := s.RestoreVersion("user", "", 1)
+	require.NoError(t, err)
+	assert.True(t, watchlist.Items[0].IsWatched, "restoring keeps the current watched state")
+	assert.Equal(t, 1, watchlist.Items[0].WatchCount)
+
+	_, err = s.GetVersion("user", "", 99)
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+	_, err = s.GetVersions("user", "missing")
+	assert.ErrorIs(t, err, ErrListNotFound)
+}
+
+func TestVersionsLeaveOutEpisodeState(t *testing.T) {
+	s := newTVProgressService(t)
+
+	_, err := s.SetPriority("user", "", "tv_1", models.PriorityHigh)
+	require.NoError(t, err)
+	_, err = s.MarkEpisodeWatched("user", "tv_1", 1, 1, true)
+	require.NoError(t, err)
+	_, err = s.MarkSeasonWatched("user", "tv_1", 2, true)
+	require.NoError(t, err)
+	_, err = s.MarkWatchedUpTo("user", "tv_1", 1, 2)
+	require.NoError(t, err)
+
+	versions, err := s.GetVersions("user", "")
+	require.NoError(t, err)
+	assert.Len(t, versions, 2, "watching episodes is not a list change")
+	version, err := s.GetVersion("user", "", 2)
+	require.NoError(t, err)
+	assert.False(t, version.Watchlist.Items[0].Progress.Seasons[0].Episodes[0].Watched)
+
+	_, err = s.RestoreVersion("user", "", 1)
+	require.NoError(t, err)
+	summary, err := s.GetTVProgress("user", "tv_1")
+	require.NoError(t, err)
+	assert.True(t, summary.IsCompleted, "restoring keeps the current episode progress")
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.True(t, watchlist.Items[0].IsWatched)
+	assert.Empty(t, watchlist.Items[0].Priority)
+}
+
+func TestRestoreVersionRenewsTakenItemIDs(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Alien"},
+	})
+	name := "Later"
+	later, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+	_, err = s.MoveItem("user", "", "a", later.ID)
+	require.NoError(t, err)
+
+	watchlist, err := s.RestoreVersion("user", "", 1)
+	require.NoError(t, err)
+	require.Len(t, watchlist.Items, 1)
+	assert.NotEqual(t, "a", watchlist.Items[0].ID, "the moved item keeps its id")
+
+	list, _, err := s.findUserListItem("user", "a")
+	require.NoError(t, err)
+	assert.Equal(t, later.ID, list.ID)
+}
+
+func TestVersionRetention(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1},
+	})
+	require.NoError(t, s.SetVersionRetention(minVersionsKept, time.Hour))
+
+	priorities := []string{models.PriorityLow, models.PriorityHigh}
+	for i := 0; i < 10; i++ {
+		_, err := s.SetPriority("user", "", "a", priorities[i%2])
+		require.NoError(t, err)
+	}
+
+	versions, err := s.GetVersions("user", "")
+	require.NoError(t, err)
+	require.Len(t, versions, minVersionsKept)
+	assert.Equal(t, 11, versions[0].Version)
+}
+
+func TestVersionsSkipUnchangedAndBackgroundSaves(t *testing.T) {
+	stale := time.Now().Add(-48 * time.Hour)
+	s := withProvider(newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Old title", MetadataUpdatedAt: &stale},
+	}), heatResponses)
+
+	for i := 0; i < 3; i++ {
+		_, err := s.SetPriority("user", "", "movie_1", models.PriorityHigh)
+		require.NoError(t, err)
+	}
+	_, err := s.RefreshStaleMetadata(24 * time.Hour)
+	require.NoError(t, err)
+
+	versions, err := s.GetVersions("user", "")
+	require.NoError(t, err)
+	assert.Len(t, versions, 2, "repeated and background saves are not versions")
+}
+
+func TestVersionRetentionUsesCreatedAt(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "a", MediaType: models.MediaTypeMovie, MovieID: 1},
+	})
+	require.NoError(t, s.SetVersionRetention(defaultMaxVersions, time.Hour))
+
+	// Backdate the first version; its file is still new
+	version, err := s.GetVersion("user", "", 1)
+	require.NoError(t, err)
+	version.CreatedAt = time.Now().Add(-2 * time.Hour)
+	data, err := json.Marshal(version)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(s.versionPath("user", "", 1), data, 0644))
+
+	priorities := []string{models.PriorityLow, models.PriorityHigh}
+	for i := 0; i < minVersionsKept; i++ {
+		_, err := s.SetPriority("user", "", "a", priorities[i%2])
+		require.NoError(t, err)
+	}
+
+	versions, err := s.GetVersions("user", "")
+	require.NoError(t, err)
+	require.Len(t, versions, minVersionsKept)
+	assert.Equal(t, 2, versions[len(versions)-1].Version)
+}
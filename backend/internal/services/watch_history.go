@@ -0,0 +1,328 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"r.a.w/backend/internal/models"
+)
+
+// titleKey identifies a title across lists and history; TMDB ids are only
+// unique per media type
+type titleKey struct {
+	mediaType string
+	id        int
+}
+
+// GetWatchHistory returns a user's watch events, newest first. If itemID is
+// set, only events for that item's title are returned.
+func (s *WatchlistService) GetWatchHistory(userID, itemID string) ([]models.WatchEvent, error) {
+	history, err := s.loadWatchHistory(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := history.Events
+	if itemID != "" {
+		item, err := s.findUserItem(userID, itemID)
+		if err != nil {
+			return nil, err
+		}
+
+		key := titleKey{item.MediaType, item.MovieID}
+		events = nil
+		for _, event := range history.Events {
+			if (titleKey{event.MediaType, event.MovieID}) == key {
+				events = append(events, event)
+			}
+		}
+	}
+
+	sorted := make([]models.WatchEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].WatchedAt.After(sorted[j].WatchedAt)
+	})
+	return sorted, nil
+}
+
+// MarkAsWatched records a new watch event for an item. Watching an item again
+// appends another event, so rewatches are counted.
+func (s *WatchlistService) MarkAsWatched(userID, itemID string, input models.WatchEventInput) (*models.WatchEvent, error) {
+	list, item, err := s.findUserListItem(userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.recordWatch(userID, item, input)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordOwnerActivity(userID, list.ID, models.ListActivity{Action: models.ActivityWatched, ItemID: item.ID, Title: item.Title})
+	return event, nil
+}
+
+// recordWatch appends a watch event for item to a user's history. The item
+// need not be in one of the user's own lists.
+func (s *WatchlistService) recordWatch(userID string, item *models.WatchlistItem, input models.WatchEventInput) (*models.WatchEvent, error) {
+	event, err := s.newWatchEvent(item, input)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.updateWatchHistory(userID, func(history *models.WatchHistory) error {
+		history.Events = append(history.Events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// MarkAsUnwatched undoes the most recent watch of an item. Earlier watches
+// stay in the history, so a rewatched title remains watched.
+func (s *WatchlistService) MarkAsUnwatched(userID, itemID string) error {
+	list, item, err := s.findUserListItem(userID, itemID)
+	if err != nil {
+		return err
+	}
+
+	err = s.updateWatchHistory(userID, func(history *models.WatchHistory) error {
+		return removeLatestWatch(history, titleKey{item.MediaType, item.MovieID})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.recordOwnerActivity(userID, list.ID, models.ListActivity{Action: models.ActivityUnwatched, ItemID: item.ID, Title: item.Title})
+	return nil
+}
+
+// newWatchEvent builds a watch event for an item, watched now unless input says otherwise
+func (s *WatchlistService) newWatchEvent(item *models.WatchlistItem, input models.WatchEventInput) (models.WatchEvent, error) {
+	now := time.Now()
+	event := models.WatchEvent{
+		ID:        s.generateID(),
+		ItemID:    item.ID,
+		MediaType: item.MediaType,
+		MovieID:   item.MovieID,
+		Title:     item.Title,
+		WatchedAt: now,
+		CreatedAt: now,
+	}
+	err := s.applyWatchEventInput(&event, input, now)
+	return event, err
+}
+
+// removeLatestWatch removes the most recent watch event for a title
+func removeLatestWatch(history *models.WatchHistory, key titleKey) error {
+	latest := latestWatch(history, key, false)
+	if latest == -1 {
+		return invalidf("item has not been watched")
+	}
+
+	history.Events = append(history.Events[:latest], history.Events[latest+1:]...)
+	return nil
+}
+
+// removeLatestCompletion removes the most recent watch event that episode
+// progress recorded for a title, if there is one
+func removeLatestCompletion(history *models.WatchHistory, key titleKey) {
+	if latest := latestWatch(history, key, true); latest != -1 {
+		history.Events = append(history.Events[:latest], history.Events[latest+1:]...)
+	}
+}
+
+// latestWatch returns the index of the most recent watch event for a title,
+// only counting completion events if completionOnly is set, or -1
+func latestWatch(history *models.WatchHistory, key titleKey, completionOnly bool) int {
+	latest := -1
+	for i, event := range history.Events {
+		if (titleKey{event.MediaType, event.MovieID}) != key || (completionOnly && !event.Completion) {
+			continue
+		}
+		if latest == -1 || event.WatchedAt.After(history.Events[latest].WatchedAt) {
+			latest = i
+		}
+	}
+	return latest
+}
+
+// UpdateWatchEvent edits the date, rating, notes or platform of a watch event
+func (s *WatchlistService) UpdateWatchEvent(userID, eventID string, input models.WatchEventInput) (*models.WatchEvent, error) {
+	var updated models.WatchEvent
+	err := s.updateWatchHistory(userID, func(history *models.WatchHistory) error {
+		for i := range history.Events {
+			if history.Events[i].ID != eventID {
+				continue
+			}
+			if err := s.applyWatchEventInput(&history.Events[i], input, time.Now()); err != nil {
+				return err
+			}
+			updated = history.Events[i]
+			return nil
+		}
+		return ErrEventNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// DeleteWatchEvent removes a watch event from the history
+func (s *WatchlistService) DeleteWatchEvent(userID, eventID string) error {
+	return s.updateWatchHistory(userID, func(history *models.WatchHistory) error {
+		for i := range history.Events {
+			if history.Events[i].ID == eventID {
+				history.Events = append(history.Events[:i], history.Events[i+1:]...)
+				return nil
+			}
+		}
+		return ErrEventNotFound
+	})
+}
+
+// applyWatchEventInput copies the set fields of input onto event
+func (s *WatchlistService) applyWatchEventInput(event *models.WatchEvent, input models.WatchEventInput, now time.Time) error {
+	if input.WatchedAt != nil {
+		if input.WatchedAt.After(now) {
+			return invalidf("watch date cannot be in the future")
+		}
+		event.WatchedAt = *input.WatchedAt
+	}
+
+	if input.Rating != nil {
+		if *input.Rating == 0 {
+			event.Rating = nil
+		} else {
+			scale := input.Scale
+			if scale == 0 {
+				scale = s.ratingScale
+			}
+			if err := validateRating(*input.Rating, scale); err != nil {
+				return err
+			}
+			event.Rating = &models.PersonalRating{
+				Value:   *input.Rating,
+				Scale:   scale,
+				RatedAt: now,
+			}
+		}
+	}
+
+	if input.Notes != nil {
+		event.Notes = *input.Notes
+	}
+	if input.Platform != nil {
+		event.Platform = *input.Platform
+	}
+
+	event.UpdatedAt = now
+	return nil
+}
+
+// applyWatchHistory derives IsWatched, WatchedAt and WatchCount for every item
+// from the user's watch history
+func applyWatchHistory(watchlist *models.Watchlist, history *models.WatchHistory) {
+	counts := make(map[titleKey]int)
+	latest := make(map[titleKey]time.Time)
+	for _, event := range history.Events {
+		key := titleKey{event.MediaType, event.MovieID}
+		counts[key]++
+		if event.WatchedAt.After(latest[key]) {
+			latest[key] = event.WatchedAt
+		}
+	}
+
+	for i := range watchlist.Items {
+		item := &watchlist.Items[i]
+		key := titleKey{item.MediaType, item.MovieID}
+		item.WatchCount = counts[key]
+		item.IsWatched = item.WatchCount > 0
+		item.WatchedAt = nil
+		if item.IsWatched {
+			watchedAt := latest[key]
+			item.WatchedAt = &watchedAt
+		}
+	}
+}
+
+// clearWatchState resets the fields applyWatchHistory derives
+func clearWatchState(item *models.WatchlistItem) {
+	item.IsWatched = false
+	item.WatchedAt = nil
+	item.WatchCount = 0
+}
+
+// updateWatchHistory loads a user's watch history, applies fn and saves it
+// while holding the service lock
+func (s *WatchlistService) updateWatchHistory(userID string, fn func(*models.WatchHistory) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, err := s.loadWatchHistory(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(history); err != nil {
+		return err
+	}
+
+	history.UpdatedAt = time.Now()
+	return s.saveWatchHistory(history)
+}
+
+// loadWatchHistory reads a user's watch history, returning an empty one if none is stored
+func (s *WatchlistService) loadWatchHistory(userID string) (*models.WatchHistory, error) {
+	filePath := filepath.Join(s.dataDir, fmt.Sprintf("watch_history_%s.json", userID))
+
+	data, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return &models.WatchHistory{
+			UserID: userID,
+			Events: []models.WatchEvent{},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch history file: %w", err)
+	}
+
+	var history models.WatchHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal watch history: %w", err)
+	}
+
+	return &history, nil
+}
+
+// saveWatchHistory saves a user's watch history to file
+func (s *WatchlistService) saveWatchHistory(history *models.WatchHistory) error {
+	filePath := filepath.Join(s.dataDir, fmt.Sprintf("watch_history_%s.json", history.UserID))
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watch history: %w", err)
+	}
+
+	// The history is the only record of what was watched, so it is written
+	// to a temporary file first, like lists, and never left half-written
+	if err := ioutil.WriteFile(filePath+".tmp", data, 0644); err != nil {
+		os.Remove(filePath + ".tmp")
+		return fmt.Errorf("failed to save watch history: %w", err)
+	}
+	if err := os.Rename(filePath+".tmp", filePath); err != nil {
+		return fmt.Errorf("failed to save watch history: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestUpdateWatchEvent(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+	first := time.Date(2023, 6, 1, 20, 0, 0, 0, time.UTC)
+	event, err := s.MarkAsWatched("user", "movie_1", models.WatchEventInput{WatchedAt: &first})
+	require.NoError(t, err)
+	_, err = s.MarkAsWatched("user", "movie_1", models.WatchEventInput{})
+	require.NoError(t, err)
+
+	rewatched := time.Date(2024, 1, 5, 20, 0, 0, 0, time.UTC)
+	rating := 4.5
+	notes := "at the cinema"
+	updated, err := s.UpdateWatchEvent("user", event.ID, models.WatchEventInput{WatchedAt: &rewatched, Rating: &rating, Notes: &notes})
+	require.NoError(t, err)
+	assert.True(t, updated.WatchedAt.Equal(rewatched))
+	assert.Equal(t, 4.5, updated.Rating.Value)
+	assert.Equal(t, "at the cinema", updated.Notes)
+
+	future := time.Now().Add(24 * time.Hour)
+	_, err = s.UpdateWatchEvent("user", event.ID, models.WatchEventInput{WatchedAt: &future})
+	assert.ErrorIs(t, err, ErrInvalidInput, "watch dates cannot be in the future")
+	_, err = s.MarkAsWatched("user", "movie_1", models.WatchEventInput{WatchedAt: &future})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	bad := 4.25
+	_, err = s.UpdateWatchEvent("user", event.ID, models.WatchEventInput{Rating: &bad})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = s.UpdateWatchEvent("user", "missing", models.WatchEventInput{Notes: &notes})
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	events, err := s.GetWatchHistory("user", "movie_1")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	for _, e := range events {
+		if e.ID == event.ID {
+			assert.True(t, e.WatchedAt.Equal(rewatched), "a rejected edit changes nothing")
+		}
+	}
+}
+
+func TestDeleteWatchEventRecomputesWatchedState(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+	first := time.Date(2023, 6, 1, 20, 0, 0, 0, time.UTC)
+	second := time.Date(2024, 1, 5, 20, 0, 0, 0, time.UTC)
+	older, err := s.MarkAsWatched("user", "movie_1", models.WatchEventInput{WatchedAt: &first})
+	require.NoError(t, err)
+	newer, err := s.MarkAsWatched("user", "movie_1", models.WatchEventInput{WatchedAt: &second})
+	require.NoError(t, err)
+
+	item := func() models.WatchlistItem {
+		watchlist, err := s.GetWatchlist("user")
+		require.NoError(t, err)
+		return watchlist.Items[0]
+	}
+	assert.Equal(t, 2, item().WatchCount)
+	assert.True(t, item().WatchedAt.Equal(second))
+
+	require.NoError(t, s.DeleteWatchEvent("user", newer.ID))
+	assert.Equal(t, 1, item().WatchCount)
+	assert.True(t, item().WatchedAt.Equal(first), "the watch date falls back to the remaining watch")
+
+	require.NoError(t, s.DeleteWatchEvent("user", older.ID))
+	assert.False(t, item().IsWatched)
+	assert.Nil(t, item().WatchedAt)
+
+	assert.ErrorIs(t, s.DeleteWatchEvent("user", older.ID), ErrEventNotFound)
+	assert.ErrorIs(t, s.MarkAsUnwatched("user", "movie_1"), ErrInvalidInput, "the item has no watch left to undo")
+	assert.ErrorIs(t, s.MarkAsUnwatched("user", "missing"), ErrItemNotFound)
+}
+
+func TestMarkAsWatchedInNamedList(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+	name := "Heists"
+	list, err := s.CreateList("user", models.ListInput{Name: &name})
+	require.NoError(t, err)
+	_, err = s.MoveItem("user", "", "movie_1", list.ID)
+	require.NoError(t, err)
+
+	_, err = s.MarkAsWatched("user", "movie_1", models.WatchEventInput{})
+	require.NoError(t, err)
+	heists, err := s.GetList("user", list.ID)
+	require.NoError(t, err)
+	require.Len(t, heists.Items, 1)
+	assert.True(t, heists.Items[0].IsWatched)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Empty(t, watchlist.Items, "moving saves the source as well")
+	assert.ErrorIs(t, s.RemoveFromWatchlist("user", "movie_1"), ErrItemNotFound)
+	require.NoError(t, s.MarkAsUnwatched("user", "movie_1"))
+}
@@ -4,45 +4,108 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"r.a.w/backend/internal/api"
 	"r.a.w/backend/internal/models"
 	"r.a.w/backend/pkg/logger"
 )
 
+// Errors returned for lists and their items, mapped to 400, 409 and 404.
+// Invalid input errors carry their own message, see invalidf.
+var (
+	ErrInvalidInput    = errors.New("invalid input")
+	ErrItemExists      = errors.New("item is already in the list")
+	ErrTitleNotFound   = errors.New("title not found on TMDB")
+	ErrItemNotFound    = errors.New("item not found in watchlist")
+	ErrListNotFound    = errors.New("list not found")
+	ErrEpisodeNotFound = errors.New("episode not found")
+	ErrEventNotFound   = errors.New("watch event not found")
+	ErrTrashNotFound   = errors.New("item not found in trash")
+	ErrVersionNotFound = errors.New("version not found")
+	ErrImportNotFound  = errors.New("import not found")
+)
+
+// invalidInput is an error in the caller's input that matches ErrInvalidInput
+type invalidInput struct {
+	message string
+}
+
+func (e *invalidInput) Error() string { return e.message }
+
+func (e *invalidInput) Is(target error) bool { return target == ErrInvalidInput }
+
+// invalidf formats an error that matches ErrInvalidInput
+func invalidf(format string, args ...interface{}) error {
+	return &invalidInput{message: fmt.Sprintf(format, args...)}
+}
+
 // WatchlistService handles watchlist operations
 type WatchlistService struct {
-	dataDir string
-	logger  *logger.Logger
+	dataDir        string
+	movieService   *api.MovieService
+	logger         *logger.Logger
+	ratingScale    int
+	trashRetention time.Duration
+	maxVersions    int
+	versionMaxAge  time.Duration
+	mu             sync.Mutex
+	// importMu serializes changes to import reports. It is taken before mu
+	// and held while metadata is fetched, which mu must never be.
+	importMu sync.Mutex
 }
 
-// NewWatchlistService creates a new watchlist service
-func NewWatchlistService(dataDir string, logger *logger.Logger) *WatchlistService {
+// NewWatchlistService creates a new watchlist service.
+// movieService may be nil, in which case items are stored without enrichment.
+func NewWatchlistService(dataDir string, movieService *api.MovieService, logger *logger.Logger) *WatchlistService {
 	// Ensure data directory exists
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		logger.Error("Failed to create data directory: %v", err)
 	}
 	
 	return &WatchlistService{
-		dataDir: dataDir,
-		logger:  logger,
+		dataDir:        dataDir,
+		movieService:   movieService,
+		logger:         logger,
+		ratingScale:    models.RatingScaleFive,
+		trashRetention: defaultTrashRetention,
+		maxVersions:    defaultMaxVersions,
+		versionMaxAge:  defaultVersionMaxAge,
 	}
 }
 
-// GetWatchlist retrieves a user's watchlist
+// GetWatchlist retrieves a user's default watchlist
 func (s *WatchlistService) GetWatchlist(userID string) (*models.Watchlist, error) {
-	filePath := filepath.Join(s.dataDir, fmt.Sprintf("watchlist_%s.json", userID))
+	return s.GetList(userID, models.DefaultListID)
+}
+
+// GetList retrieves one of a user's lists. The default list always exists;
+// other lists must have been created first.
+func (s *WatchlistService) GetList(userID, listID string) (*models.Watchlist, error) {
+	if listID == "" {
+		listID = models.DefaultListID
+	}
+	filePath := s.listFilePath(userID, listID)
 	
 	// If file doesn't exist, return empty watchlist
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if listID != models.DefaultListID {
+			return nil, ErrListNotFound
+		}
 		return &models.Watchlist{
+			SchemaVersion: models.CurrentWatchlistSchemaVersion,
+			ID:        models.DefaultListID,
 			UserID:    userID,
+			Name:      defaultListName,
+			IsDefault: true,
 			Items:     []models.WatchlistItem{},
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
@@ -59,94 +122,136 @@ func (s *WatchlistService) GetWatchlist(userID string) (*models.Watchlist, error
 		return nil, fmt.Errorf("failed to unmarshal watchlist: %w", err)
 	}
 	
-	return &watchlist, nil
-}
-
-// AddToWatchlist adds a movie to the user's watchlist
-func (s *WatchlistService) AddToWatchlist(userID string, item models.WatchlistItem) error {
-	watchlist, err := s.GetWatchlist(userID)
-	if err != nil {
-		return err
+	if migrated, seed := migrateWatchlist(&watchlist); migrated {
+		if err := s.seedWatchHistory(userID, seed); err != nil {
+			return nil, err
+		}
+		if err := s.writeWatchlist(&watchlist); err != nil {
+			s.logger.Warning("Failed to persist migrated watchlist for user %s: %v", userID, err)
+		}
 	}
 	
-	// Check if item already exists
-	for _, existingItem := range watchlist.Items {
-		if existingItem.MovieID == item.MovieID {
-			return fmt.Errorf("movie already in watchlist")
+	// Files written before named lists existed have no id or name
+	if listID == models.DefaultListID {
+		watchlist.ID = models.DefaultListID
+		watchlist.IsDefault = true
+		if watchlist.Name == "" {
+			watchlist.Name = defaultListName
 		}
 	}
 	
-	// Generate unique ID for the item
-	item.ID = s.generateID()
-	item.AddedAt = time.Now()
-	item.IsWatched = false
+	sortByRank(watchlist.Items)
 	
-	watchlist.Items = append(watchlist.Items, item)
-	watchlist.UpdatedAt = time.Now()
+	// Watched state is derived from the watch history
+	history, err := s.loadWatchHistory(userID)
+	if err != nil {
+		return nil, err
+	}
+	applyWatchHistory(&watchlist, history)
 	
-	return s.saveWatchlist(watchlist)
+	return &watchlist, nil
 }
 
-// RemoveFromWatchlist removes a movie from the user's watchlist
-func (s *WatchlistService) RemoveFromWatchlist(userID, itemID string) error {
-	watchlist, err := s.GetWatchlist(userID)
+// AddToWatchlist adds a movie or TV show to the user's default list, see AddToList
+func (s *WatchlistService) AddToWatchlist(userID, mediaType string, tmdbID int) (*models.WatchlistItem, error) {
+	return s.AddToList(userID, models.DefaultListID, mediaType, tmdbID)
+}
+
+// AddToList adds a movie or TV show to one of the user's lists.
+// Only the media type and TMDB id are taken from the caller; everything else
+// is filled in from TMDB and OMDB.
+func (s *WatchlistService) AddToList(userID, listID, mediaType string, tmdbID int) (*models.WatchlistItem, error) {
+	item, err := s.prepareItem(mediaType, tmdbID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	
-	// Find and remove the item
-	for i, item := range watchlist.Items {
-		if item.ID == itemID {
-			watchlist.Items = append(watchlist.Items[:i], watchlist.Items[i+1:]...)
-			watchlist.UpdatedAt = time.Now()
-			return s.saveWatchlist(watchlist)
+	err = s.updateList(userID, listID, func(watchlist *models.Watchlist) error {
+		// The owner is credited like members are on collaborative lists
+		collab, err := s.findCollaboration(userID, watchlist.ID)
+		if err != nil {
+			return err
+		}
+		if collab != nil {
+			item.AddedBy = userID
 		}
+		return s.insertItem(watchlist, item)
+	})
+	if err != nil {
+		return nil, err
 	}
 	
-	return fmt.Errorf("item not found in watchlist")
+	s.recordOwnerActivity(userID, listID, models.ListActivity{Action: models.ActivityAdded, ItemID: item.ID, Title: item.Title})
+	return item, nil
 }
 
-// MarkAsWatched marks a movie as watched in the user's watchlist
-func (s *WatchlistService) MarkAsWatched(userID, itemID string, notes string) error {
-	watchlist, err := s.GetWatchlist(userID)
-	if err != nil {
-		return err
+// prepareItem validates a media type and TMDB id and builds a new item with
+// its metadata filled in. It does not touch any list.
+func (s *WatchlistService) prepareItem(mediaType string, tmdbID int) (*models.WatchlistItem, error) {
+	if mediaType == "" {
+		mediaType = models.MediaTypeMovie
 	}
-	
-	// Find and update the item
-	for i, item := range watchlist.Items {
-		if item.ID == itemID {
-			now := time.Now()
-			watchlist.Items[i].IsWatched = true
-			watchlist.Items[i].WatchedAt = &now
-			watchlist.Items[i].UserNotes = notes
-			watchlist.UpdatedAt = time.Now()
-			return s.saveWatchlist(watchlist)
-		}
+	if mediaType != models.MediaTypeMovie && mediaType != models.MediaTypeTV {
+		return nil, invalidf("invalid media type %q", mediaType)
+	}
+	if tmdbID <= 0 {
+		return nil, invalidf("invalid %s id %d", mediaType, tmdbID)
 	}
 	
-	return fmt.Errorf("item not found in watchlist")
+	item := &models.WatchlistItem{
+		MediaType: mediaType,
+		MovieID:   tmdbID,
+	}
+	if err := s.fetchMetadata(item); err != nil {
+		return nil, err
+	}
+	return item, nil
 }
 
-// MarkAsUnwatched marks a movie as unwatched in the user's watchlist
-func (s *WatchlistService) MarkAsUnwatched(userID, itemID string) error {
-	watchlist, err := s.GetWatchlist(userID)
+// RemoveFromWatchlist removes a movie from the user's default list, see RemoveFromList
+func (s *WatchlistService) RemoveFromWatchlist(userID, itemID string) error {
+	return s.RemoveFromList(userID, models.DefaultListID, itemID)
+}
+
+// RemoveFromList removes an item from one of the user's lists. The item goes
+// to the user's trash, from where it can be restored until it expires.
+func (s *WatchlistService) RemoveFromList(userID, listID, itemID string) error {
+	item, err := s.removeToTrash(userID, listID, itemID)
 	if err != nil {
 		return err
 	}
-	
-	// Find and update the item
+
+	s.recordOwnerActivity(userID, listID, models.ListActivity{Action: models.ActivityRemoved, ItemID: itemID, Title: item.Title})
+	return nil
+}
+
+// removeItem splices an item out of a list, reporting whether it was found
+func removeItem(watchlist *models.Watchlist, itemID string) bool {
 	for i, item := range watchlist.Items {
 		if item.ID == itemID {
-			watchlist.Items[i].IsWatched = false
-			watchlist.Items[i].WatchedAt = nil
-			watchlist.Items[i].UserNotes = ""
-			watchlist.UpdatedAt = time.Now()
-			return s.saveWatchlist(watchlist)
+			watchlist.Items = append(watchlist.Items[:i], watchlist.Items[i+1:]...)
+			return true
 		}
 	}
+	return false
+}
+
+// insertItem appends an item to a list with a fresh id, rejecting duplicates
+func (s *WatchlistService) insertItem(watchlist *models.Watchlist, item *models.WatchlistItem) error {
+	// Check if item already exists; TMDB ids are only unique per media type
+	for _, existingItem := range watchlist.Items {
+		if existingItem.MediaType == item.MediaType && existingItem.MovieID == item.MovieID {
+			return fmt.Errorf("%s %d: %w", item.MediaType, item.MovieID, ErrItemExists)
+		}
+	}
+	
+	// Generate unique ID for the item and place it at the end
+	item.ID = s.generateID()
+	item.AddedAt = time.Now()
+	item.Rank = nextRank(watchlist.Items)
 	
-	return fmt.Errorf("item not found in watchlist")
+	watchlist.Items = append(watchlist.Items, *item)
+	return nil
 }
 
 // GetWatchlistStats returns statistics about the user's watchlist
@@ -156,6 +261,11 @@ func (s *WatchlistService) GetWatchlistStats(userID string) (*models.WatchlistSt
 		return nil, err
 	}
 	
+	return computeStats(watchlist), nil
+}
+
+// computeStats summarizes the items of a watchlist
+func computeStats(watchlist *models.Watchlist) *models.WatchlistStats {
 	stats := &models.WatchlistStats{
 		TotalItems: len(watchlist.Items),
 	}
@@ -163,17 +273,30 @@ func (s *WatchlistService) GetWatchlistStats(userID string) (*models.WatchlistSt
 	var totalRating float64
 	var ratingCount int
 	genreCount := make(map[string]int)
+	typeRatings := make(map[string][2]float64)
 	
 	for _, item := range watchlist.Items {
+		typeStats := &stats.Movies
+		if item.IsTV() {
+			typeStats = &stats.TVShows
+			typeStats.TotalEpisodes += item.NumberOfEpisodes
+		}
+		typeStats.TotalItems++
+		
 		if item.IsWatched {
 			stats.WatchedItems++
+			typeStats.WatchedItems++
 		} else {
 			stats.UnwatchedItems++
+			typeStats.UnwatchedItems++
 		}
 		
 		if item.Rating > 0 {
 			totalRating += item.Rating
 			ratingCount++
+			
+			r := typeRatings[item.MediaType]
+			typeRatings[item.MediaType] = [2]float64{r[0] + item.Rating, r[1] + 1}
 		}
 		
 		// Count genres
@@ -189,6 +312,14 @@ func (s *WatchlistService) GetWatchlistStats(userID string) (*models.WatchlistSt
 	if ratingCount > 0 {
 		stats.AverageRating = totalRating / float64(ratingCount)
 	}
+	if r := typeRatings[models.MediaTypeMovie]; r[1] > 0 {
+		stats.Movies.AverageRating = r[0] / r[1]
+	}
+	if r := typeRatings[models.MediaTypeTV]; r[1] > 0 {
+		stats.TVShows.AverageRating = r[0] / r[1]
+	}
+	
+	addRatingStats(stats, watchlist.Items)
 	
 	// Convert genre map to sorted slice
 	for genre, count := range genreCount {
@@ -208,81 +339,119 @@ func (s *WatchlistService) GetWatchlistStats(userID string) (*models.WatchlistSt
 		stats.TopGenres = stats.TopGenres[:5]
 	}
 	
-	return stats, nil
+	return stats
 }
 
-// CreateShareableWatchlist creates a shareable version of the watchlist
-func (s *WatchlistService) CreateShareableWatchlist(userID, title, description string, isPublic bool) (*models.ShareableWatchlist, error) {
-	watchlist, err := s.GetWatchlist(userID)
-	if err != nil {
-		return nil, err
-	}
-	
-	shareableWatchlist := &models.ShareableWatchlist{
-		ID:          s.generateID(),
-		Title:       title,
-		Description: description,
-		Items:       watchlist.Items,
-		CreatedBy:   userID,
-		CreatedAt:   time.Now(),
-		IsPublic:    isPublic,
-		ShareToken:  s.generateShareToken(),
-	}
+// updateWatchlist applies fn to a user's default list, see updateList
+func (s *WatchlistService) updateWatchlist(userID string, fn func(*models.Watchlist) error) error {
+	return s.updateList(userID, models.DefaultListID, fn)
+}
+
+// updateList loads one of a user's lists, applies fn and saves the result
+// while holding the service lock, so that concurrent requests and background
+// jobs don't overwrite each other's changes. Nothing is saved if fn fails.
+func (s *WatchlistService) updateList(userID, listID string, fn func(*models.Watchlist) error) error {
+	return s.modifyList(userID, listID, true, fn)
+}
+
+// refreshList is updateList for background jobs; the change is not recorded
+// as a version of the list
+func (s *WatchlistService) refreshList(userID, listID string, fn func(*models.Watchlist) error) error {
+	return s.modifyList(userID, listID, false, fn)
+}
+
+// modifyList implements updateList and refreshList
+func (s *WatchlistService) modifyList(userID, listID string, version bool, fn func(*models.Watchlist) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	
-	// Save shareable watchlist
-	filePath := filepath.Join(s.dataDir, fmt.Sprintf("shared_watchlist_%s.json", shareableWatchlist.ID))
-	data, err := json.MarshalIndent(shareableWatchlist, "", "  ")
+	watchlist, err := s.GetList(userID, listID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal shareable watchlist: %w", err)
+		return err
 	}
 	
-	if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
-		return nil, fmt.Errorf("failed to save shareable watchlist: %w", err)
+	if err := fn(watchlist); err != nil {
+		return err
 	}
 	
-	return shareableWatchlist, nil
+	watchlist.UpdatedAt = time.Now()
+	if !version {
+		return s.writeWatchlist(watchlist)
+	}
+	return s.saveWatchlist(watchlist)
 }
 
-// GetSharedWatchlist retrieves a shared watchlist by token
-func (s *WatchlistService) GetSharedWatchlist(shareToken string) (*models.ShareableWatchlist, error) {
-	// Search for the shared watchlist file with the given token
+// storedLists returns a reference to every list saved in the data directory
+func (s *WatchlistService) storedLists() ([]listRef, error) {
 	files, err := ioutil.ReadDir(s.dataDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read data directory: %w", err)
 	}
 	
+	var refs []listRef
 	for _, file := range files {
-		if strings.HasPrefix(file.Name(), "shared_watchlist_") {
-			filePath := filepath.Join(s.dataDir, file.Name())
-			data, err := ioutil.ReadFile(filePath)
+		name := file.Name()
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		
+		switch {
+		case strings.HasPrefix(name, "watchlist_"):
+			userID := strings.TrimSuffix(strings.TrimPrefix(name, "watchlist_"), ".json")
+			refs = append(refs, listRef{userID: userID, listID: models.DefaultListID})
+		case strings.HasPrefix(name, "list_"):
+			// User ids may contain underscores, so read the owner from the file
+			data, err := ioutil.ReadFile(filepath.Join(s.dataDir, name))
 			if err != nil {
 				continue
 			}
-			
-			var sharedWatchlist models.ShareableWatchlist
-			if err := json.Unmarshal(data, &sharedWatchlist); err != nil {
+			var list models.Watchlist
+			if err := json.Unmarshal(data, &list); err != nil {
 				continue
 			}
-			
-			if sharedWatchlist.ShareToken == shareToken {
-				return &sharedWatchlist, nil
-			}
+			refs = append(refs, listRef{userID: list.UserID, listID: list.ID})
 		}
 	}
 	
-	return nil, fmt.Errorf("shared watchlist not found")
+	return refs, nil
+}
+
+// listFilePath returns where a list is stored. The default list keeps the
+// original watchlist_<userID>.json name so existing data keeps working.
+func (s *WatchlistService) listFilePath(userID, listID string) string {
+	if listID == "" || listID == models.DefaultListID {
+		return filepath.Join(s.dataDir, fmt.Sprintf("watchlist_%s.json", userID))
+	}
+	return filepath.Join(s.dataDir, fmt.Sprintf("list_%s_%s.json", userID, listID))
 }
 
-// saveWatchlist saves the watchlist to file
+// saveWatchlist saves the watchlist to file and records it as a new version
 func (s *WatchlistService) saveWatchlist(watchlist *models.Watchlist) error {
-	filePath := filepath.Join(s.dataDir, fmt.Sprintf("watchlist_%s.json", watchlist.UserID))
+	if err := s.writeWatchlist(watchlist); err != nil {
+		return err
+	}
+	s.saveVersion(watchlist)
+	return nil
+}
+
+// writeWatchlist saves the watchlist to file without recording a version. It
+// is used for maintenance such as migrations and metadata refreshes, which
+// users don't need to undo.
+func (s *WatchlistService) writeWatchlist(watchlist *models.Watchlist) error {
+	filePath := s.listFilePath(watchlist.UserID, watchlist.ID)
 	
 	data, err := json.MarshalIndent(watchlist, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal watchlist: %w", err)
 	}
 	
-	if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
+	// Write to a temporary file first, so that readers that don't take the
+	// lock never see a half-written list
+	if err := ioutil.WriteFile(filePath+".tmp", data, 0644); err != nil {
+		os.Remove(filePath + ".tmp")
+		return fmt.Errorf("failed to save watchlist: %w", err)
+	}
+	if err := os.Rename(filePath+".tmp", filePath); err != nil {
 		return fmt.Errorf("failed to save watchlist: %w", err)
 	}
 	
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"r.a.w/backend/internal/models"
+)
+
+func TestInsertItemDedupsByMediaTypeAndID(t *testing.T) {
+	s := newTestService(t, []models.WatchlistItem{
+		{ID: "movie_1", MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"},
+	})
+
+	err := s.updateWatchlist("user", func(watchlist *models.Watchlist) error {
+		return s.insertItem(watchlist, &models.WatchlistItem{MediaType: models.MediaTypeTV, MovieID: 1, Title: "Chernobyl"})
+	})
+	require.NoError(t, err, "TMDB ids are only unique per media type")
+
+	err = s.updateWatchlist("user", func(watchlist *models.Watchlist) error {
+		return s.insertItem(watchlist, &models.WatchlistItem{MediaType: models.MediaTypeMovie, MovieID: 1, Title: "Heat"})
+	})
+	assert.Error(t, err)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	require.Len(t, watchlist.Items, 2)
+	assert.Equal(t, models.MediaTypeTV, watchlist.Items[1].MediaType)
+	assert.NotEmpty(t, watchlist.Items[1].ID)
+	assert.Greater(t, watchlist.Items[1].Rank, watchlist.Items[0].Rank)
+}
+
+func TestPrepareItemValidatesInput(t *testing.T) {
+	s := newTestService(t, nil)
+
+	_, err := s.AddToWatchlist("user", "book", 1)
+	assert.Error(t, err)
+	_, err = s.AddToWatchlist("user", models.MediaTypeTV, 0)
+	assert.Error(t, err)
+
+	watchlist, err := s.GetWatchlist("user")
+	require.NoError(t, err)
+	assert.Empty(t, watchlist.Items)
+}
+
+func TestMigrateWatchlistDefaultsMediaType(t *testing.T) {
+	watchlist := &models.Watchlist{Items: []models.WatchlistItem{
+		{ID: "a", MovieID: 1},
+		{ID: "b", MediaType: models.MediaTypeTV, MovieID: 2},
+	}}
+
+	changed, _ := migrateWatchlist(watchlist)
+	assert.True(t, changed)
+	assert.Equal(t, models.MediaTypeMovie, watchlist.Items[0].MediaType, "items stored before TV support are movies")
+	assert.Equal(t, models.MediaTypeTV, watchlist.Items[1].MediaType)
+	assert.Equal(t, models.CurrentWatchlistSchemaVersion, watchlist.SchemaVersion)
+
+	changed, _ = migrateWatchlist(watchlist)
+	assert.False(t, changed, "migrating twice is a no-op")
+}
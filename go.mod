@@ -6,6 +6,7 @@ require github.com/gorilla/mux v1.8.1
 
 require (
 	github.com/joho/godotenv v1.5.1
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/stretchr/testify v1.10.0
 )
 